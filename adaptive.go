@@ -0,0 +1,51 @@
+package stargazer
+
+import "time"
+
+// adaptivePolls is how many times an adaptive gazer aims to poll in the time
+// it expects the repository to take to reach its next target.
+const adaptivePolls = 10
+
+// WithAdaptiveInterval is an option that can be passed to NewGitHubStargazer
+// to have the gazer choose its own interval between min and max, instead of
+// polling every Interval. The interval tightens as the star velocity rises
+// and the count nears the next target, and relaxes towards max when growth
+// stalls. A schedule set with WithSchedule takes precedence.
+func WithAdaptiveInterval(min, max time.Duration) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.adaptiveMin, sg.adaptiveMax = min, max
+	}
+}
+
+// adaptiveInterval returns the interval to poll at so that the gazer polls
+// about adaptivePolls times before the next target is expected to be reached
+// at the current velocity.
+func (sg *GitHubStargazer) adaptiveInterval() time.Duration {
+	if sg.lastSuccess.IsZero() || (sg.relative && !sg.resolved) {
+		return sg.adaptiveMin
+	}
+	next := 0
+	for _, target := range sg.targets {
+		if !sg.fired[target] {
+			next = target
+			break
+		}
+	}
+	remaining := next - sg.stargazersCount
+	if remaining <= 0 {
+		return sg.adaptiveMin
+	}
+	perHour := sg.velocity.velocity().PerHour
+	if perHour <= 0 {
+		return sg.adaptiveMax
+	}
+	eta := time.Duration(float64(remaining) / perHour * float64(time.Hour))
+	interval := eta / adaptivePolls
+	if interval < sg.adaptiveMin {
+		return sg.adaptiveMin
+	}
+	if interval > sg.adaptiveMax {
+		return sg.adaptiveMax
+	}
+	return interval
+}
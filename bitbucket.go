@@ -0,0 +1,132 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// BitbucketSource is a Source of the number of watchers or forks of a
+// repository on Bitbucket Cloud, which has no stars.
+type BitbucketSource struct {
+	// Repository is the name of the repository in workspace/repo format.
+	Repository string
+
+	// Count is what is counted. It is Watchers unless set with
+	// WithBitbucketCount.
+	Count Count
+
+	baseURL string
+	token   TokenSource
+	client  *http.Client
+	editors []RequestEditor
+}
+
+// NewBitbucketSource returns a Source of the number of watchers of repo,
+// unless another count is set with WithBitbucketCount.
+func NewBitbucketSource(repo string, options ...func(*BitbucketSource)) (*BitbucketSource, error) {
+	if repo == "" {
+		return nil, errors.New("repository must be specified")
+	}
+	bs := &BitbucketSource{
+		Repository: strings.Trim(repo, "/"),
+		Count:      Watchers,
+		baseURL:    "https://api.bitbucket.org",
+		client:     &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, o := range options {
+		o(bs)
+	}
+	switch bs.Count {
+	case Watchers, Forks:
+	default:
+		return nil, errors.Errorf("Bitbucket cannot count %s", bs.Count)
+	}
+	if _, err := url.Parse(bs.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid Bitbucket API base URL")
+	}
+	return bs, nil
+}
+
+// WithBitbucketBaseURL is an option that can be passed to NewBitbucketSource
+// to use another base URL for the Bitbucket API than
+// https://api.bitbucket.org.
+func WithBitbucketBaseURL(baseURL string) func(*BitbucketSource) {
+	return func(bs *BitbucketSource) {
+		bs.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithBitbucketHTTPClient is an option that can be passed to
+// NewBitbucketSource to make its requests with client instead of a default
+// client with a 20 second timeout.
+func WithBitbucketHTTPClient(client *http.Client) func(*BitbucketSource) {
+	return func(bs *BitbucketSource) {
+		if client != nil {
+			bs.client = client
+		}
+	}
+}
+
+// WithBitbucketRequestEditor is an option that can be passed to
+// NewBitbucketSource to have editors edit every request it makes, in order,
+// after its User-Agent is set.
+func WithBitbucketRequestEditor(editors ...RequestEditor) func(*BitbucketSource) {
+	return func(bs *BitbucketSource) {
+		bs.editors = append(bs.editors, editors...)
+	}
+}
+
+// WithBitbucketCount is an option that can be passed to NewBitbucketSource
+// to count Forks instead of Watchers.
+func WithBitbucketCount(count Count) func(*BitbucketSource) {
+	return func(bs *BitbucketSource) {
+		bs.Count = count
+	}
+}
+
+// WithBitbucketToken is an option that can be passed to NewBitbucketSource
+// to authenticate with an access token, which private repositories require.
+func WithBitbucketToken(token string) func(*BitbucketSource) {
+	return WithBitbucketTokenSource(StaticTokenSource(token))
+}
+
+// WithBitbucketTokenSource is an option that can be passed to
+// NewBitbucketSource to supply the access token from source each time it is
+// needed.
+func WithBitbucketTokenSource(source TokenSource) func(*BitbucketSource) {
+	return func(bs *BitbucketSource) {
+		bs.token = source
+	}
+}
+
+// Fetch fetches the count of the repository. Bitbucket reports the total
+// with each page of watchers or forks, so only the smallest page is
+// fetched.
+func (bs *BitbucketSource) Fetch() (int, error) {
+	endpoint := fmt.Sprintf("%s/2.0/repositories/%s/%s?pagelen=1", bs.baseURL, bs.Repository, bs.Count)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return -1, err
+	}
+	if bs.token != nil {
+		token, err := bs.token()
+		if err != nil {
+			return -1, errors.Wrap(err, "error getting Bitbucket token")
+		}
+		if token != "" {
+			req.Header.Add("Authorization", "Bearer "+token)
+		}
+	}
+	var page struct {
+		Size int `json:"size"`
+	}
+	if err := getJSON(bs.client, bs.editors, req, "Bitbucket", &page); err != nil {
+		return -1, err
+	}
+	return page.Size, nil
+}
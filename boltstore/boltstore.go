@@ -0,0 +1,195 @@
+// Package boltstore keeps the stargazers counts observed by gazers, and any
+// state their owners want to persist, in a bbolt key-value database. It is a
+// lighter alternative to sqlitestore with the same methods.
+package boltstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	statesBucket  = []byte("states")
+	samplesBucket = []byte("samples")
+)
+
+// Store is a bbolt-backed history of stargazers counts and store of state. It
+// is safe for concurrent use.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the bbolt database at path, creating it if necessary.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening database")
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(statesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(samplesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "error creating buckets")
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record adds a sample to the history of repo. Samples are kept in a bucket
+// per repo, keyed by time so that they can be scanned in order.
+func (s *Store) Record(repo string, sample stargazer.Sample) error {
+	return errors.Wrap(s.record(repo, sample), "error recording sample")
+}
+
+// RecordAll adds samples to the history of repo in a single transaction.
+func (s *Store) RecordAll(repo string, samples []stargazer.Sample) error {
+	return errors.Wrap(s.record(repo, samples...), "error recording samples")
+}
+
+func (s *Store) record(repo string, samples ...stargazer.Sample) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(samplesBucket).CreateBucketIfNotExists([]byte(repo))
+		if err != nil {
+			return err
+		}
+		for _, sample := range samples {
+			if err := b.Put(timeKey(sample.Time), countValue(sample.Count)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// History returns the samples recorded for repo between from and to, oldest
+// first.
+func (s *Store) History(repo string, from, to time.Time) ([]stargazer.Sample, error) {
+	var samples []stargazer.Sample
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(samplesBucket).Bucket([]byte(repo))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Seek(timeKey(from)); k != nil; k, v = c.Next() {
+			t := time.Unix(0, int64(binary.BigEndian.Uint64(k)))
+			if t.After(to) {
+				break
+			}
+			count, _ := binary.Varint(v)
+			samples = append(samples, stargazer.Sample{Time: t, Count: int(count)})
+		}
+		return nil
+	})
+	return samples, errors.Wrap(err, "error querying history")
+}
+
+// Compact reduces the samples recorded before rollupBefore to the last one in
+// each period of the given resolution, and deletes those recorded before
+// dropBefore. A zero time skips that step.
+func (s *Store) Compact(rollupBefore, dropBefore time.Time, resolution time.Duration) error {
+	var rollupKey, dropKey []byte
+	if !rollupBefore.IsZero() {
+		rollupKey = timeKey(rollupBefore)
+	}
+	if !dropBefore.IsZero() {
+		dropKey = timeKey(dropBefore)
+	}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		samples := tx.Bucket(samplesBucket)
+		return samples.ForEachBucket(func(repo []byte) error {
+			b := samples.Bucket(repo)
+			// Deleting while iterating with a cursor skips keys, so collect
+			// the keys to delete first.
+			var (
+				doomed     [][]byte
+				prev       []byte
+				prevPeriod uint64
+			)
+			c := b.Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				k = append([]byte(nil), k...)
+				switch {
+				case dropKey != nil && bytes.Compare(k, dropKey) < 0:
+					doomed = append(doomed, k)
+				case rollupKey != nil && bytes.Compare(k, rollupKey) < 0:
+					period := binary.BigEndian.Uint64(k) / uint64(resolution)
+					if prev != nil && period == prevPeriod {
+						doomed = append(doomed, prev)
+					}
+					prev, prevPeriod = k, period
+				default:
+					return deleteKeys(b, doomed)
+				}
+			}
+			return deleteKeys(b, doomed)
+		})
+	})
+	return errors.Wrap(err, "error compacting history")
+}
+
+func deleteKeys(b *bolt.Bucket, keys [][]byte) error {
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PutState stores value under key, replacing any value already stored there.
+func (s *Store) PutState(key string, value []byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(statesBucket).Put([]byte(key), value)
+	})
+	return errors.Wrap(err, "error storing state")
+}
+
+// States returns every stored state by key.
+func (s *Store) States() (map[string][]byte, error) {
+	states := make(map[string][]byte)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(statesBucket).ForEach(func(k, v []byte) error {
+			states[string(k)] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	return states, errors.Wrap(err, "error reading states")
+}
+
+// DeleteState removes the state stored under key.
+func (s *Store) DeleteState(key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(statesBucket).Delete([]byte(key))
+	})
+	return errors.Wrap(err, "error deleting state")
+}
+
+// timeKey encodes t so that keys sort in time order. Times before the Unix
+// epoch are encoded as the epoch.
+func timeKey(t time.Time) []byte {
+	k := make([]byte, 8)
+	if t.After(time.Unix(0, 0)) {
+		binary.BigEndian.PutUint64(k, uint64(t.UnixNano()))
+	}
+	return k
+}
+
+func countValue(count int) []byte {
+	v := make([]byte, binary.MaxVarintLen64)
+	return v[:binary.PutVarint(v, int64(count))]
+}
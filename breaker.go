@@ -0,0 +1,109 @@
+package stargazer
+
+import "time"
+
+// CircuitState is the state of a gazer's circuit breaker.
+type CircuitState string
+
+// States of a circuit breaker. A closed circuit lets every poll through. It
+// opens after too many fetches in a row fail, and lets no polls through until
+// its cooldown has passed. It is then half-open, and lets one poll through
+// to probe whether GitHub has recovered: the circuit closes if it succeeds,
+// and opens again if it fails.
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// Circuit is the state of a gazer's circuit breaker.
+type Circuit struct {
+	State CircuitState `json:"state"`
+
+	// OpenedAt is when the circuit last opened after being closed, and
+	// RetryAt when it half-opens to probe, while it is open.
+	OpenedAt time.Time `json:"opened_at"`
+	RetryAt  time.Time `json:"retry_at"`
+}
+
+// WithCircuitBreaker is an option that can be passed to NewGitHubStargazer to
+// stop polling after failures fetches in a row fail, rather than keep
+// hammering an API that is down or refusing the gazer, and to probe with a
+// single poll every cooldown until one succeeds. The gazer sends a Degraded
+// event when the circuit opens, and a Recovered event when it closes again.
+func WithCircuitBreaker(failures int, cooldown time.Duration) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.breakerFailures, sg.breakerCooldown = failures, cooldown
+		sg.circuit.State = CircuitClosed
+	}
+}
+
+// Circuit returns the state of the gazer's circuit breaker. It returns false
+// if the gazer has no circuit breaker.
+func (sg *GitHubStargazer) Circuit() (Circuit, bool) {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.circuit, sg.breakerFailures > 0
+}
+
+// circuitAllows reports whether the circuit breaker lets a poll through now,
+// half-opening it if its cooldown has passed.
+func (sg *GitHubStargazer) circuitAllows() bool {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	if sg.breakerFailures == 0 || sg.circuit.State != CircuitOpen {
+		return true
+	}
+	if sg.clock.Now().Before(sg.circuit.RetryAt) {
+		return false
+	}
+	sg.circuit.State = CircuitHalfOpen
+	sg.log.Infow("probing after circuit breaker cooldown", "repo", sg.Repository)
+	return true
+}
+
+// circuitFailed records the failed fetch f, opening the circuit if it was
+// half-open or too many fetches in a row have failed.
+func (sg *GitHubStargazer) circuitFailed(f Failure) {
+	sg.mu.Lock()
+	if sg.breakerFailures == 0 ||
+		(sg.circuit.State == CircuitClosed && f.ConsecutiveFailures < sg.breakerFailures) {
+		sg.mu.Unlock()
+		return
+	}
+	now := sg.clock.Now()
+	opened := sg.circuit.State == CircuitClosed
+	if opened {
+		sg.circuit.OpenedAt = now
+	}
+	sg.circuit.State = CircuitOpen
+	sg.circuit.RetryAt = now.Add(sg.breakerCooldown)
+	sg.mu.Unlock()
+	sg.metrics.Gauge(MetricCircuitOpen, 1, "repo", sg.Repository)
+	if !opened {
+		return
+	}
+	sg.log.Warnw("circuit breaker opened; polling suspended",
+		"repo", sg.Repository,
+		"consecutive_failures", f.ConsecutiveFailures,
+		"cooldown", sg.breakerCooldown,
+		"err", f.Err.Error())
+	sg.emit(Degraded{Failure: f, Time: now})
+}
+
+// circuitSucceeded records a successful fetch, closing the circuit if it
+// wasn't closed.
+func (sg *GitHubStargazer) circuitSucceeded() {
+	sg.mu.Lock()
+	if sg.breakerFailures == 0 || sg.circuit.State == CircuitClosed {
+		sg.mu.Unlock()
+		return
+	}
+	now := sg.clock.Now()
+	down := now.Sub(sg.circuit.OpenedAt)
+	sg.circuit = Circuit{State: CircuitClosed}
+	sg.mu.Unlock()
+	sg.metrics.Gauge(MetricCircuitOpen, 0, "repo", sg.Repository)
+	sg.log.Infow("circuit breaker closed; polling resumed", "repo", sg.Repository, "down", down)
+	sg.emit(Recovered{Repository: sg.Repository, Time: now, Down: down})
+}
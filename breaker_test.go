@@ -0,0 +1,160 @@
+package stargazer_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/ianfoo/github-stargazer/githubtest"
+)
+
+// fakeClock is a Clock whose time only passes when it is advanced, or slept
+// through. Gazers given it can poll and be primed, but not gaze, as it
+// can't make tickers or timers.
+type fakeClock struct {
+	stargazer.Clock
+
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.advance(d)
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	// poll is a poll made after advance, which fails if fail is set or is
+	// rate limited if rateLimited is, and whether it should reach GitHub and
+	// what state it should leave the circuit in.
+	type poll struct {
+		advance     time.Duration
+		fail        bool
+		rateLimited bool
+		wantRequest bool
+		wantState   stargazer.CircuitState
+	}
+	tests := []struct {
+		name       string
+		polls      []poll
+		wantEvents []string
+	}{
+		{
+			name: "stays closed while polls succeed",
+			polls: []poll{
+				{wantRequest: true, wantState: stargazer.CircuitClosed},
+				{fail: true, wantRequest: true, wantState: stargazer.CircuitClosed},
+				{wantRequest: true, wantState: stargazer.CircuitClosed},
+				{fail: true, wantRequest: true, wantState: stargazer.CircuitClosed},
+			},
+		},
+		{
+			name: "opens after failures in a row",
+			polls: []poll{
+				{fail: true, wantRequest: true, wantState: stargazer.CircuitClosed},
+				{fail: true, wantRequest: true, wantState: stargazer.CircuitOpen},
+				{wantState: stargazer.CircuitOpen},
+				{advance: 59 * time.Second, wantState: stargazer.CircuitOpen},
+			},
+			wantEvents: []string{"degraded"},
+		},
+		{
+			name: "opens again when the probe fails",
+			polls: []poll{
+				{fail: true, wantRequest: true, wantState: stargazer.CircuitClosed},
+				{fail: true, wantRequest: true, wantState: stargazer.CircuitOpen},
+				{advance: time.Minute, fail: true, wantRequest: true, wantState: stargazer.CircuitOpen},
+				{advance: 30 * time.Second, wantState: stargazer.CircuitOpen},
+			},
+			wantEvents: []string{"degraded"},
+		},
+		{
+			name: "rate limits don't open it",
+			polls: []poll{
+				{rateLimited: true, wantRequest: true, wantState: stargazer.CircuitClosed},
+				{advance: time.Minute, rateLimited: true, wantRequest: true, wantState: stargazer.CircuitClosed},
+				{advance: time.Minute, rateLimited: true, wantRequest: true, wantState: stargazer.CircuitClosed},
+				{advance: time.Minute, wantRequest: true, wantState: stargazer.CircuitClosed},
+			},
+		},
+		{
+			name: "closes when the probe succeeds",
+			polls: []poll{
+				{fail: true, wantRequest: true, wantState: stargazer.CircuitClosed},
+				{fail: true, wantRequest: true, wantState: stargazer.CircuitOpen},
+				{advance: time.Minute, wantRequest: true, wantState: stargazer.CircuitClosed},
+				{fail: true, wantRequest: true, wantState: stargazer.CircuitClosed},
+			},
+			wantEvents: []string{"degraded", "recovered"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := githubtest.NewServer()
+			defer srv.Close()
+			srv.SetStargazers("matryer/moq", 10)
+			clock := newFakeClock()
+			sg, err := stargazer.NewGitHubStargazer("matryer/moq", 100, time.Minute, nil,
+				stargazer.WithGitHubBaseURL(srv.URL),
+				stargazer.WithClock(clock),
+				stargazer.WithCircuitBreaker(2, time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			events := sg.Events()
+
+			for i, p := range tt.polls {
+				clock.advance(p.advance)
+				if p.fail {
+					srv.RequireToken("secret")
+				} else {
+					srv.RequireToken("")
+				}
+				if p.rateLimited {
+					srv.SetRateLimit(60, 0, time.Now().Add(time.Hour))
+				} else {
+					srv.SetRateLimit(0, 0, time.Time{})
+				}
+				// Change the count so that no response comes from the cache.
+				srv.AddStargazers("matryer/moq", 1)
+				requests := srv.Requests()
+				sg.Poll()
+				if got := srv.Requests() > requests; got != p.wantRequest {
+					t.Errorf("poll %d reached GitHub: %v, want %v", i, got, p.wantRequest)
+				}
+				if c, _ := sg.Circuit(); c.State != p.wantState {
+					t.Errorf("poll %d left the circuit %s, want %s", i, c.State, p.wantState)
+				}
+			}
+			var got []string
+			for len(events) > 0 {
+				switch (<-events).(type) {
+				case stargazer.Degraded:
+					got = append(got, "degraded")
+				case stargazer.Recovered:
+					got = append(got, "recovered")
+				}
+			}
+			if !slices.Equal(got, tt.wantEvents) {
+				t.Errorf("got events %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
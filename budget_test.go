@@ -0,0 +1,143 @@
+package stargazer_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/ianfoo/github-stargazer/githubtest"
+)
+
+// gauges is a Metrics that keeps the last value of each gauge.
+type gauges struct {
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+func (g *gauges) Counter(name string, delta int64, tags ...string)   {}
+func (g *gauges) Timer(name string, d time.Duration, tags ...string) {}
+
+func (g *gauges) Gauge(name string, value float64, tags ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[name] = value
+}
+
+func (g *gauges) get(name string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.values[name]
+}
+
+func TestSchedulerBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget int
+		batch  bool
+		polls  int
+
+		// stop has the scheduler stopped before the last poll, which fails
+		// if it has to wait for the budget.
+		stop bool
+
+		wantErr       bool
+		wantRequests  int
+		wantUsed      float64
+		wantRemaining float64
+	}{
+		{
+			name:          "polls count against the budget",
+			budget:        10,
+			polls:         2,
+			wantRequests:  2,
+			wantUsed:      2,
+			wantRemaining: 8,
+		},
+		{
+			name:          "GraphQL batch requests count against the budget",
+			budget:        10,
+			batch:         true,
+			polls:         1,
+			wantRequests:  1,
+			wantUsed:      1,
+			wantRemaining: 9,
+		},
+		{
+			name:          "poll waits once the budget is used up",
+			budget:        1,
+			polls:         2,
+			stop:          true,
+			wantErr:       true,
+			wantRequests:  1,
+			wantUsed:      1,
+			wantRemaining: 0,
+		},
+		{
+			name:          "batch waits once the budget is used up",
+			budget:        1,
+			batch:         true,
+			polls:         2,
+			stop:          true,
+			wantErr:       true,
+			wantRequests:  1,
+			wantUsed:      1,
+			wantRemaining: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := githubtest.NewServer()
+			defer srv.Close()
+			srv.SetStargazers("matryer/moq", 99)
+
+			metrics := &gauges{values: make(map[string]float64)}
+			scheduler, err := stargazer.NewScheduler(
+				stargazer.WithHourlyBudget(tt.budget),
+				stargazer.WithSchedulerMetrics(metrics))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer scheduler.Stop()
+			options := []func(*stargazer.GitHubStargazer){
+				stargazer.WithGitHubBaseURL(srv.URL),
+				stargazer.WithScheduler(scheduler),
+			}
+			if tt.batch {
+				batch, err := stargazer.NewGraphQLBatch(
+					stargazer.WithGraphQLBaseURL(srv.URL),
+					stargazer.WithGraphQLTokenSource(stargazer.StaticTokenSource("token")),
+					stargazer.WithGraphQLMaxAge(0),
+					stargazer.WithGraphQLScheduler(scheduler))
+				if err != nil {
+					t.Fatal(err)
+				}
+				options = append(options, stargazer.WithGraphQLBatch(batch))
+			}
+			sg, err := stargazer.NewGitHubStargazer("matryer/moq", 100, time.Minute, nil, options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < tt.polls; i++ {
+				if tt.stop && i == tt.polls-1 {
+					scheduler.Stop()
+				}
+				srv.AddStargazers("matryer/moq", 1)
+				err = sg.Prime()
+			}
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if got := srv.Requests(); got != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", got, tt.wantRequests)
+			}
+			if got := metrics.get(stargazer.MetricSchedulerBudgetUsed); got != tt.wantUsed {
+				t.Errorf("got %v of the budget used, want %v", got, tt.wantUsed)
+			}
+			if got := metrics.get(stargazer.MetricSchedulerBudgetRemaining); got != tt.wantRemaining {
+				t.Errorf("got %v of the budget remaining, want %v", got, tt.wantRemaining)
+			}
+		})
+	}
+}
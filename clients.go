@@ -0,0 +1,37 @@
+package stargazer
+
+//go:generate moq -out stargazermock/mocks.go -pkg stargazermock . GitHubClient SMSSender
+
+// GitHubClient is what a GitHubStargazer does with GitHub on its owner's
+// behalf, besides gazing: fetching the count, starring and unstarring the
+// repository, checking whether it is starred and fetching its star history. Code that only needs these can take a
+// GitHubClient, to be tested with stargazermock.GitHubClientMock instead of
+// a gazer talking to GitHub.
+type GitHubClient interface {
+	Fetch() (int, error)
+	Star() error
+	StarHistory() ([]Sample, error)
+	Starred() (bool, error)
+	Unstar() error
+}
+
+// SMSSender sends SMS messages, as TwilioSMSSender does. Hooks that send
+// messages can take an SMSSender, to be tested with
+// stargazermock.SMSSenderMock instead of Twilio.
+type SMSSender interface {
+	Send(to, message string) error
+}
+
+// MMSSender is an SMSSender that can also attach images to its messages, as
+// TwilioSMSSender can. Code that takes an SMSSender can check whether it is
+// an MMSSender before attaching images.
+type MMSSender interface {
+	SMSSender
+	SendMedia(to, message string, mediaURLs ...string) error
+}
+
+var (
+	_ GitHubClient = (*GitHubStargazer)(nil)
+	_ SMSSender    = TwilioSMSSender{}
+	_ MMSSender    = TwilioSMSSender{}
+)
@@ -0,0 +1,60 @@
+package stargazer
+
+import "time"
+
+// Clock tells a gazer the time and makes the tickers and timers it waits on,
+// so that tests can control the passing of time instead of sleeping. The
+// default Clock uses the time package.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	NewTicker(d time.Duration) Ticker
+	NewTimer(d time.Duration) Timer
+}
+
+// Ticker is a time.Ticker made by a Clock.
+type Ticker interface {
+	C() <-chan time.Time
+	Reset(d time.Duration)
+	Stop()
+}
+
+// Timer is a time.Timer made by a Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// WithClock is an option that can be passed to NewGitHubStargazer to have the
+// gazer tell the time, tick for its polls, heartbeats, reports and deadline,
+// and back off between hook retries with clock. A Scheduler given with
+// WithScheduler keeps to its own clock, set with WithSchedulerClock.
+func WithClock(clock Clock) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		if clock != nil {
+			sg.clock = clock
+		}
+	}
+}
+
+// realClock is the Clock of the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// outageAlerter watches the notifications sent through a channel, and alerts
+// through a fallback webhook when after of them in a row have failed, so
+// that whoever runs the watcher learns that the channel is broken before a
+// milestone is missed. It alerts again once a notification gets through. A
+// nil outageAlerter alerts nobody.
+type outageAlerter struct {
+	log     *zap.SugaredLogger
+	webhook string
+	client  *http.Client
+	after   int
+
+	mu       sync.Mutex
+	failures map[string]int
+	down     map[string]time.Time
+}
+
+// newOutageAlerter returns an alerter that posts to webhook, or nil if
+// webhook is empty.
+func newOutageAlerter(log *zap.SugaredLogger, webhook string, after int, client *http.Client) (*outageAlerter, error) {
+	if webhook == "" {
+		return nil, nil
+	}
+	if after < 1 {
+		return nil, errors.New("-alert-after must be at least 1")
+	}
+	return &outageAlerter{
+		log:      log,
+		webhook:  webhook,
+		client:   client,
+		after:    after,
+		failures: make(map[string]int),
+		down:     make(map[string]time.Time),
+	}, nil
+}
+
+// record records the result of sending a notification through channel,
+// alerting if the channel has gone down or come back up, and writing the
+// alert to events.
+func (a *outageAlerter) record(channel string, err error, events *eventLog) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	if err == nil {
+		downSince, wasDown := a.down[channel]
+		delete(a.failures, channel)
+		delete(a.down, channel)
+		a.mu.Unlock()
+		if wasDown {
+			a.alert(events, eventRecord{
+				Time:    time.Now(),
+				Type:    "channel_up",
+				Channel: channel,
+				Message: fmt.Sprintf("github-stargazer: notifications through %s are working again after %s",
+					channel, time.Since(downSince).Round(time.Second)),
+			})
+		}
+		return
+	}
+	a.failures[channel]++
+	failures := a.failures[channel]
+	_, wasDown := a.down[channel]
+	if failures < a.after || wasDown {
+		a.mu.Unlock()
+		return
+	}
+	a.down[channel] = time.Now()
+	a.mu.Unlock()
+	a.alert(events, eventRecord{
+		Time:     time.Now(),
+		Type:     "channel_down",
+		Channel:  channel,
+		Failures: failures,
+		Error:    err.Error(),
+		Message: fmt.Sprintf("github-stargazer: %d notifications in a row through %s have failed, the last with: %s",
+			failures, channel, err),
+	})
+}
+
+// alert logs the channel event rec, writes it to events and posts its
+// message to the webhook.
+func (a *outageAlerter) alert(events *eventLog, rec eventRecord) {
+	if rec.Type == "channel_down" {
+		a.log.Errorw("notification channel is down", "channel", rec.Channel, "failures", rec.Failures, "err", rec.Error)
+	} else {
+		a.log.Infow("notification channel is back up", "channel", rec.Channel)
+	}
+	if err := events.write(rec); err != nil {
+		a.log.Warnw("unable to record channel event in event log", "channel", rec.Channel, "err", err)
+	}
+	result := eventRecord{
+		Time:    time.Now(),
+		Type:    "notification",
+		Channel: "webhook",
+		To:      a.webhook,
+		Message: rec.Message,
+		Result:  "sent",
+	}
+	if err := a.post(rec); err != nil {
+		a.log.Errorw("unable to send notification channel alert", "channel", rec.Channel, "err", err)
+		result.Result, result.Error = "failed", err.Error()
+	}
+	if err := events.write(result); err != nil {
+		a.log.Warnw("unable to record alert in event log", "channel", rec.Channel, "err", err)
+	}
+}
+
+// post posts rec to the webhook as JSON. Its message is the text field, as
+// Slack and Mattermost incoming webhooks expect.
+func (a *outageAlerter) post(rec eventRecord) error {
+	return postJSON(a.client, a.webhook, map[string]interface{}{
+		"text":     rec.Message,
+		"type":     rec.Type,
+		"channel":  rec.Channel,
+		"failures": rec.Failures,
+		"error":    rec.Error,
+		"time":     rec.Time,
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// auditRecord is an attempt to send a notification, as kept in the audit log.
+type auditRecord struct {
+	Repository string    `json:"repository"`
+	Channel    string    `json:"channel"`
+	To         string    `json:"to"`
+	Message    string    `json:"message"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+	Attempted  time.Time `json:"attempted"`
+	Completed  time.Time `json:"completed"`
+}
+
+// auditLog keeps every notification attempt in a file of JSON lines. With an
+// empty path, nothing is kept.
+type auditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (al *auditLog) append(rec auditRecord) error {
+	if al == nil || al.path == "" {
+		return nil
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	f, err := os.OpenFile(al.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "error opening audit log")
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return errors.Wrap(err, "error writing audit log")
+	}
+	return errors.Wrap(f.Close(), "error writing audit log")
+}
+
+// query returns the records for repo, or for every repo if it is empty, of
+// the attempts made between from and to, oldest first.
+func (al *auditLog) query(repo string, from, to time.Time) ([]auditRecord, error) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	f, err := os.Open(al.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening audit log")
+	}
+	defer f.Close()
+	var records []auditRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var rec auditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, errors.Wrap(err, "error decoding audit log")
+		}
+		if repo != "" && watchKey(rec.Repository) != watchKey(repo) {
+			continue
+		}
+		if rec.Attempted.Before(from) || rec.Attempted.After(to) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, errors.Wrap(scanner.Err(), "error reading audit log")
+}
+
+// handleNotifications serves GET /notifications, listing the notification
+// attempts in the audit log. The repo, from and to query parameters narrow
+// the list.
+func (s *statusServer) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	q := r.URL.Query()
+	from, to, err := parseTimeRange(q.Get("from"), q.Get("to"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	records, err := s.audit.query(q.Get("repo"), from, to)
+	if err != nil {
+		s.log.Warnw("unable to read audit log", "err", err)
+		s.writeError(w, http.StatusInternalServerError, "unable to read audit log")
+		return
+	}
+	if records == nil {
+		records = []auditRecord{}
+	}
+	s.writeJSON(w, http.StatusOK, records)
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+)
+
+// The size of a chart, and the margins around its plot that hold the title
+// and the labels of its axes.
+const (
+	chartWidth        = 800
+	chartHeight       = 400
+	chartMarginLeft   = 70
+	chartMarginRight  = 20
+	chartMarginTop    = 40
+	chartMarginBottom = 40
+
+	// chartTicks is how many lines divide the count axis of a chart.
+	chartTicks = 4
+)
+
+// defaultChartDays is how many days of history a chart covers unless its
+// request asks for another number.
+const defaultChartDays = 30
+
+// chartKinds are the kinds of event whose notifications have a chart of the
+// repository's history attached, if -public-url is set.
+var chartKinds = map[string]bool{
+	"target":    true,
+	"milestone": true,
+	"progress":  true,
+	"velocity":  true,
+	"report":    true,
+	"overtook":  true,
+	"overtaken": true,
+}
+
+var (
+	chartBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	chartGrid       = color.RGBA{0xe1, 0xe4, 0xe8, 0xff}
+	chartAxis       = color.RGBA{0x58, 0x60, 0x69, 0xff}
+	chartLine       = color.RGBA{0x03, 0x66, 0xd6, 0xff}
+)
+
+// chartPoint is a sample placed on a chart, in pixels from its top left.
+type chartPoint struct {
+	x, y int
+}
+
+// chart is a star history chart of a repository, laid out for rendering.
+type chart struct {
+	repo     string
+	from, to time.Time
+	min, max int
+	points   []chartPoint
+	last     int
+}
+
+// newChart lays out samples, which must not be empty, in a chart of repo.
+// The time axis spans the samples, and the count axis their counts.
+func newChart(repo string, samples []stargazer.Sample) chart {
+	c := chart{
+		repo: repo,
+		from: samples[0].Time,
+		to:   samples[len(samples)-1].Time,
+		min:  samples[0].Count,
+		max:  samples[0].Count,
+		last: samples[len(samples)-1].Count,
+	}
+	for _, s := range samples {
+		if s.Count < c.min {
+			c.min = s.Count
+		}
+		if s.Count > c.max {
+			c.max = s.Count
+		}
+	}
+	if c.max == c.min {
+		// A flat history is drawn across the middle of the chart.
+		if c.min > 0 {
+			c.min--
+		}
+		c.max++
+	}
+	span := c.to.Sub(c.from)
+	for _, s := range samples {
+		// A single sample, or several at the same time, are drawn
+		// across the whole chart.
+		x := 0.0
+		if span > 0 {
+			x = float64(s.Time.Sub(c.from)) / float64(span)
+		}
+		c.points = append(c.points, chartPoint{
+			x: chartMarginLeft + int(x*float64(plotWidth())),
+			y: c.countY(s.Count),
+		})
+	}
+	if len(c.points) == 1 || span == 0 {
+		c.points = []chartPoint{
+			{chartMarginLeft, c.points[0].y},
+			{chartMarginLeft + plotWidth(), c.points[len(c.points)-1].y},
+		}
+	}
+	return c
+}
+
+func plotWidth() int  { return chartWidth - chartMarginLeft - chartMarginRight }
+func plotHeight() int { return chartHeight - chartMarginTop - chartMarginBottom }
+
+// countY returns where count is on the count axis of the chart.
+func (c chart) countY(count int) int {
+	y := float64(count-c.min) / float64(c.max-c.min)
+	return chartMarginTop + plotHeight() - int(y*float64(plotHeight()))
+}
+
+// ticks returns the counts at the lines that divide the count axis, from
+// the least to the greatest.
+func (c chart) ticks() []int {
+	ticks := make([]int, 0, chartTicks+1)
+	for i := 0; i <= chartTicks; i++ {
+		tick := c.min + (c.max-c.min)*i/chartTicks
+		if len(ticks) > 0 && ticks[len(ticks)-1] == tick {
+			continue
+		}
+		ticks = append(ticks, tick)
+	}
+	return ticks
+}
+
+// writeSVG renders the chart as SVG, with its title and labelled axes.
+func (c chart) writeSVG(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %[1]d %[2]d" font-family="sans-serif" font-size="12">`+"\n", chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColor(chartBackground))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="16" fill="%s">%s · %d</text>`+"\n",
+		chartMarginLeft, chartMarginTop-16, hexColor(chartAxis), html.EscapeString(c.repo), c.last)
+	right := chartMarginLeft + plotWidth()
+	for _, tick := range c.ticks() {
+		y := c.countY(tick)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"/>`+"\n", chartMarginLeft, y, right, y, hexColor(chartGrid))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" fill="%s">%d</text>`+"\n", chartMarginLeft-8, y+4, hexColor(chartAxis), tick)
+	}
+	bottom := chartMarginTop + plotHeight()
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"/>`+"\n", chartMarginLeft, bottom, right, bottom, hexColor(chartAxis))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s">%s</text>`+"\n", chartMarginLeft, bottom+20, hexColor(chartAxis), c.date(c.from))
+	fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" fill="%s">%s</text>`+"\n", right, bottom+20, hexColor(chartAxis), c.date(c.to))
+	points := make([]string, 0, len(c.points))
+	for _, p := range c.points {
+		points = append(points, fmt.Sprintf("%d,%d", p.x, p.y))
+	}
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2" stroke-linejoin="round"/>`+"\n",
+		strings.Join(points, " "), hexColor(chartLine))
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writePNG renders the chart as a PNG. As the standard library has no fonts
+// to draw text with, the PNG has no title or labels, only the grid and the
+// line of the history, for channels like MMS that can't show SVG.
+func (c chart) writePNG(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{chartBackground}, image.Point{}, draw.Src)
+	right := chartMarginLeft + plotWidth()
+	for _, tick := range c.ticks() {
+		y := c.countY(tick)
+		drawLine(img, chartPoint{chartMarginLeft, y}, chartPoint{right, y}, 1, chartGrid)
+	}
+	bottom := chartMarginTop + plotHeight()
+	drawLine(img, chartPoint{chartMarginLeft, bottom}, chartPoint{right, bottom}, 1, chartAxis)
+	for i := 1; i < len(c.points); i++ {
+		drawLine(img, c.points[i-1], c.points[i], 3, chartLine)
+	}
+	return png.Encode(w, img)
+}
+
+// drawLine draws a line width pixels wide from a to b on img.
+func drawLine(img *image.RGBA, a, b chartPoint, width int, c color.Color) {
+	dx, dy := abs(b.x-a.x), -abs(b.y-a.y)
+	sx, sy := 1, 1
+	if a.x > b.x {
+		sx = -1
+	}
+	if a.y > b.y {
+		sy = -1
+	}
+	pen := image.Rect(-width/2, -width/2, width-width/2, width-width/2)
+	for x, y, e := a.x, a.y, dx+dy; ; {
+		draw.Draw(img, pen.Add(image.Pt(x, y)), &image.Uniform{c}, image.Point{}, draw.Src)
+		if x == b.x && y == b.y {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// date labels t on the time axis of the chart, with the time of day if the
+// chart covers less than a few days.
+func (c chart) date(t time.Time) string {
+	if c.to.Sub(c.from) < 72*time.Hour {
+		return t.UTC().Format("Jan 2 15:04 MST")
+	}
+	return t.UTC().Format("Jan 2 2006")
+}
+
+// handleChart serves a chart of the history of a repo, at
+// /chart/owner/repo.svg or /chart/owner/repo.png, covering the last 30 days
+// unless the days parameter asks for another number.
+func (s *statusServer) handleChart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	repo := strings.Trim(strings.TrimPrefix(r.URL.Path, "/chart/"), "/")
+	format := repo[strings.LastIndex(repo, ".")+1:]
+	if format != "png" && format != "svg" {
+		s.writeError(w, http.StatusNotFound, "chart must be .png or .svg")
+		return
+	}
+	repo = strings.TrimSuffix(repo, "."+format)
+	days := defaultChartDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n <= 0 {
+			s.writeError(w, http.StatusBadRequest, "days must be a positive number")
+			return
+		}
+		days = n
+	}
+	now := time.Now()
+	samples, err := s.historyBetween(repo, now.AddDate(0, 0, -days), now)
+	if err != nil && err != errNoHistory {
+		s.log.Warnw("unable to read history", "repo", repo, "err", err)
+		s.writeError(w, http.StatusInternalServerError, "unable to read history")
+		return
+	}
+	if len(samples) == 0 {
+		s.writeError(w, http.StatusNotFound, "no history for "+repo)
+		return
+	}
+	c := newChart(repo, samples)
+	w.Header().Set("Cache-Control", "max-age=60")
+	if format == "png" {
+		w.Header().Set("Content-Type", "image/png")
+		err = c.writePNG(w)
+	} else {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		err = c.writeSVG(w)
+	}
+	if err != nil {
+		s.log.Warnw("unable to write response", "err", err)
+	}
+}
+
+// chartFor returns the URL of the chart to attach to a notification of kind
+// of event about the watch of spec, or "" if none is to be attached.
+func (n *notifier) chartFor(spec watchSpec, kind string) string {
+	if n.publicURL == "" || spec.Repo == "" || !chartKinds[kind] {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(spec.Repo, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	// The time makes the URL of each chart unique, so that channels that
+	// cache images by URL, like Slack, show the history as it is now.
+	return fmt.Sprintf("%s/chart/%s.png?at=%d", n.publicURL, strings.Join(segments, "/"), time.Now().Unix())
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Exit statuses of check, for scripts to act on.
+const (
+	checkTargetMet    = 0
+	checkTargetNotMet = 1
+	checkFailed       = 2
+)
+
+// check fetches the stargazers count of a repository once and prints it,
+// along with whether the target has been met if one is given. It exits with
+// checkTargetNotMet if the target hasn't been met, and checkFailed if the
+// count couldn't be fetched.
+func check(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	var (
+		repo      = fs.String("repo", "", "Repository to check (owner/repo, or its URL)")
+		provider  = fs.String("provider", "", "Where the repo is: github, gitlab, gitea, bitbucket, npm, crates, pypi or go (default from the -repo URL, or github)")
+		counting  = fs.String("count", "", "What to count instead of the provider's default, such as forks or downloads (see the README for each provider's counts)")
+		target    = fs.Int("target", 0, "Target number of stargazers to exit unsuccessfully if not met (0 for none)")
+		apiURL    = fs.String("github-url", "", "Base URL of a GitHub Enterprise Server instance (default github.com)")
+		tokenFile = fs.String("github-token-file", "", "File to read the GitHub token from")
+		proxy     = fs.String("proxy", "", proxyUsage)
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return exitStatus{checkFailed, err}
+	}
+	if *repo == "" {
+		return exitStatus{checkFailed, errors.New("repo is required")}
+	}
+	if *target < 0 {
+		return exitStatus{checkFailed, errors.New("target stargazers must not be negative")}
+	}
+	spec, err := resolveProvider(watchSpec{Repo: *repo, Provider: *provider, Count: *counting})
+	if err != nil {
+		return exitStatus{checkFailed, err}
+	}
+	client, err := httpClient(*proxy)
+	if err != nil {
+		return exitStatus{checkFailed, err}
+	}
+	n := &notifier{
+		gitlabToken:    tokenSource("", envGitLabToken),
+		giteaToken:     tokenSource("", envGiteaToken),
+		bitbucketToken: tokenSource("", envBitbucketToken),
+		client:         client,
+	}
+	sourceOptions, err := n.sourceOptions(spec)
+	if err != nil {
+		return exitStatus{checkFailed, err}
+	}
+	// The gazer is only used to fetch the count, so its target doesn't
+	// matter.
+	gazer, err := stargazer.NewGitHubStargazer(spec.Repo, 1, 0, nil,
+		append(githubOptions(zap.NewNop().Sugar(), *apiURL, *tokenFile, client), sourceOptions...)...)
+	if err != nil {
+		return exitStatus{checkFailed, err}
+	}
+	count, err := gazer.Fetch()
+	if err != nil {
+		return exitStatus{checkFailed, err}
+	}
+	switch {
+	case *target == 0:
+		fmt.Println(count)
+	case count >= *target:
+		fmt.Printf("%d (target %d met)\n", count, *target)
+	default:
+		fmt.Printf("%d (%d short of target %d)\n", count, *target-count, *target)
+		return exitStatus{code: checkTargetNotMet}
+	}
+	return nil
+}
+
+// unstar removes the star of the GitHub token's user from a repository,
+// undoing the star that a watch adds when it reaches its target.
+func unstar(args []string) error {
+	fs := flag.NewFlagSet("unstar", flag.ExitOnError)
+	var (
+		repo      = fs.String("repo", "", "Repository to unstar (owner/repo, or its URL)")
+		apiURL    = fs.String("github-url", "", "Base URL of a GitHub Enterprise Server instance (default github.com)")
+		tokenFile = fs.String("github-token-file", "", "File to read the GitHub token from")
+		proxy     = fs.String("proxy", "", proxyUsage)
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *repo == "" {
+		return errors.New("repo is required")
+	}
+	spec, err := resolveProvider(watchSpec{Repo: *repo})
+	if err != nil {
+		return err
+	}
+	if spec.Provider != providerGitHub {
+		return errors.Errorf("cannot unstar %s: only GitHub repositories can be starred", spec.Repo)
+	}
+	client, err := httpClient(*proxy)
+	if err != nil {
+		return err
+	}
+	gazer, err := stargazer.NewGitHubStargazer(spec.Repo, 1, 0, nil,
+		githubOptions(zap.NewNop().Sugar(), *apiURL, *tokenFile, client)...)
+	if err != nil {
+		return err
+	}
+	if err := gazer.Unstar(); err != nil {
+		return err
+	}
+	fmt.Printf("Unstarred %s\n", spec.Repo)
+	return nil
+}
+
+// status prints the status of the watches of a running watcher, fetched
+// from its status server.
+func status(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	var (
+		addr   = fs.String("addr", "http://localhost:8080", "URL of the watcher's status server")
+		asJSON = fs.Bool("json", false, "Print the status as JSON, as the status server serves it")
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 20 * time.Second}
+	resp, err := client.Get(strings.TrimRight(*addr, "/") + "/status")
+	if err != nil {
+		return errors.Wrap(err, "error fetching status")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("error fetching status: %s", resp.Status)
+	}
+	var watches []statusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&watches); err != nil {
+		return errors.Wrap(err, "error decoding status")
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(watches)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tSTARGAZERS\tTARGET\tPER HOUR\tPAUSED")
+	for _, w := range watches {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%t\n", w.Repository,
+			w.StargazersCount, w.StargazersTarget, w.Velocity.PerHour, w.Paused)
+	}
+	return tw.Flush()
+}
+
+// sendTest sends a test SMS, to check that Twilio is configured correctly.
+func sendTest(args []string) error {
+	fs := flag.NewFlagSet("send-test", flag.ExitOnError)
+	var (
+		phone         = fs.String("phone", "", "Phone number to send the test SMS to")
+		sender        = fs.String("sender", "", "Twilio phone number from which to send the test SMS")
+		authTokenFile = fs.String("twilio-auth-token-file", "", "File to read the Twilio auth token from")
+		message       = fs.String("message", "This is a test message from github-stargazer.", "Message to send")
+		proxy         = fs.String("proxy", "", proxyUsage)
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *phone == "" {
+		return errors.New("phone number is required")
+	}
+	client, err := httpClient(*proxy)
+	if err != nil {
+		return err
+	}
+	twilio, err := newTwilio(zap.NewNop().Sugar(), *sender, *authTokenFile, client)
+	if err != nil {
+		return err
+	}
+	if err := twilio.Send(*phone, *message); err != nil {
+		return err
+	}
+	fmt.Printf("Sent test SMS to %s\n", *phone)
+	return nil
+}
+
+// validate checks the flags of watch, and the watches in the watches file if
+// there is one, without starting any watches or opening the store.
+func validate(args []string) error {
+	c, err := newConfig("validate", args)
+	if err != nil {
+		return err
+	}
+	log, _, err := c.logger()
+	if err != nil {
+		return err
+	}
+	if err := c.check(); err != nil {
+		return err
+	}
+	n, err := c.notifier(log)
+	if err != nil {
+		return err
+	}
+	var specs []watchSpec
+	if spec, ok, err := c.spec(); err != nil {
+		return err
+	} else if ok {
+		specs = append(specs, spec)
+	}
+	if c.watchesFile != "" {
+		saved, err := readWatches(c.watchesFile)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, saved...)
+	}
+	for _, spec := range specs {
+		if err := n.validateSpec(spec); err != nil {
+			return errors.Wrapf(err, "invalid watch for %s", spec.Repo)
+		}
+	}
+	fmt.Printf("Configuration is valid (%d watches)\n", len(specs))
+	return nil
+}
+
+// validateSpec checks that a watch could be created for spec, without
+// starting it.
+func (n *notifier) validateSpec(spec watchSpec) error {
+	spec, err := resolveProvider(spec)
+	if err != nil {
+		return err
+	}
+	if spec.Deadline, err = absoluteDeadline(spec.Deadline, time.Now()); err != nil {
+		return err
+	}
+	if _, err := n.newGazer(spec); err != nil {
+		return err
+	}
+	if _, err := n.newMentionWatchers(spec); err != nil {
+		return err
+	}
+	_, err = n.newTrendingWatcher(spec)
+	return err
+}
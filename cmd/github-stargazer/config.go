@@ -0,0 +1,667 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// config is the configuration of the watcher, from the flags of the watch
+// and validate subcommands.
+type config struct {
+	repo             string
+	org              string
+	user             string
+	orgInterval      time.Duration
+	orgForks         bool
+	orgInclude       string
+	orgExclude       string
+	provider         string
+	count            string
+	target           string
+	phone            string
+	interval         time.Duration
+	intervalAt       string
+	minInterval      time.Duration
+	maxInterval      time.Duration
+	deadline         string
+	jitter           time.Duration
+	breakerFailures  int
+	breakerCooldown  time.Duration
+	stallAfter       time.Duration
+	workers          int
+	graphqlBatchSize int
+	hourlyBudget     int
+	schedule         string
+	report           string
+	sender           string
+	alertWebhook     string
+	alertAfter       int
+	notifyLimit      int
+	notifyPeriod     time.Duration
+	leaderboardEvery time.Duration
+	referrersEvery   time.Duration
+	apiURL           string
+	proxy            string
+	milestones       string
+	progress         string
+	velocityAlert    float64
+	rival            string
+	rivalGap         int
+	skipReached      bool
+	unstarOnExit     bool
+	watchContent     bool
+
+	hackerNews      bool
+	hackerNewsURL   string
+	reddit          bool
+	subreddits      string
+	redditURL       string
+	mentionPoints   string
+	mentionInterval time.Duration
+
+	trending          bool
+	trendingSince     string
+	trendingLanguages string
+	trendingInterval  time.Duration
+	trendingURL       string
+
+	spikeWindow time.Duration
+	lobstersURL string
+
+	hookRetries int
+	hookBackoff time.Duration
+	hookRearm   bool
+
+	githubTokenFile     string
+	twilioAuthTokenFile string
+	gitlabTokenFile     string
+	giteaTokenFile      string
+	bitbucketTokenFile  string
+
+	statusAddr      string
+	grpcAddr        string
+	publicURL       string
+	healthcheckURL  string
+	unhealthy       int
+	tlsCert         string
+	tlsKey          string
+	autocertHost    string
+	autocertCache   string
+	logLevel        string
+	shutdownTimeout time.Duration
+	backfill        bool
+	retainRaw       time.Duration
+	retainRollups   time.Duration
+	templatesFile   string
+	routesFile      string
+	lang            string
+	auditLogFile    string
+	eventLogFile    string
+	watchesFile     string
+	storage         string
+	storagePath     string
+}
+
+// newConfig parses the watcher's flags from args for the named subcommand.
+func newConfig(name string, args []string) (*config, error) {
+	var c config
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.StringVar(&c.repo, "repo", "", "Repository to watch (owner/repo, or its URL)")
+	fs.StringVar(&c.org, "org", "", "GitHub organization to watch every repository of, with the same -target and other settings as -repo")
+	fs.StringVar(&c.user, "user", "", "GitHub user to watch every public repository of, with the same -target and other settings as -repo")
+	fs.DurationVar(&c.orgInterval, "org-interval", time.Hour, "How often to list the repositories of -org and -user again to start watching new ones")
+	fs.BoolVar(&c.orgForks, "org-forks", false, "Watch the forks in -org and -user as well as their own repositories")
+	fs.StringVar(&c.orgInclude, "org-include", "", "Comma-separated list of glob patterns of the repositories of -org and -user to watch, like go-* (default all of them)")
+	fs.StringVar(&c.orgExclude, "org-exclude", "", "Comma-separated list of glob patterns of the repositories of -org and -user not to watch")
+	fs.StringVar(&c.provider, "provider", "", "Where the repo is: github, gitlab, gitea, bitbucket, npm, crates, pypi or go (default from the -repo URL, or github)")
+	fs.StringVar(&c.count, "count", "", "What to count instead of the provider's default, such as forks or downloads (see the README for each provider's counts)")
+	fs.StringVar(&c.target, "target", "", "Target number of stargazers, or +N to watch for N more than the current count")
+	fs.StringVar(&c.phone, "phone", "", "Phone number to send SMS to upon reaching stargazer target")
+	fs.DurationVar(&c.interval, "interval", time.Minute, "How often to check stargazer count")
+	fs.StringVar(&c.intervalAt, "interval-at", "", "Comma-separated list of count=interval pairs to check at a different interval from (relative if -target is)")
+	fs.DurationVar(&c.minInterval, "min-interval", 15*time.Second, "Shortest interval to check the stargazer count at when adapting it")
+	fs.DurationVar(&c.maxInterval, "max-interval", 0, "Adapt the interval to the star velocity, up to this when growth stalls (0 disables)")
+	fs.StringVar(&c.deadline, "deadline", "", "Stop watching at this RFC 3339 time, or after this long, and report the count if the target wasn't reached")
+	fs.DurationVar(&c.jitter, "jitter", 0, "Delay each check of the stargazer count by a random amount up to this")
+	fs.IntVar(&c.breakerFailures, "breaker-failures", 0, "Stop checking a repo after this many checks in a row fail, probing every -breaker-cooldown until one succeeds (0 disables)")
+	fs.DurationVar(&c.breakerCooldown, "breaker-cooldown", 5*time.Minute, "How long to stop checking a repo for once -breaker-failures checks in a row have failed")
+	fs.DurationVar(&c.stallAfter, "stall-after", 0, "Send an SMS when a repo's count hasn't been checked successfully for this long (0 disables)")
+	fs.IntVar(&c.workers, "workers", 0, "Poll every watch through a shared scheduler, making at most this many requests at once (0 lets each watch poll on its own)")
+	fs.IntVar(&c.hourlyBudget, "hourly-budget", 0, "Make at most this many GitHub requests per hour across every watch, spacing out and skipping polls through the shared scheduler (0 disables)")
+	fs.IntVar(&c.graphqlBatchSize, "graphql-batch", 0, "Fetch the counts of GitHub watches together, this many per GraphQL request, once per -interval (0 fetches each on its own; needs a token)")
+	fs.StringVar(&c.schedule, "schedule", "", "Cron expression for when to check stargazer count, instead of every -interval")
+	fs.StringVar(&c.report, "report", "", "Cron expression for when to send an SMS with the current count and how many more the next target needs, like \"0 9 * * *\" for every morning")
+	fs.StringVar(&c.sender, "sender", "", "Twilio phone number from which to send SMS messages")
+	fs.StringVar(&c.alertWebhook, "alert-webhook", "", "URL of a webhook, such as a Slack incoming webhook, to alert when SMS notifications keep failing (empty disables)")
+	fs.IntVar(&c.alertAfter, "alert-after", 3, "How many SMS notifications in a row must fail before alerting -alert-webhook")
+	fs.IntVar(&c.notifyLimit, "notify-limit", 0, "Send at most this many SMS each -notify-period across every watch, holding the rest back to send together (0 disables)")
+	fs.DurationVar(&c.notifyPeriod, "notify-period", time.Hour, "Period over which -notify-limit SMS can be sent")
+	fs.DurationVar(&c.leaderboardEvery, "leaderboard-every", 0, "Send a digest ranking every watch by count and by growth this often, to -phone unless -routes routes it elsewhere (0 disables)")
+	fs.DurationVar(&c.referrersEvery, "referrers-every", 0, "Fetch the top referrers of GitHub repos this often, for the status and the leaderboard digest (0 disables; needs a token that can push to them)")
+	fs.StringVar(&c.apiURL, "github-url", "", "Base URL of a GitHub Enterprise Server instance (default github.com)")
+	fs.StringVar(&c.proxy, "proxy", "", proxyUsage)
+
+	fs.IntVar(&c.hookRetries, "hook-retries", 0, "How many times to retry a failed milestone notification")
+	fs.DurationVar(&c.hookBackoff, "hook-backoff", 5*time.Second, "How long to wait before retrying a failed milestone notification, doubling each time")
+	fs.BoolVar(&c.hookRearm, "hook-rearm", false, "Keep retrying a failed milestone notification at each check until it succeeds")
+
+	fs.StringVar(&c.githubTokenFile, "github-token-file", "", "File to read the GitHub token from, re-read when it changes")
+	fs.StringVar(&c.twilioAuthTokenFile, "twilio-auth-token-file", "", "File to read the Twilio auth token from, re-read when it changes")
+	fs.StringVar(&c.gitlabTokenFile, "gitlab-token-file", "", "File to read the GitLab access token from, re-read when it changes")
+	fs.StringVar(&c.giteaTokenFile, "gitea-token-file", "", "File to read the Gitea access token from, re-read when it changes")
+	fs.StringVar(&c.bitbucketTokenFile, "bitbucket-token-file", "", "File to read the Bitbucket access token from, re-read when it changes")
+
+	fs.StringVar(&c.milestones, "milestones", "", "Comma-separated list of additional stargazer counts to send an SMS for (relative if -target is)")
+	fs.StringVar(&c.progress, "progress", "", "Comma-separated list of percentages of the target to send a progress SMS at")
+	fs.BoolVar(&c.skipReached, "skip-reached", false, "Don't send an SMS for the target, milestones or progress already reached when the watch starts")
+	fs.BoolVar(&c.unstarOnExit, "unstar-on-exit", false, "Remove the stars added to repos that reached their targets when the watcher exits, to run it again from scratch")
+	fs.Float64Var(&c.velocityAlert, "velocity-alert", 0, "Send an SMS when the repo gains more than this many stars per hour (0 disables)")
+	fs.StringVar(&c.rival, "rival", "", "Repository on the same provider to compare -repo with, sending an SMS when either overtakes the other")
+	fs.IntVar(&c.rivalGap, "rival-gap", 0, "Also send an SMS when -repo and -rival come within this many of each other (0 disables)")
+	fs.BoolVar(&c.watchContent, "watch-content", false, "Send an SMS when the description or README of a GitHub repo changes")
+	fs.BoolVar(&c.hackerNews, "hn", false, "Send an SMS when the repo is submitted to Hacker News or a submission reaches the front page")
+	fs.StringVar(&c.hackerNewsURL, "hn-url", "", "Base URL of the Algolia Hacker News search API (default https://hn.algolia.com)")
+	fs.BoolVar(&c.reddit, "reddit", false, "Send an SMS when the repo is posted to Reddit")
+	fs.StringVar(&c.subreddits, "subreddits", "", "Comma-separated list of subreddits to search for posts about the repo with -reddit (default all of Reddit)")
+	fs.StringVar(&c.redditURL, "reddit-url", "", "Base URL of Reddit (default https://www.reddit.com)")
+	fs.StringVar(&c.mentionPoints, "mention-points", "", "Comma-separated list of points (or Reddit scores) to send an SMS at when a post about the repo reaches them")
+	fs.DurationVar(&c.mentionInterval, "mention-interval", 5*time.Minute, "How often to search for posts about the repo")
+	fs.BoolVar(&c.trending, "trending", false, "Send an SMS when the repo enters or leaves GitHub Trending")
+	fs.StringVar(&c.trendingSince, "trending-since", "", "Comma-separated list of GitHub Trending periods to watch with -trending: daily, weekly or monthly (default daily,weekly)")
+	fs.StringVar(&c.trendingLanguages, "trending-languages", "", "Comma-separated list of languages whose GitHub Trending lists to watch with -trending, besides the list of every language")
+	fs.DurationVar(&c.trendingInterval, "trending-interval", time.Hour, "How often to read GitHub Trending")
+	fs.StringVar(&c.trendingURL, "trending-url", "", "Base URL of GitHub Trending (default https://github.com)")
+	fs.DurationVar(&c.spikeWindow, "spike-window", 24*time.Hour, "How far back to look on Hacker News, Reddit and Lobsters for the post that set off a -velocity-alert spike, to name it in the SMS (0 disables)")
+	fs.StringVar(&c.lobstersURL, "lobsters-url", "", "Base URL of Lobsters (default https://lobste.rs)")
+	fs.StringVar(&c.statusAddr, "status-addr", "", "Address on which to serve the dashboard, /status, /healthz and /readyz (empty disables)")
+	fs.StringVar(&c.grpcAddr, "grpc-addr", "", "Address on which to serve the gRPC API for managing watches and streaming their events, with the control token (empty disables)")
+	fs.StringVar(&c.publicURL, "public-url", "", "URL at which Twilio, Slack and PagerDuty can reach -status-addr, like https://stars.example.com, to attach star history charts to notifications (empty attaches none)")
+	fs.StringVar(&c.healthcheckURL, "healthcheck-url", "", "URL to ping, like that of a Healthchecks.io check, each time every watch has fetched its count, at most every 30s (empty disables)")
+	fs.IntVar(&c.unhealthy, "unhealthy-after", 5, "Consecutive fetch failures after which /healthz reports unhealthy")
+	fs.StringVar(&c.tlsCert, "tls-cert", "", "Certificate file for serving the status server over HTTPS")
+	fs.StringVar(&c.tlsKey, "tls-key", "", "Key file for serving the status server over HTTPS")
+	fs.StringVar(&c.autocertHost, "tls-autocert-host", "", "Host name to obtain a Let's Encrypt certificate for the status server")
+	fs.StringVar(&c.autocertCache, "tls-autocert-cache", "autocert", "Directory in which to cache Let's Encrypt certificates")
+	fs.StringVar(&c.logLevel, "log-level", "info", "Minimum level of messages to log: debug, info, warn or error")
+	fs.DurationVar(&c.shutdownTimeout, "shutdown-timeout", 10*time.Second, "How long to wait for in-flight work to finish when shutting down")
+	fs.BoolVar(&c.backfill, "backfill", false, "Fetch the full star history of newly watched repos into the sqlite or bolt store")
+	fs.DurationVar(&c.retainRaw, "retain-raw", 30*24*time.Hour, "How long to keep every recorded sample before rolling them up hourly (0 keeps them forever)")
+	fs.DurationVar(&c.retainRollups, "retain-rollups", 365*24*time.Hour, "How long to keep hourly rollups of history (0 keeps them forever)")
+	fs.StringVar(&c.templatesFile, "templates", "", "JSON file of text/template notification messages by event, replacing those for -lang: target, milestone, progress, velocity, starred, deadline, stalled, renamed, archived, deleted, license, description, readme, report, held, overtook, overtaken, gap, leaderboard, leaderboard.velocity, leaderboard.referrers, trending, left-trending, mention, front-page, points, release (for the feed)")
+	fs.StringVar(&c.routesFile, "routes", "", "JSON file of channels and rules routing notifications by event, repo, count and severity to SMS, webhooks or PagerDuty (default SMS for every event)")
+	fs.StringVar(&c.lang, "lang", "en", "Language to send notifications in: de, en, es or fr")
+	fs.StringVar(&c.auditLogFile, "audit-log", "", "File in which to record every notification attempt, served at /notifications")
+	fs.StringVar(&c.eventLogFile, "event-log", "", "File to append every event and notification result to as JSON lines, or - for standard output")
+	fs.StringVar(&c.watchesFile, "watches-file", "", "File in which to persist watches managed through the API")
+	fs.StringVar(&c.storage, "storage", "file", "Storage driver for state and history: file (state only), sqlite or bolt")
+	fs.StringVar(&c.storagePath, "storage-path", "", "Where the storage driver keeps its data (empty persists nothing with the file driver)")
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// envFlagPrefix prefixes the name of the environment variable that a flag
+// falls back to.
+const envFlagPrefix = "STARGAZER_"
+
+// parseFlags parses args with fs. Flags that aren't given in args are set
+// from the environment variable named for them, such as STARGAZER_REPO for
+// -repo or STARGAZER_GITHUB_URL for -github-url, if it is set. Flags in args
+// take precedence over the environment, which takes precedence over the
+// defaults.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.Parse(args)
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if given[f.Name] || err != nil {
+			return
+		}
+		name := flagEnv(f.Name)
+		if value, ok := os.LookupEnv(name); ok {
+			if setErr := fs.Set(f.Name, value); setErr != nil {
+				err = errors.Wrapf(setErr, "invalid %s", name)
+			}
+		}
+	})
+	return err
+}
+
+// flagEnv returns the name of the environment variable for the flag name.
+func flagEnv(name string) string {
+	return envFlagPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// logger builds the log, returning the level so that it can be changed while
+// the watcher runs.
+func (c *config) logger() (*zap.SugaredLogger, zap.AtomicLevel, error) {
+	level := zap.NewAtomicLevel()
+	if err := level.UnmarshalText([]byte(c.logLevel)); err != nil {
+		return nil, level, errors.Wrap(err, "invalid -log-level")
+	}
+	config := zap.NewDevelopmentConfig()
+	config.Level = level
+	plainLog, err := config.Build()
+	if err != nil {
+		return nil, level, err
+	}
+	return plainLog.Sugar(), level, nil
+}
+
+// notifier builds the notifier that creates gazers for watches and sends
+// their notifications.
+func (c *config) notifier(log *zap.SugaredLogger) (*notifier, error) {
+	client, err := httpClient(c.proxy)
+	if err != nil {
+		return nil, err
+	}
+	metrics := newExpvarMetrics()
+	twilio, err := newTwilio(log, c.sender, c.twilioAuthTokenFile, client,
+		stargazer.WithTwilioMetrics(metrics))
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := newOutageAlerter(log, c.alertWebhook, c.alertAfter, client)
+	if err != nil {
+		return nil, err
+	}
+	router, err := loadRouter(c.routesFile)
+	if err != nil {
+		return nil, err
+	}
+	throttle, err := newThrottle(c.notifyLimit, c.notifyPeriod)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := loadCatalog(c.lang, c.templatesFile)
+	if err != nil {
+		return nil, err
+	}
+	var audit *auditLog
+	if c.auditLogFile != "" {
+		audit = &auditLog{path: c.auditLogFile}
+	}
+	gazerOptions := append(c.gazerOptions(log, client), stargazer.WithGitHubMetrics(metrics))
+	var scheduler *stargazer.Scheduler
+	if c.workers > 0 || c.hourlyBudget > 0 {
+		options := []func(*stargazer.Scheduler){
+			stargazer.WithHourlyBudget(c.hourlyBudget),
+			stargazer.WithSchedulerMetrics(metrics),
+		}
+		if c.workers > 0 {
+			options = append(options, stargazer.WithWorkers(c.workers))
+		}
+		scheduler, err = stargazer.NewScheduler(options...)
+		if err != nil {
+			return nil, err
+		}
+		gazerOptions = append(gazerOptions, stargazer.WithScheduler(scheduler))
+	}
+	batch, err := c.graphqlBatch(client, scheduler, metrics)
+	if err != nil {
+		return nil, err
+	}
+	hackerNewsOptions := []func(*stargazer.HackerNewsSource){stargazer.WithHackerNewsHTTPClient(client)}
+	if c.hackerNewsURL != "" {
+		hackerNewsOptions = append(hackerNewsOptions, stargazer.WithHackerNewsBaseURL(c.hackerNewsURL))
+	}
+	redditOptions := []func(*stargazer.RedditSource){stargazer.WithRedditHTTPClient(client)}
+	if c.redditURL != "" {
+		redditOptions = append(redditOptions, stargazer.WithRedditBaseURL(c.redditURL))
+	}
+	lobstersOptions := []func(*stargazer.LobstersSource){stargazer.WithLobstersHTTPClient(client)}
+	if c.lobstersURL != "" {
+		lobstersOptions = append(lobstersOptions, stargazer.WithLobstersBaseURL(c.lobstersURL))
+	}
+	trendingOptions := []func(*stargazer.TrendingWatcher){stargazer.WithTrendingHTTPClient(client)}
+	if c.trendingURL != "" {
+		trendingOptions = append(trendingOptions, stargazer.WithTrendingBaseURL(c.trendingURL))
+	}
+	return &notifier{
+		log:             log,
+		sms:             twilio,
+		alerts:          alerts,
+		throttle:        throttle,
+		router:          router,
+		client:          client,
+		gazerOptions:    gazerOptions,
+		gitlabToken:     tokenSource(c.gitlabTokenFile, envGitLabToken),
+		giteaToken:      tokenSource(c.giteaTokenFile, envGiteaToken),
+		bitbucketToken:  tokenSource(c.bitbucketTokenFile, envBitbucketToken),
+		defaultPhone:    c.phone,
+		defaultInterval: c.interval,
+		stallAfter:      c.stallAfter,
+		mentionInterval: c.mentionInterval,
+		audit:           audit,
+		metrics:         metrics,
+		messages:        msgs,
+		publicURL:       strings.TrimRight(c.publicURL, "/"),
+
+		graphqlBatch:      batch,
+		unstarOnExit:      c.unstarOnExit,
+		hackerNewsOptions: hackerNewsOptions,
+		redditOptions:     redditOptions,
+		trendingInterval:  c.trendingInterval,
+		trendingOptions:   trendingOptions,
+		spikeWindow:       c.spikeWindow,
+		lobstersOptions:   lobstersOptions,
+	}, nil
+}
+
+// gazerOptions returns the options that every gazer is created with.
+func (c *config) gazerOptions(log *zap.SugaredLogger, client *http.Client) []func(*stargazer.GitHubStargazer) {
+	options := githubOptions(log, c.apiURL, c.githubTokenFile, client)
+	if c.maxInterval > 0 {
+		options = append(options, stargazer.WithAdaptiveInterval(c.minInterval, c.maxInterval))
+	}
+	if c.hookRetries > 0 || c.hookRearm {
+		options = append(options, stargazer.WithHookRetry(stargazer.HookRetry{
+			Retries: c.hookRetries,
+			Backoff: c.hookBackoff,
+			Rearm:   c.hookRearm,
+		}))
+	}
+	if c.jitter > 0 {
+		options = append(options, stargazer.WithJitter(c.jitter))
+	}
+	if c.skipReached {
+		options = append(options, stargazer.WithSkipReachedTargets())
+	}
+	if c.breakerFailures > 0 {
+		options = append(options, stargazer.WithCircuitBreaker(c.breakerFailures, c.breakerCooldown))
+	}
+	if c.referrersEvery > 0 {
+		options = append(options, stargazer.WithReferrers(c.referrersEvery))
+	}
+	return options
+}
+
+// spec returns the spec of the watch given by the flags, if -repo is set.
+func (c *config) spec() (watchSpec, bool, error) {
+	if c.repo == "" {
+		return watchSpec{}, false, nil
+	}
+	spec, err := c.watchTemplate()
+	if err != nil {
+		return watchSpec{}, false, err
+	}
+	spec.Repo = c.repo
+	spec.Rival = c.rival
+	spec.RivalGap = c.rivalGap
+	return spec, true, nil
+}
+
+// watchTemplate returns the spec given by the flags for every watch they
+// configure, without its repository.
+func (c *config) watchTemplate() (watchSpec, error) {
+	milestones, err := parseIntList(c.milestones, "milestone")
+	if err != nil {
+		return watchSpec{}, err
+	}
+	progress, err := parseIntList(c.progress, "progress percentage")
+	if err != nil {
+		return watchSpec{}, err
+	}
+	intervals, err := parseIntervalList(c.intervalAt)
+	if err != nil {
+		return watchSpec{}, err
+	}
+	points, err := parseIntList(c.mentionPoints, "mention points")
+	if err != nil {
+		return watchSpec{}, err
+	}
+	var hackerNews *mentionSpec
+	if c.hackerNews {
+		hackerNews = &mentionSpec{Points: points}
+	}
+	var reddit *mentionSpec
+	if c.reddit {
+		reddit = &mentionSpec{Points: points}
+		if c.subreddits != "" {
+			reddit.Subreddits = strings.Split(c.subreddits, ",")
+		}
+	} else if c.subreddits != "" {
+		return watchSpec{}, errors.New("-subreddits requires -reddit")
+	}
+	var trending *trendingSpec
+	if c.trending {
+		trending = &trendingSpec{}
+		if c.trendingSince != "" {
+			trending.Since = strings.Split(c.trendingSince, ",")
+		}
+		if c.trendingLanguages != "" {
+			trending.Languages = strings.Split(c.trendingLanguages, ",")
+		}
+	} else if c.trendingSince != "" || c.trendingLanguages != "" {
+		return watchSpec{}, errors.New("-trending-since and -trending-languages require -trending")
+	}
+	return watchSpec{
+		Provider:      c.provider,
+		Count:         c.count,
+		Target:        c.target,
+		Milestones:    milestones,
+		Progress:      progress,
+		IntervalAt:    intervals,
+		Schedule:      c.schedule,
+		Report:        c.report,
+		Deadline:      c.deadline,
+		VelocityAlert: c.velocityAlert,
+		HackerNews:    hackerNews,
+		Reddit:        reddit,
+		WatchContent:  c.watchContent,
+		Trending:      trending,
+	}, nil
+}
+
+// check checks the flags that aren't checked by building what they
+// configure.
+func (c *config) check() error {
+	if c.storage != "file" && c.storagePath == "" {
+		return fmt.Errorf("-storage-path is required for the %s storage driver", c.storage)
+	}
+	if (c.tlsCert == "") != (c.tlsKey == "") {
+		return errors.New("-tls-cert and -tls-key must be given together")
+	}
+	if (c.org != "" || c.user != "") && c.provider != "" && c.provider != providerGitHub {
+		return errors.New("-org and -user only watch GitHub repos")
+	}
+	if (c.org != "" || c.user != "") && (c.count == string(stargazer.Followers) || c.count == string(stargazer.Sponsors)) {
+		return errors.New("-org and -user watch repos, which have no followers or sponsors")
+	}
+	if c.leaderboardEvery > 0 && c.phone == "" && c.routesFile == "" {
+		return errors.New("-leaderboard-every requires -phone or -routes")
+	}
+	if c.healthcheckURL != "" {
+		if u, err := url.Parse(c.healthcheckURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.New("-healthcheck-url must be an http or https URL")
+		}
+	}
+	if c.publicURL != "" && c.statusAddr == "" {
+		return errors.New("-public-url requires -status-addr")
+	}
+	if (c.org != "" || c.user != "") && c.orgInterval <= 0 {
+		return errors.New("-org-interval must be positive")
+	}
+	return nil
+}
+
+// githubToken returns the source of the GitHub token: tokenFile if it is set,
+// or else the environment or the token stored by the login subcommand.
+func githubToken(tokenFile string) stargazer.TokenSource {
+	if tokenFile != "" {
+		return stargazer.FileTokenSource(tokenFile)
+	}
+	token := os.Getenv(envGitHubToken)
+	if token == "" {
+		token = storedToken()
+	}
+	return stargazer.StaticTokenSource(token)
+}
+
+// graphqlBatch builds the batch that GitHub watches fetch their counts with,
+// if -graphql-batch is set. Its requests count against the hourly budget of
+// scheduler, if it is set.
+func (c *config) graphqlBatch(
+	client *http.Client,
+	scheduler *stargazer.Scheduler,
+	metrics stargazer.Metrics) (*stargazer.GraphQLBatch, error) {
+
+	if c.graphqlBatchSize <= 0 {
+		return nil, nil
+	}
+	options := []func(*stargazer.GraphQLBatch){
+		stargazer.WithGraphQLBatchSize(c.graphqlBatchSize),
+		stargazer.WithGraphQLMaxAge(c.interval),
+		stargazer.WithGraphQLTokenSource(githubToken(c.githubTokenFile)),
+		stargazer.WithGraphQLHTTPClient(client),
+		stargazer.WithGraphQLMetrics(metrics),
+	}
+	if c.apiURL != "" {
+		options = append(options, stargazer.WithGraphQLBaseURL(c.apiURL))
+	}
+	if scheduler != nil {
+		options = append(options, stargazer.WithGraphQLScheduler(scheduler))
+	}
+	return stargazer.NewGraphQLBatch(options...)
+}
+
+// account is a GitHub organization or user whose repositories are watched.
+type account struct {
+	name   string
+	lister repoLister
+}
+
+// accounts builds the listers of the repositories of the organization and
+// user to watch, if -org or -user is set.
+func (c *config) accounts(client *http.Client) ([]account, error) {
+	var accounts []account
+	if c.org != "" {
+		options := []func(*stargazer.GitHubOrg){
+			stargazer.WithOrgTokenSource(githubToken(c.githubTokenFile)),
+			stargazer.WithOrgHTTPClient(client),
+		}
+		if c.apiURL != "" {
+			options = append(options, stargazer.WithOrgBaseURL(c.apiURL))
+		}
+		org, err := stargazer.NewGitHubOrg(c.org, options...)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account{name: org.Org, lister: org})
+	}
+	if c.user != "" {
+		options := []func(*stargazer.GitHubUser){
+			stargazer.WithUserTokenSource(githubToken(c.githubTokenFile)),
+			stargazer.WithUserHTTPClient(client),
+		}
+		if c.apiURL != "" {
+			options = append(options, stargazer.WithUserBaseURL(c.apiURL))
+		}
+		user, err := stargazer.NewGitHubUser(c.user, options...)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account{name: user.User, lister: user})
+	}
+	return accounts, nil
+}
+
+// accountFilter returns which of the repositories of -org and -user to watch.
+func (c *config) accountFilter() (accountFilter, error) {
+	include, err := parsePatterns(c.orgInclude)
+	if err != nil {
+		return accountFilter{}, errors.Wrap(err, "invalid -org-include")
+	}
+	exclude, err := parsePatterns(c.orgExclude)
+	if err != nil {
+		return accountFilter{}, errors.Wrap(err, "invalid -org-exclude")
+	}
+	return accountFilter{
+		forks:    c.orgForks,
+		include:  include,
+		exclude:  exclude,
+		interval: c.orgInterval,
+	}, nil
+}
+
+// proxyUsage is the usage of the -proxy flag of each subcommand that has it.
+const proxyUsage = "URL of the HTTP, HTTPS or SOCKS5 proxy to reach GitHub, Twilio and every other site through, like socks5://localhost:1080 (default from HTTPS_PROXY, HTTP_PROXY and NO_PROXY)"
+
+// httpClient returns the client to make requests to GitHub, Twilio and the
+// other sites with, which goes through proxy, or nil to use the default clients if proxy is
+// empty. The default clients go through the proxy given by the environment.
+func httpClient(proxy string) (*http.Client, error) {
+	if proxy == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid -proxy")
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, errors.Errorf("invalid -proxy %q: scheme must be http, https, socks5 or socks5h", proxy)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(u)
+	return &http.Client{Timeout: 20 * time.Second, Transport: transport}, nil
+}
+
+// newTwilio builds the Twilio sender from the environment. The sender is
+// the phone number to send from, defaulting to the environment's, and the
+// auth token is read from authTokenFile if it is set. Requests are made
+// with client, unless it is nil, and extra options are applied last.
+func newTwilio(
+	log *zap.SugaredLogger,
+	sender, authTokenFile string,
+	client *http.Client,
+	extra ...func(*stargazer.TwilioSMSSender)) (*stargazer.TwilioSMSSender, error) {
+
+	if sender == "" {
+		sender = os.Getenv(envTwilioPhoneNumber)
+	}
+	options := append([]func(*stargazer.TwilioSMSSender){
+		stargazer.WithTwilioLogger(log),
+		stargazer.WithTwilioHTTPClient(client),
+	}, extra...)
+	if authTokenFile != "" {
+		options = append(options,
+			stargazer.WithTwilioAuthTokenSource(stargazer.FileTokenSource(authTokenFile)))
+	}
+	return stargazer.NewTwilioSMSSender(os.Getenv(envTwilioAccountSID),
+		os.Getenv(envTwilioAuthToken),
+		sender, options...)
+}
+
+// githubOptions returns the options for talking to GitHub: the log, the
+// base URL, if any, the token, from tokenFile if it is set, and the client,
+// unless it is nil.
+func githubOptions(
+	log *zap.SugaredLogger,
+	apiURL, tokenFile string,
+	client *http.Client) []func(*stargazer.GitHubStargazer) {
+
+	options := []func(*stargazer.GitHubStargazer){
+		stargazer.WithGitHubLogger(log),
+		stargazer.WithGitHubTokenSource(githubToken(tokenFile)),
+		stargazer.WithGitHubHTTPClient(client),
+	}
+	if apiURL != "" {
+		options = append(options, stargazer.WithGitHubBaseURL(apiURL))
+	}
+	return options
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+)
+
+// eventsInterval is how often /events pushes the state of the watches.
+const eventsInterval = 5 * time.Second
+
+// dashboardRepo is the state of a watch as sent to the dashboard.
+type dashboardRepo struct {
+	statusResponse
+	History []stargazer.Sample `json:"history"`
+}
+
+func (s *statusServer) dashboardState() []dashboardRepo {
+	repos := []dashboardRepo{}
+	for _, wt := range s.watches.list() {
+		repos = append(repos, dashboardRepo{
+			statusResponse: newStatusResponse(wt.gazer),
+			History:        s.history(wt.gazer),
+		})
+	}
+	return repos
+}
+
+// history returns the recent counts for the gazer's repo, from the store if
+// it records history.
+func (s *statusServer) history(gazer *stargazer.GitHubStargazer) []stargazer.Sample {
+	now := time.Now()
+	samples, err := s.watches.store.History(gazer.Repository, now.Add(-velocityWindow), now)
+	if err == errNoHistory {
+		return gazer.History()
+	}
+	if err != nil {
+		s.log.Warnw("unable to read history", "repo", gazer.Repository, "err", err)
+		return gazer.History()
+	}
+	return samples
+}
+
+// historyBetween returns the counts for repo between from and to, from the
+// store if it records history, or else from the gazer of its watch, which
+// keeps them for the velocity window. It returns errNoHistory if the store
+// doesn't record history and repo isn't watched.
+func (s *statusServer) historyBetween(repo string, from, to time.Time) ([]stargazer.Sample, error) {
+	samples, err := s.watches.store.History(repo, from, to)
+	if err != errNoHistory {
+		return samples, err
+	}
+	wt, ok := s.watches.get(repo)
+	if !ok {
+		return nil, errNoHistory
+	}
+	samples = nil
+	for _, sample := range wt.gazer.History() {
+		if !sample.Time.Before(from) && !sample.Time.After(to) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples, nil
+}
+
+// handleEvents streams the state of the watches as server-sent events until
+// the client goes away.
+func (s *statusServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	t := time.NewTicker(eventsInterval)
+	defer t.Stop()
+	for {
+		b, err := json.Marshal(s.dashboardState())
+		if err != nil {
+			s.log.Warnw("unable to encode dashboard state", "err", err)
+			return
+		}
+		if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", b); err != nil {
+			return
+		}
+		flusher.Flush()
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *statusServer) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, dashboardHTML)
+}
+
+const dashboardHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>github stargazer 🤩</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #24292e; }
+.repo { border: 1px solid #e1e4e8; border-radius: 6px; padding: 1em; margin-bottom: 1em; max-width: 40em; }
+.repo h2 { margin: 0 0 .5em; font-size: 1.2em; }
+.bar { background: #e1e4e8; border-radius: 3px; height: 1em; overflow: hidden; }
+.bar div { background: #2ea44f; height: 100%; }
+.stats { margin: .5em 0; }
+svg { width: 100%; height: 3em; }
+polyline { fill: none; stroke: #0366d6; stroke-width: 2; }
+</style>
+</head>
+<body>
+<h1>github stargazer 🤩</h1>
+<div id="repos">Waiting for the first update…</div>
+<script>
+function sparkline(history) {
+  if (history.length < 2) return "";
+  var counts = history.map(function (s) { return s.count; });
+  var min = Math.min.apply(null, counts), max = Math.max.apply(null, counts);
+  var span = max - min || 1;
+  var points = counts.map(function (c, i) {
+    return (i / (counts.length - 1) * 100) + "," + (30 - (c - min) / span * 30);
+  });
+  return '<svg viewBox="0 0 100 30" preserveAspectRatio="none"><polyline points="' + points.join(" ") + '"/></svg>';
+}
+function escape(s) {
+  var d = document.createElement("div");
+  d.textContent = s;
+  return d.innerHTML;
+}
+function render(repos) {
+  var el = document.getElementById("repos");
+  if (repos.length === 0) { el.textContent = "Not watching any repositories."; return; }
+  el.innerHTML = repos.map(function (r) {
+    var pct = Math.min(100, r.stargazers_count / r.stargazers_target * 100);
+    return '<div class="repo"><h2>' + escape(r.repository) + (r.paused ? " (paused)" : "") + '</h2>' +
+      '<div class="bar"><div style="width:' + pct + '%"></div></div>' +
+      '<div class="stats">' + r.stargazers_count + ' of ' + r.stargazers_target + ' stargazers · ' +
+      r.velocity.stars_per_hour.toFixed(1) + ' stars/hour · ' +
+      r.velocity.stars_per_day.toFixed(1) + ' stars/day · ' +
+      '<a href="chart/' + encodeURI(r.repository) + '.svg">chart</a></div>' +
+      sparkline(r.history) + '</div>';
+  }).join("");
+}
+new EventSource("events").addEventListener("status", function (e) {
+  render(JSON.parse(e.data));
+});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+)
+
+// eventRecord is an event as written to the event log. Fields that don't
+// apply to the event are omitted.
+type eventRecord struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Repository string    `json:"repository"`
+	Count      int       `json:"count,omitempty"`
+	Previous   int       `json:"previous,omitempty"`
+	Target     int       `json:"target,omitempty"`
+	Final      bool      `json:"final,omitempty"`
+	Op         string    `json:"op,omitempty"`
+	Failures   int       `json:"failures,omitempty"`
+	Channel    string    `json:"channel,omitempty"`
+	To         string    `json:"to,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Result     string    `json:"result,omitempty"`
+	Error      string    `json:"error,omitempty"`
+
+	// NewName is what the repository of a "renamed" record was renamed or
+	// transferred to.
+	NewName string `json:"new_name,omitempty"`
+
+	// License and PreviousLicense are the new and old license of the
+	// repository of a "license_changed" record.
+	License         string `json:"license,omitempty"`
+	PreviousLicense string `json:"previous_license,omitempty"`
+
+	// Part is what changed about the repository of a "content_changed"
+	// record: its "description" or "readme".
+	Part string `json:"part,omitempty"`
+}
+
+// eventLog writes every event of every watch, and the result of every
+// notification, as a line of JSON, if it has a writer, and sends it to its
+// subscribers. It is safe for concurrent use, and a nil eventLog writes
+// nothing.
+type eventLog struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	// subscribers are sent every record written until they unsubscribe.
+	subscribers map[chan eventRecord]bool
+}
+
+// openEventLog opens the event log that appends to the file at path, or
+// writes to standard output if path is "-".
+func openEventLog(path string) (*eventLog, error) {
+	if path == "-" {
+		return &eventLog{w: os.Stdout}, nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening event log")
+	}
+	return &eventLog{w: f}, nil
+}
+
+func (el *eventLog) write(rec eventRecord) error {
+	if el == nil {
+		return nil
+	}
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	for sub := range el.subscribers {
+		select {
+		case sub <- rec:
+		default:
+		}
+	}
+	if el.w == nil {
+		return nil
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = el.w.Write(append(b, '\n'))
+	return errors.Wrap(err, "error writing event log")
+}
+
+// subscribe returns a channel that is sent every record written from now on,
+// until unsubscribe is called. Records are dropped rather than wait for a
+// subscriber that falls behind.
+func (el *eventLog) subscribe() (records <-chan eventRecord, unsubscribe func()) {
+	sub := make(chan eventRecord, 64)
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	if el.subscribers == nil {
+		el.subscribers = make(map[chan eventRecord]bool)
+	}
+	el.subscribers[sub] = true
+	return sub, func() {
+		el.mu.Lock()
+		defer el.mu.Unlock()
+		delete(el.subscribers, sub)
+	}
+}
+
+// newEventRecord returns the record of the gazer event e.
+func newEventRecord(e stargazer.Event) eventRecord {
+	switch e := e.(type) {
+	case stargazer.CountChanged:
+		return eventRecord{
+			Time:       e.Time,
+			Type:       "count_changed",
+			Repository: e.Repository,
+			Count:      e.StargazersCount,
+			Previous:   e.Previous,
+		}
+	case stargazer.ThresholdCrossed:
+		return eventRecord{
+			Time:       time.Now(),
+			Type:       "threshold_crossed",
+			Repository: e.Repository,
+			Count:      e.StargazersCount,
+			Target:     e.Target,
+			Final:      e.Final,
+		}
+	case stargazer.FetchFailed:
+		return eventRecord{
+			Time:       time.Now(),
+			Type:       "failed",
+			Repository: e.Repository,
+			Op:         e.Op,
+			Failures:   e.ConsecutiveFailures,
+			Error:      e.Err.Error(),
+		}
+	case stargazer.Degraded:
+		return eventRecord{
+			Time:       e.Time,
+			Type:       "degraded",
+			Repository: e.Repository,
+			Op:         e.Op,
+			Failures:   e.ConsecutiveFailures,
+			Error:      e.Err.Error(),
+		}
+	case stargazer.Recovered:
+		return eventRecord{Time: e.Time, Type: "recovered", Repository: e.Repository}
+	case stargazer.Stalled:
+		rec := eventRecord{
+			Time:       e.Time,
+			Type:       "stalled",
+			Repository: e.Repository,
+			Failures:   e.ConsecutiveFailures,
+		}
+		if e.Err != nil {
+			rec.Error = e.Err.Error()
+		}
+		return rec
+	case stargazer.Renamed:
+		return eventRecord{Time: e.Time, Type: "renamed", Repository: e.Repository, NewName: e.NewName}
+	case stargazer.Retired:
+		rec := eventRecord{Time: e.Time, Type: "deleted", Repository: e.Repository}
+		if e.Archived {
+			rec.Type = "archived"
+		}
+		if e.Err != nil {
+			rec.Error = e.Err.Error()
+		}
+		return rec
+	case stargazer.LicenseChanged:
+		return eventRecord{Time: e.Time, Type: "license_changed", Repository: e.Repository, License: e.License, PreviousLicense: e.Previous}
+	case stargazer.ContentChanged:
+		return eventRecord{Time: e.Time, Type: "content_changed", Repository: e.Repository, Part: e.Part}
+	case stargazer.Paused:
+		return eventRecord{Time: e.Time, Type: "paused", Repository: e.Repository}
+	case stargazer.Resumed:
+		return eventRecord{Time: e.Time, Type: "resumed", Repository: e.Repository}
+	case stargazer.Stopped:
+		return eventRecord{Time: e.Time, Type: "stopped", Repository: e.Repository}
+	default:
+		return eventRecord{Time: time.Now(), Type: "unknown"}
+	}
+}
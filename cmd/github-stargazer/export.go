@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+)
+
+// export writes the history recorded for a repository to standard output.
+func export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	var (
+		repo        = fs.String("repo", "", "GitHub repository to export the history of (owner/repo)")
+		format      = fs.String("format", "csv", "Output format: csv or json")
+		from        = fs.String("from", "", "Export samples from this time (RFC 3339, default the beginning)")
+		to          = fs.String("to", "", "Export samples up to this time (RFC 3339, default now)")
+		storage     = fs.String("storage", "sqlite", "Storage driver that recorded the history: sqlite or bolt")
+		storagePath = fs.String("storage-path", "", "Where the storage driver keeps its data")
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *repo == "" {
+		return errors.New("repo is required")
+	}
+	if *storagePath == "" {
+		return errors.New("-storage-path is required")
+	}
+	start, end, err := parseTimeRange(*from, *to)
+	if err != nil {
+		return err
+	}
+	st, err := openStore(*storage, *storagePath)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	samples, err := st.History(*repo, start, end)
+	if err != nil {
+		return err
+	}
+	return writeHistory(os.Stdout, *format, samples)
+}
+
+// handleHistory serves GET /history/{owner}/{repo}, exporting the recorded
+// history of a repository. The format, from and to query parameters work like
+// the flags of the export subcommand.
+func (s *statusServer) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	repo := strings.Trim(strings.TrimPrefix(r.URL.Path, "/history/"), "/")
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "csv" && format != "json" {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q", format))
+		return
+	}
+	from, to, err := parseTimeRange(q.Get("from"), q.Get("to"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	samples, err := s.watches.store.History(repo, from, to)
+	if err == errNoHistory {
+		s.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.log.Warnw("unable to read history", "repo", repo, "err", err)
+		s.writeError(w, http.StatusInternalServerError, "unable to read history")
+		return
+	}
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if err := writeHistory(w, format, samples); err != nil {
+		s.log.Warnw("unable to write response", "err", err)
+	}
+}
+
+// writeHistory writes samples to w as CSV, with a header row, or as a JSON
+// array.
+func writeHistory(w io.Writer, format string, samples []stargazer.Sample) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"time", "count"})
+		for _, sample := range samples {
+			cw.Write([]string{sample.Time.UTC().Format(time.RFC3339), strconv.Itoa(sample.Count)})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		if samples == nil {
+			samples = []stargazer.Sample{}
+		}
+		return json.NewEncoder(w).Encode(samples)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// parseTimeRange parses the RFC 3339 bounds of a history query. An empty from
+// means the beginning of the history, and an empty to means now.
+func parseTimeRange(from, to string) (time.Time, time.Time, error) {
+	start, end := time.Unix(0, 0), time.Now()
+	var err error
+	if from != "" {
+		if start, err = time.Parse(time.RFC3339, from); err != nil {
+			return start, end, errors.Wrap(err, "invalid from time")
+		}
+	}
+	if to != "" {
+		if end, err = time.Parse(time.RFC3339, to); err != nil {
+			return start, end, errors.Wrap(err, "invalid to time")
+		}
+	}
+	return start, end, nil
+}
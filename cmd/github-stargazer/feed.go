@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+)
+
+// maxFeedEntries is how many of the latest events the feed keeps.
+const maxFeedEntries = 100
+
+// feedID identifies the feed, and prefixes the IDs of its entries.
+const feedID = "urn:github-stargazer:feed"
+
+// feedEntry is an event in the feed.
+type feedEntry struct {
+	ID      string
+	Repo    string
+	Title   string
+	Link    string
+	Updated time.Time
+}
+
+// feed is the latest milestone and release events of the watches, served as
+// an Atom feed. It is safe for concurrent use.
+type feed struct {
+	mu      sync.Mutex
+	entries []feedEntry
+}
+
+// add adds e to the feed, replacing any entry with the same ID.
+func (f *feed) add(e feedEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, old := range f.entries {
+		if old.ID == e.ID {
+			f.entries = append(f.entries[:i], f.entries[i+1:]...)
+			break
+		}
+	}
+	f.entries = append(f.entries, e)
+	if len(f.entries) > maxFeedEntries {
+		f.entries = f.entries[len(f.entries)-maxFeedEntries:]
+	}
+}
+
+// latest returns the entries for repo, or for every repository if repo is
+// empty, newest first.
+func (f *feed) latest(repo string) []feedEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var entries []feedEntry
+	for i := len(f.entries) - 1; i >= 0; i-- {
+		if repo == "" || watchKey(f.entries[i].Repo) == watchKey(repo) {
+			entries = append(entries, f.entries[i])
+		}
+	}
+	return entries
+}
+
+// record adds the feed entry for e, an event of w, if it is a milestone or,
+// for watches counting releases, a new release. Its title is the message
+// that would be sent for it.
+func (m *manager) record(w *watch, e stargazer.Event) {
+	spec := w.spec
+	var (
+		kind string
+		id   string
+		data messageData
+	)
+	switch e := e.(type) {
+	case stargazer.ThresholdCrossed:
+		kind = "milestone"
+		if target, _ := w.gazer.Targets(); e.Target == target {
+			kind = "target"
+		}
+		id = fmt.Sprintf("milestone:%d", e.Target)
+		data = messageData{Repo: e.Repository, Count: e.StargazersCount, Target: e.Target}
+	case stargazer.CountChanged:
+		if spec.Count != string(stargazer.Releases) || e.StargazersCount <= e.Previous {
+			return
+		}
+		kind = "release"
+		id = fmt.Sprintf("release:%d", e.StargazersCount)
+		data = messageData{Repo: e.Repository, Count: e.StargazersCount}
+	default:
+		return
+	}
+	title, err := m.notifier.message(spec, kind, data)
+	if err != nil {
+		m.log.Warnw("unable to render feed entry", "repo", spec.Repo, "err", err)
+		return
+	}
+	m.feed.add(feedEntry{
+		ID:      feedID + ":" + watchKey(spec.Repo) + ":" + id,
+		Repo:    spec.Repo,
+		Title:   title,
+		Link:    "https://" + repoLink(spec),
+		Updated: time.Now(),
+	})
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Link    atomLink `xml:"link"`
+	Summary string   `xml:"summary"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  string      `xml:"author>name"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// handleFeed serves the feed, narrowed to one repository with the repo
+// parameter.
+func (s *statusServer) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	repo := r.URL.Query().Get("repo")
+	af := atomFeed{
+		ID:     feedID,
+		Title:  "github stargazer",
+		Author: "github stargazer",
+		Link:   atomLink{Href: r.URL.String(), Rel: "self"},
+	}
+	if repo != "" {
+		af.ID += ":" + watchKey(repo)
+		af.Title += ": " + repo
+	}
+	updated := time.Unix(0, 0)
+	for _, e := range s.watches.feed.latest(repo) {
+		if e.Updated.After(updated) {
+			updated = e.Updated
+		}
+		af.Entries = append(af.Entries, atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Updated: e.Updated.Format(time.RFC3339),
+			Link:    atomLink{Href: e.Link},
+			Summary: e.Title,
+		})
+	}
+	af.Updated = updated.Format(time.RFC3339)
+	b, err := xml.MarshalIndent(af, "", "  ")
+	if err != nil {
+		s.log.Warnw("unable to encode feed", "err", err)
+		s.writeError(w, http.StatusInternalServerError, "unable to encode feed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	w.Write(b)
+}
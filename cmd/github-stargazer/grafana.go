@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+)
+
+// The status server speaks the protocol of Grafana's Simple JSON datasource
+// (and of the JSON datasource plugins that replaced it) under /grafana/, so
+// that the stored history can be charted in Grafana without an exporter. Each
+// watched repository is a metric, named owner/repo.
+
+// grafanaSearch is the body of a request to /grafana/search.
+type grafanaSearch struct {
+	Target string `json:"target"`
+}
+
+// grafanaQuery is the body of a request to /grafana/query.
+type grafanaQuery struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+		Type   string `json:"type"`
+	} `json:"targets"`
+	MaxDataPoints int `json:"maxDataPoints"`
+}
+
+// grafanaSeries is a time series in a response to /grafana/query. Each of its
+// datapoints is a count and the time of the sample in Unix milliseconds.
+type grafanaSeries struct {
+	Target     string     `json:"target"`
+	Datapoints [][2]int64 `json:"datapoints"`
+}
+
+// handleGrafana serves the Grafana datasource endpoints: / to test the
+// connection, /search to list the metrics, /query for their history and
+// /annotations, of which there are none.
+func (s *statusServer) handleGrafana(w http.ResponseWriter, r *http.Request) {
+	switch strings.Trim(strings.TrimPrefix(r.URL.Path, "/grafana"), "/") {
+	case "":
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	case "search":
+		s.handleGrafanaSearch(w, r)
+	case "query":
+		s.handleGrafanaQuery(w, r)
+	case "annotations":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, []struct{}{})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleGrafanaSearch lists the watched repositories whose names contain the
+// target of the search.
+func (s *statusServer) handleGrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	var search grafanaSearch
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil && err != io.EOF {
+		s.writeError(w, http.StatusBadRequest, "invalid search: "+err.Error())
+		return
+	}
+	metrics := []string{}
+	for _, wt := range s.watches.list() {
+		if strings.Contains(watchKey(wt.gazer.Repository), watchKey(search.Target)) {
+			metrics = append(metrics, wt.gazer.Repository)
+		}
+	}
+	s.writeJSON(w, http.StatusOK, metrics)
+}
+
+// handleGrafanaQuery returns the history of each target of the query over its
+// range, thinned to at most its maxDataPoints samples each.
+func (s *statusServer) handleGrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	var query grafanaQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		s.writeError(w, http.StatusBadRequest, "invalid query: "+err.Error())
+		return
+	}
+	from, to := query.Range.From, query.Range.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	series := []grafanaSeries{}
+	for _, target := range query.Targets {
+		if target.Target == "" {
+			continue
+		}
+		if target.Type != "" && target.Type != "timeserie" {
+			s.writeError(w, http.StatusBadRequest, "only timeserie targets are supported")
+			return
+		}
+		samples, err := s.historyBetween(target.Target, from, to)
+		if err != nil && err != errNoHistory {
+			s.log.Warnw("unable to read history", "repo", target.Target, "err", err)
+			s.writeError(w, http.StatusInternalServerError, "unable to read history")
+			return
+		}
+		samples = thin(samples, query.MaxDataPoints)
+		datapoints := make([][2]int64, 0, len(samples))
+		for _, sample := range samples {
+			datapoints = append(datapoints, [2]int64{int64(sample.Count), sample.Time.UnixMilli()})
+		}
+		series = append(series, grafanaSeries{Target: target.Target, Datapoints: datapoints})
+	}
+	s.writeJSON(w, http.StatusOK, series)
+}
+
+// thin returns at most max of samples, evenly spread and always including the
+// latest, or all of them if max is 0.
+func thin(samples []stargazer.Sample, max int) []stargazer.Sample {
+	if max <= 0 || len(samples) <= max {
+		return samples
+	}
+	if max == 1 {
+		return samples[len(samples)-1:]
+	}
+	thinned := make([]stargazer.Sample, 0, max)
+	for i := 0; i < max; i++ {
+		thinned = append(thinned, samples[(len(samples)-1)*i/(max-1)])
+	}
+	return thinned
+}
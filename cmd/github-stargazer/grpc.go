@@ -0,0 +1,385 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strings"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/ianfoo/github-stargazer/stargazerpb"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcServer serves the Stargazer gRPC service, which manages the watches,
+// reports their status and streams their events, for clients that would
+// rather have generated, strongly typed stubs than the HTTP API.
+type grpcServer struct {
+	stargazerpb.UnimplementedStargazerServer
+
+	addr    string
+	watches *manager
+	events  *eventLog
+	log     *zap.SugaredLogger
+
+	// controlToken is the bearer token that every call must carry.
+	controlToken string
+
+	server *grpc.Server
+
+	// stopping is closed when the server is shutting down, to end the
+	// event streams, which would otherwise hold up a graceful stop.
+	stopping chan struct{}
+}
+
+// newGRPCServer returns a server of the Stargazer service on addr, over TLS
+// with the certificate and key files if they are set.
+func newGRPCServer(addr string, watches *manager, events *eventLog, controlToken, tlsCert, tlsKey string, log *zap.SugaredLogger) (*grpcServer, error) {
+	s := &grpcServer{
+		addr:         addr,
+		watches:      watches,
+		events:       events,
+		log:          log,
+		controlToken: controlToken,
+		stopping:     make(chan struct{}),
+	}
+	options := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.authorizeUnary),
+		grpc.StreamInterceptor(s.authorizeStream),
+	}
+	if tlsCert != "" {
+		creds, err := credentials.NewServerTLSFromFile(tlsCert, tlsKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading TLS certificate for gRPC")
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+	s.server = grpc.NewServer(options...)
+	stargazerpb.RegisterStargazerServer(s.server, s)
+	return s, nil
+}
+
+// serve runs the gRPC server until it fails or is shut down.
+func (s *grpcServer) serve() {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		s.log.Errorw("unable to listen for gRPC", "addr", s.addr, "err", err)
+		return
+	}
+	s.log.Infow("serving gRPC", "addr", s.addr)
+	if err := s.server.Serve(lis); err != nil {
+		s.log.Errorw("gRPC server failed", "err", err)
+	}
+}
+
+// shutdown ends the event streams and stops the server once the calls in
+// progress have finished, or when ctx is done, whichever comes first.
+func (s *grpcServer) shutdown(ctx context.Context) {
+	close(s.stopping)
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
+
+// authorize checks that the metadata of ctx carries the control token as a
+// bearer token.
+func (s *grpcServer) authorize(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		if !strings.HasPrefix(auth, "Bearer ") {
+			continue
+		}
+		token := strings.TrimPrefix(auth, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.controlToken)) == 1 {
+			return nil
+		}
+	}
+	return grpcstatus.Error(codes.Unauthenticated, "unauthorized")
+}
+
+func (s *grpcServer) authorizeUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *grpcServer) authorizeStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// watchError returns the gRPC status of an error from the manager.
+func watchError(err error) error {
+	switch err {
+	case errWatchExists:
+		return grpcstatus.Error(codes.AlreadyExists, err.Error())
+	case errWatchNotFound:
+		return grpcstatus.Error(codes.NotFound, err.Error())
+	default:
+		return grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+}
+
+// lookup returns the watch of repo, or a NotFound status if there is none.
+func (s *grpcServer) lookup(repo string) (*watch, error) {
+	wt, ok := s.watches.get(repo)
+	if !ok {
+		return nil, grpcstatus.Error(codes.NotFound, errWatchNotFound.Error())
+	}
+	return wt, nil
+}
+
+func (s *grpcServer) ListWatches(ctx context.Context, req *stargazerpb.ListWatchesRequest) (*stargazerpb.ListWatchesResponse, error) {
+	resp := &stargazerpb.ListWatchesResponse{}
+	for _, wt := range s.watches.list() {
+		resp.Watches = append(resp.Watches, specToProto(wt.spec))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) GetWatch(ctx context.Context, req *stargazerpb.GetWatchRequest) (*stargazerpb.WatchSpec, error) {
+	wt, err := s.lookup(req.GetRepo())
+	if err != nil {
+		return nil, err
+	}
+	return specToProto(wt.spec), nil
+}
+
+func (s *grpcServer) CreateWatch(ctx context.Context, req *stargazerpb.CreateWatchRequest) (*stargazerpb.WatchSpec, error) {
+	if req.GetWatch() == nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "watch is required")
+	}
+	spec := specFromProto(req.GetWatch())
+	if err := s.watches.create(spec); err != nil {
+		return nil, watchError(err)
+	}
+	return s.GetWatch(ctx, &stargazerpb.GetWatchRequest{Repo: spec.Repo})
+}
+
+func (s *grpcServer) UpdateWatch(ctx context.Context, req *stargazerpb.UpdateWatchRequest) (*stargazerpb.WatchSpec, error) {
+	if req.GetWatch() == nil {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "watch is required")
+	}
+	spec := specFromProto(req.GetWatch())
+	repo := req.GetRepo()
+	if repo == "" {
+		repo = spec.Repo
+	}
+	if err := s.watches.update(repo, spec); err != nil {
+		return nil, watchError(err)
+	}
+	return s.GetWatch(ctx, &stargazerpb.GetWatchRequest{Repo: repo})
+}
+
+func (s *grpcServer) DeleteWatch(ctx context.Context, req *stargazerpb.DeleteWatchRequest) (*stargazerpb.DeleteWatchResponse, error) {
+	if err := s.watches.remove(req.GetRepo()); err != nil {
+		return nil, watchError(err)
+	}
+	return &stargazerpb.DeleteWatchResponse{}, nil
+}
+
+func (s *grpcServer) PauseWatch(ctx context.Context, req *stargazerpb.PauseWatchRequest) (*stargazerpb.WatchStatus, error) {
+	wt, err := s.lookup(req.GetRepo())
+	if err != nil {
+		return nil, err
+	}
+	wt.gazer.Pause()
+	return statusToProto(wt.gazer), nil
+}
+
+func (s *grpcServer) ResumeWatch(ctx context.Context, req *stargazerpb.ResumeWatchRequest) (*stargazerpb.WatchStatus, error) {
+	wt, err := s.lookup(req.GetRepo())
+	if err != nil {
+		return nil, err
+	}
+	wt.gazer.Resume()
+	return statusToProto(wt.gazer), nil
+}
+
+func (s *grpcServer) GetStatus(ctx context.Context, req *stargazerpb.GetStatusRequest) (*stargazerpb.GetStatusResponse, error) {
+	resp := &stargazerpb.GetStatusResponse{}
+	if req.GetRepo() != "" {
+		wt, err := s.lookup(req.GetRepo())
+		if err != nil {
+			return nil, err
+		}
+		resp.Watches = append(resp.Watches, statusToProto(wt.gazer))
+		return resp, nil
+	}
+	for _, wt := range s.watches.list() {
+		resp.Watches = append(resp.Watches, statusToProto(wt.gazer))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) StreamEvents(req *stargazerpb.StreamEventsRequest, stream stargazerpb.Stargazer_StreamEventsServer) error {
+	records, unsubscribe := s.events.subscribe()
+	defer unsubscribe()
+	repo := watchKey(req.GetRepo())
+	for {
+		select {
+		case rec := <-records:
+			if repo != "" && watchKey(rec.Repository) != repo {
+				continue
+			}
+			if err := stream.Send(eventToProto(rec)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		case <-s.stopping:
+			return grpcstatus.Error(codes.Unavailable, "shutting down")
+		}
+	}
+}
+
+func specToProto(spec watchSpec) *stargazerpb.WatchSpec {
+	pb := &stargazerpb.WatchSpec{
+		Repo:          spec.Repo,
+		Provider:      spec.Provider,
+		BaseUrl:       spec.BaseURL,
+		Count:         spec.Count,
+		Target:        spec.Target,
+		Milestones:    ints64(spec.Milestones),
+		Progress:      ints64(spec.Progress),
+		Interval:      spec.Interval,
+		Schedule:      spec.Schedule,
+		Report:        spec.Report,
+		Deadline:      spec.Deadline,
+		VelocityAlert: spec.VelocityAlert,
+		Phone:         spec.Phone,
+		Lang:          spec.Lang,
+		HackerNews:    mentionToProto(spec.HackerNews),
+		Reddit:        mentionToProto(spec.Reddit),
+		Channels:      spec.Channels,
+		Templates:     spec.Templates,
+		Rival:         spec.Rival,
+		RivalGap:      int64(spec.RivalGap),
+	}
+	if len(spec.IntervalAt) > 0 {
+		pb.IntervalAt = make(map[int64]string, len(spec.IntervalAt))
+		for count, interval := range spec.IntervalAt {
+			pb.IntervalAt[int64(count)] = interval
+		}
+	}
+	return pb
+}
+
+func specFromProto(pb *stargazerpb.WatchSpec) watchSpec {
+	spec := watchSpec{
+		Repo:          pb.GetRepo(),
+		Provider:      pb.GetProvider(),
+		BaseURL:       pb.GetBaseUrl(),
+		Count:         pb.GetCount(),
+		Target:        pb.GetTarget(),
+		Milestones:    ints(pb.GetMilestones()),
+		Progress:      ints(pb.GetProgress()),
+		Interval:      pb.GetInterval(),
+		Schedule:      pb.GetSchedule(),
+		Report:        pb.GetReport(),
+		Deadline:      pb.GetDeadline(),
+		VelocityAlert: pb.GetVelocityAlert(),
+		Phone:         pb.GetPhone(),
+		Lang:          pb.GetLang(),
+		HackerNews:    mentionFromProto(pb.GetHackerNews()),
+		Reddit:        mentionFromProto(pb.GetReddit()),
+		Channels:      pb.GetChannels(),
+		Templates:     pb.GetTemplates(),
+		Rival:         pb.GetRival(),
+		RivalGap:      int(pb.GetRivalGap()),
+	}
+	if len(pb.GetIntervalAt()) > 0 {
+		spec.IntervalAt = make(map[int]string, len(pb.GetIntervalAt()))
+		for count, interval := range pb.GetIntervalAt() {
+			spec.IntervalAt[int(count)] = interval
+		}
+	}
+	return spec
+}
+
+func mentionToProto(spec *mentionSpec) *stargazerpb.MentionSpec {
+	if spec == nil {
+		return nil
+	}
+	return &stargazerpb.MentionSpec{Points: ints64(spec.Points), Subreddits: spec.Subreddits}
+}
+
+func mentionFromProto(pb *stargazerpb.MentionSpec) *mentionSpec {
+	if pb == nil {
+		return nil
+	}
+	return &mentionSpec{Points: ints(pb.GetPoints()), Subreddits: pb.GetSubreddits()}
+}
+
+func statusToProto(gazer *stargazer.GitHubStargazer) *stargazerpb.WatchStatus {
+	target, milestones := gazer.Targets()
+	v := gazer.Velocity()
+	return &stargazerpb.WatchStatus{
+		Repository:          gazer.Repository,
+		StargazersCount:     int64(gazer.StargazersCount()),
+		StargazersTarget:    int64(target),
+		Milestones:          ints64(milestones),
+		Velocity:            &stargazerpb.Velocity{StarsPerHour: v.PerHour, StarsPerDay: v.PerDay},
+		Paused:              gazer.Paused(),
+		Ready:               gazer.Ready(),
+		ConsecutiveFailures: int64(gazer.ConsecutiveFailures()),
+	}
+}
+
+func eventToProto(rec eventRecord) *stargazerpb.Event {
+	return &stargazerpb.Event{
+		Time:       timestamppb.New(rec.Time),
+		Type:       rec.Type,
+		Repository: rec.Repository,
+		Count:      int64(rec.Count),
+		Previous:   int64(rec.Previous),
+		Target:     int64(rec.Target),
+		Final:      rec.Final,
+		Op:         rec.Op,
+		Failures:   int64(rec.Failures),
+		Channel:    rec.Channel,
+		To:         rec.To,
+		Message:    rec.Message,
+		Result:     rec.Result,
+		Error:      rec.Error,
+	}
+}
+
+func ints64(ns []int) []int64 {
+	if ns == nil {
+		return nil
+	}
+	out := make([]int64, len(ns))
+	for i, n := range ns {
+		out[i] = int64(n)
+	}
+	return out
+}
+
+func ints(ns []int64) []int {
+	if ns == nil {
+		return nil
+	}
+	out := make([]int, len(ns))
+	for i, n := range ns {
+		out[i] = int(n)
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+)
+
+// healthcheckTick is how often the watcher checks whether every watch has
+// fetched its count since the healthcheck was last pinged, and so how often
+// it is pinged at most.
+const healthcheckTick = 30 * time.Second
+
+// healthcheck is a URL, like that of a Healthchecks.io check or any other
+// dead man's switch, to ping each time every watch has fetched its count, so
+// that monitoring notices when the watcher dies, hangs or can no longer fetch.
+type healthcheck struct {
+	url    string
+	client *http.Client
+}
+
+// ping pings the healthcheck with a GET request.
+func (h *healthcheck) ping() error {
+	req, err := http.NewRequest("GET", h.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", stargazer.UserAgent)
+	client := h.client
+	if client == nil {
+		client = &http.Client{Timeout: 20 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL is left out of the error, as the URL of a check is
+		// all it takes to ping it.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return errors.Wrapf(err, "error pinging %s", req.URL.Host)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("error pinging %s: %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// pingHealthcheck pings the healthcheck each time every watch that isn't
+// paused has fetched its count since the last ping, until stop is closed.
+func (a *app) pingHealthcheck(h *healthcheck, stop <-chan struct{}) {
+	t := time.NewTicker(healthcheckTick)
+	defer t.Stop()
+	last := time.Unix(0, 0)
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-t.C:
+			if !a.watches.fetchedSince(last) {
+				continue
+			}
+			if err := h.ping(); err != nil {
+				a.log.Warnw("unable to ping healthcheck", "err", err)
+				continue
+			}
+			last = now
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+)
+
+// The metrics that the history of a watch can be queried for: its count, how
+// much the count changed since the previous point, and how fast it changed,
+// per hour.
+const (
+	metricCount = "count"
+	metricDelta = "delta"
+	metricRate  = "rate"
+)
+
+// historyPoint is a point of a queried history.
+type historyPoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// historyResponse is the response to a query of a watch's history.
+type historyResponse struct {
+	Repository string         `json:"repository"`
+	Metric     string         `json:"metric"`
+	Resolution string         `json:"resolution,omitempty"`
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Points     []historyPoint `json:"points"`
+}
+
+// handleRepos serves the history of a repo at /repos/owner/repo/history to
+// anyone, like /history/, and the actions under /repos/ to holders of the
+// control token, if it is set.
+func (s *statusServer) handleRepos(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(strings.TrimRight(r.URL.Path, "/"), "/history") {
+		s.handleRepoHistory(w, r)
+		return
+	}
+	if s.controlToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	s.requireToken(s.handleControl)(w, r)
+}
+
+// handleRepoHistory serves GET /repos/{owner}/{repo}/history, querying the
+// history of a repository between the RFC 3339 from and to parameters. The
+// metric parameter is count (the default), delta or rate, and resolution is
+// how far apart the points are, as a duration like 1h, rather than one point
+// for every sample.
+func (s *statusServer) handleRepoHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	repo := strings.TrimSuffix(strings.Trim(r.URL.Path, "/"), "/history")
+	repo = strings.TrimPrefix(repo, "repos/")
+	q := r.URL.Query()
+	from, to, err := parseTimeRange(q.Get("from"), q.Get("to"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	metric := q.Get("metric")
+	if metric == "" {
+		metric = metricCount
+	}
+	if metric != metricCount && metric != metricDelta && metric != metricRate {
+		s.writeError(w, http.StatusBadRequest, "metric must be count, delta or rate")
+		return
+	}
+	var resolution time.Duration
+	if res := q.Get("resolution"); res != "" {
+		if resolution, err = time.ParseDuration(res); err != nil || resolution <= 0 {
+			s.writeError(w, http.StatusBadRequest, "resolution must be a positive duration")
+			return
+		}
+	}
+	samples, err := s.historyBetween(repo, from, to)
+	if err == errNoHistory {
+		s.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.log.Warnw("unable to read history", "repo", repo, "err", err)
+		s.writeError(w, http.StatusInternalServerError, "unable to read history")
+		return
+	}
+	resp := historyResponse{
+		Repository: repo,
+		Metric:     metric,
+		From:       from,
+		To:         to,
+		Points:     historyPoints(resample(samples, resolution), metric),
+	}
+	if resolution > 0 {
+		resp.Resolution = resolution.String()
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+// resample returns the last of samples in each period of resolution, at the
+// start of the period, or samples as they are if resolution is 0.
+func resample(samples []stargazer.Sample, resolution time.Duration) []stargazer.Sample {
+	if resolution <= 0 {
+		return samples
+	}
+	var resampled []stargazer.Sample
+	for _, sample := range samples {
+		sample.Time = sample.Time.Truncate(resolution)
+		if n := len(resampled); n > 0 && resampled[n-1].Time.Equal(sample.Time) {
+			resampled[n-1] = sample
+			continue
+		}
+		resampled = append(resampled, sample)
+	}
+	return resampled
+}
+
+// historyPoints returns the points of metric for samples. As the delta and
+// rate of a sample are measured from the one before it, they have no point
+// for the first sample.
+func historyPoints(samples []stargazer.Sample, metric string) []historyPoint {
+	points := []historyPoint{}
+	for i, sample := range samples {
+		switch metric {
+		case metricCount:
+			points = append(points, historyPoint{Time: sample.Time, Value: float64(sample.Count)})
+		case metricDelta, metricRate:
+			if i == 0 {
+				continue
+			}
+			prev := samples[i-1]
+			value := float64(sample.Count - prev.Count)
+			if metric == metricRate {
+				hours := sample.Time.Sub(prev.Time).Hours()
+				if hours <= 0 {
+					continue
+				}
+				value /= hours
+			}
+			points = append(points, historyPoint{Time: sample.Time, Value: value})
+		}
+	}
+	return points
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+)
+
+// digestSize is how many repositories each ranking in the leaderboard digest
+// lists.
+const digestSize = 5
+
+// digestReferrers is how many of the top referrers of each repository the
+// leaderboard digest lists.
+const digestReferrers = 3
+
+// leaderboardEntry is a watch's place in a ranking of the leaderboard.
+type leaderboardEntry struct {
+	Rank            int                `json:"rank"`
+	Repository      string             `json:"repository"`
+	Site            string             `json:"site"`
+	Count           string             `json:"count"`
+	StargazersCount int                `json:"stargazers_count"`
+	Velocity        stargazer.Velocity `json:"velocity"`
+
+	// Referrers are the top referrers of the repository, if they are
+	// fetched with -referrers-every.
+	Referrers []stargazer.Referrer `json:"referrers,omitempty"`
+}
+
+// leaderboard ranks the watches by their counts, and by how fast their
+// counts have been growing over the velocity window.
+type leaderboard struct {
+	ByStars    []leaderboardEntry `json:"by_stars"`
+	ByVelocity []leaderboardEntry `json:"by_velocity"`
+}
+
+// newLeaderboard ranks watches, listing up to limit of them in each ranking,
+// or all of them if limit is 0. Ties are broken by the name of the
+// repository.
+func newLeaderboard(watches []*watch, limit int) leaderboard {
+	entries := make([]leaderboardEntry, 0, len(watches))
+	for _, w := range watches {
+		count := w.spec.Count
+		if count == "" {
+			count = string(stargazer.Stars)
+		}
+		referrers, _, _ := w.gazer.Referrers()
+		entries = append(entries, leaderboardEntry{
+			Repository:      w.gazer.Repository,
+			Site:            siteName(w.spec),
+			Count:           count,
+			StargazersCount: w.gazer.StargazersCount(),
+			Velocity:        w.gazer.Velocity(),
+			Referrers:       referrers,
+		})
+	}
+	byStars := rank(entries, limit, func(a, b leaderboardEntry) bool {
+		return a.StargazersCount > b.StargazersCount
+	})
+	byVelocity := rank(entries, limit, func(a, b leaderboardEntry) bool {
+		return a.Velocity.PerHour > b.Velocity.PerHour
+	})
+	return leaderboard{ByStars: byStars, ByVelocity: byVelocity}
+}
+
+// rank returns a copy of entries sorted by ahead, numbered from 1 and cut
+// to limit unless it is 0.
+func rank(entries []leaderboardEntry, limit int, ahead func(a, b leaderboardEntry) bool) []leaderboardEntry {
+	ranked := append([]leaderboardEntry{}, entries...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ahead(ranked[i], ranked[j]) {
+			return true
+		}
+		if ahead(ranked[j], ranked[i]) {
+			return false
+		}
+		return watchKey(ranked[i].Repository) < watchKey(ranked[j].Repository)
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+	return ranked
+}
+
+func (s *statusServer) handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 0 {
+			s.writeError(w, http.StatusBadRequest, "limit must be a non-negative number")
+			return
+		}
+		limit = n
+	}
+	s.writeJSON(w, http.StatusOK, newLeaderboard(s.watches.list(), limit))
+}
+
+// digest renders the leaderboard as a notification, under the headers of
+// the leaderboard templates. The top referrers of the repositories ranked by
+// count that have them are listed after the rankings.
+func (n *notifier) digest(lb leaderboard) (string, error) {
+	var b strings.Builder
+	var referred []leaderboardEntry
+	for _, e := range lb.ByStars {
+		if len(e.Referrers) > 0 {
+			referred = append(referred, e)
+		}
+	}
+	sections := []struct {
+		kind    string
+		entries []leaderboardEntry
+		line    func(e leaderboardEntry) string
+	}{
+		{"leaderboard", lb.ByStars, func(e leaderboardEntry) string {
+			return fmt.Sprintf("%d. %s %d", e.Rank, e.Repository, e.StargazersCount)
+		}},
+		{"leaderboard.velocity", lb.ByVelocity, func(e leaderboardEntry) string {
+			return fmt.Sprintf("%d. %s %+.1f/day", e.Rank, e.Repository, e.Velocity.PerDay)
+		}},
+		{"leaderboard.referrers", referred, func(e leaderboardEntry) string {
+			referrers := e.Referrers
+			if len(referrers) > digestReferrers {
+				referrers = referrers[:digestReferrers]
+			}
+			sites := make([]string, len(referrers))
+			for i, r := range referrers {
+				sites[i] = fmt.Sprintf("%s %d", r.Referrer, r.Count)
+			}
+			return fmt.Sprintf("%s: %s", e.Repository, strings.Join(sites, ", "))
+		}},
+	}
+	for _, section := range sections {
+		if section.kind == "leaderboard.referrers" && len(section.entries) == 0 {
+			continue
+		}
+		header, err := n.messages.render("", section.kind, messageData{Count: len(section.entries)})
+		if err != nil {
+			return "", err
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(header)
+		for _, e := range section.entries {
+			b.WriteString("\n" + section.line(e))
+		}
+	}
+	return b.String(), nil
+}
+
+// startDigest sends the leaderboard to the default phone number, or the
+// channels that routing rules route it to, every digestEvery until
+// stopDigest is called.
+func (m *manager) startDigest() {
+	if m.digestEvery <= 0 {
+		return
+	}
+	m.digestStop = make(chan struct{})
+	m.digestDone = make(chan struct{})
+	go func() {
+		defer close(m.digestDone)
+		t := time.NewTicker(m.digestEvery)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				m.sendDigest()
+			case <-m.digestStop:
+				return
+			}
+		}
+	}()
+}
+
+// stopDigest stops sending the leaderboard and waits for one being sent to
+// finish. The caller must not hold m.mu.
+func (m *manager) stopDigest() {
+	if m.digestStop == nil {
+		return
+	}
+	close(m.digestStop)
+	<-m.digestDone
+}
+
+func (m *manager) sendDigest() {
+	watches := m.list()
+	if len(watches) == 0 {
+		return
+	}
+	n := m.notifier
+	message, err := n.digest(newLeaderboard(watches, digestSize))
+	if err != nil {
+		m.log.Warnw("unable to render leaderboard digest", "err", err)
+		return
+	}
+	if err := n.notifyMessage(watchSpec{}, n.defaultPhone, "leaderboard", message); err != nil {
+		m.log.Warnw("unable to send leaderboard digest", "err", err)
+	}
+}
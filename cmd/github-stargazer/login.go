@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const envGitHubClientID = "GITHUB_CLIENT_ID"
+
+// login performs the GitHub OAuth device flow and stores the resulting token
+// where setup will find it.
+func login(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	var (
+		clientID = fs.String("client-id", os.Getenv(envGitHubClientID), "Client ID of the GitHub OAuth app to authorize")
+		baseURL  = fs.String("github-url", "https://github.com", "Base URL of the GitHub server to log in to")
+	)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *clientID == "" {
+		return errors.New("OAuth app client ID is required")
+	}
+	root := strings.TrimSuffix(strings.TrimRight(*baseURL, "/"), "/api/v3")
+	client := &http.Client{Timeout: 20 * time.Second}
+
+	var code struct {
+		DeviceCode      string `json:"device_code"`
+		UserCode        string `json:"user_code"`
+		VerificationURI string `json:"verification_uri"`
+		ExpiresIn       int    `json:"expires_in"`
+		Interval        int    `json:"interval"`
+	}
+	err := postOAuthForm(client, root+"/login/device/code", url.Values{
+		"client_id": {*clientID},
+		"scope":     {"public_repo"},
+	}, &code)
+	if err != nil {
+		return errors.Wrap(err, "error requesting device code")
+	}
+	fmt.Printf("Open %s and enter the code %s\n", code.VerificationURI, code.UserCode)
+
+	// RFC 8628 has clients poll every 5 seconds if the interval isn't given.
+	interval := 5 * time.Second
+	if code.Interval >= 1 {
+		interval = time.Duration(code.Interval) * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+		var token struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+		}
+		err := postOAuthForm(client, root+"/login/oauth/access_token", url.Values{
+			"client_id":   {*clientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		}, &token)
+		if err != nil {
+			return errors.Wrap(err, "error requesting access token")
+		}
+		switch token.Error {
+		case "":
+			path, err := storeToken(token.AccessToken)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Logged in. Token stored in %s\n", path)
+			return nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return fmt.Errorf("login failed: %s", token.Error)
+		}
+	}
+	return errors.New("login failed: device code expired")
+}
+
+// postOAuthForm posts values as a form to the OAuth endpoint and decodes
+// the JSON response into v.
+func postOAuthForm(client *http.Client, endpoint string, values url.Values, v interface{}) error {
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// tokenPath is where login stores the GitHub token.
+func tokenPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "github-stargazer", "token"), nil
+}
+
+func storeToken(token string) (string, error) {
+	path, err := tokenPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", errors.Wrap(err, "error creating config directory")
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", errors.Wrap(err, "error storing token")
+	}
+	return path, nil
+}
+
+// storedToken returns the token saved by login, or an empty string if there
+// isn't one.
+func storedToken() string {
+	path, err := tokenPath()
+	if err != nil {
+		return ""
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
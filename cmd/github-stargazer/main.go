@@ -1,13 +1,18 @@
 package main
 
 import (
-	"flag"
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
-	stargazer "github.com/ianfoo/github-stargazer"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
@@ -17,89 +22,350 @@ const (
 	envTwilioAuthToken   = "TWILIO_AUTH_TOKEN"
 	envTwilioPhoneNumber = "TWILIO_PHONE_NUMBER"
 	envGitHubToken       = "GITHUB_TOKEN"
+	envControlToken      = "STARGAZER_CONTROL_TOKEN"
 )
 
+// command is a subcommand of the binary.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// commands are the subcommands, in the order usage lists them.
+var commands = []command{
+	{"watch", "Watch repositories and send notifications (the default)", runWatch},
+	{"check", "Print the current stargazers count of a repository once", check},
+	{"unstar", "Remove your star from a repository, such as the one added at the target", unstar},
+	{"status", "Print the status of the watches of a running watcher", status},
+	{"send-test", "Send a test SMS to check the Twilio configuration", sendTest},
+	{"validate", "Check the configuration of watch without starting it", validate},
+	{"export", "Write the recorded history of a repository", export},
+	{"login", "Log in to GitHub and store the token for later runs", login},
+}
+
 func main() {
-	gazer, err := setup()
+	args := os.Args[1:]
+	name := "watch"
+	// Flags without a subcommand are those of watch, as they were before
+	// there were subcommands.
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage()
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(args); err != nil {
+				exit(err)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// usage lists the subcommands on standard error.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [command] [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun a command with -h for its flags.\n")
+}
+
+// runWatch runs the watcher until its watches finish or it is told to stop.
+func runWatch(args []string) error {
+	c, err := newConfig("watch", args)
+	if err != nil {
+		return err
+	}
+	a, err := setup(c)
 	if err != nil {
-		exit(err)
+		return err
+	}
+	a.run()
+	return nil
+}
+
+// app is the running watcher: its watches, and the status server reporting
+// on them, if there is one.
+type app struct {
+	watches         *manager
+	server          *statusServer
+	grpc            *grpcServer
+	systemd         *systemd
+	healthcheck     *healthcheck
+	log             *zap.SugaredLogger
+	shutdownTimeout time.Duration
+}
+
+// run runs until every watch has finished, or until the process is asked to
+// stop by SIGINT or SIGTERM.
+func (a *app) run() {
+	if a.server != nil {
+		go a.server.serve()
+	}
+	if a.grpc != nil {
+		go a.grpc.serve()
+	}
+	if a.systemd != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go a.supervise(a.systemd, stop)
 	}
-	gazer.Gaze()
+	if a.healthcheck != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go a.pingHealthcheck(a.healthcheck, stop)
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		a.watches.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case sig := <-signals:
+		a.log.Infow("shutting down", "signal", sig.String())
+		a.shutdown()
+	}
+	a.watches.notifier.unstarAll()
 }
 
-func setup() (*stargazer.GitHubStargazer, error) {
-	var (
-		repo     = flag.String("repo", "", "GitHub repository to watch (owner/repo)")
-		target   = flag.Uint("target", 0, "Target number of stargazers")
-		phone    = flag.String("phone", "", "Phone number to send SMS to upon reaching stargazer target")
-		interval = flag.Duration("interval", time.Minute, "How often to check stargazer count")
-		sender   = flag.String("sender", "", "Twilio phone number from which to send SMS messages")
-	)
-	var log *zap.SugaredLogger
-	{
-		plainLog, err := zap.NewDevelopment()
+// shutdown stops the status server and the watches, giving them until the
+// shutdown timeout to finish what they are doing.
+func (a *app) shutdown() {
+	if a.systemd != nil {
+		if err := a.systemd.notify("STOPPING=1"); err != nil {
+			a.log.Warnw("unable to notify systemd", "err", err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+	if a.server != nil {
+		if err := a.server.shutdown(ctx); err != nil {
+			a.log.Warnw("unable to shut down status server cleanly", "err", err)
+		}
+	}
+	if a.grpc != nil {
+		a.grpc.shutdown(ctx)
+	}
+	stopped := make(chan error, 1)
+	go func() { stopped <- a.watches.shutdown() }()
+	select {
+	case err := <-stopped:
 		if err != nil {
-			return nil, err
+			a.log.Warnw("unable to save watches", "err", err)
 		}
-		log = plainLog.Sugar()
+	case <-ctx.Done():
+		a.log.Warnw("timed out waiting for watches to stop")
 	}
+}
 
-	flag.Parse()
-	if *target == 0 {
-		return nil, errors.New("target stargazers must be greater than zero")
+// setup builds the watcher configured by c and starts its watches.
+func setup(c *config) (*app, error) {
+	log, level, err := c.logger()
+	if err != nil {
+		return nil, err
 	}
-	if *phone == "" {
-		return nil, errors.New("phone number is required")
+	if err := c.check(); err != nil {
+		return nil, err
 	}
-	if *repo == "" {
-		return nil, errors.New("repo is required")
+	n, err := c.notifier(log)
+	if err != nil {
+		return nil, err
 	}
-	if *sender == "" {
-		*sender = os.Getenv(envTwilioPhoneNumber)
+	if c.eventLogFile != "" {
+		if n.events, err = openEventLog(c.eventLogFile); err != nil {
+			return nil, err
+		}
 	}
-	twilio, err := stargazer.NewTwilioSMSSender(os.Getenv(envTwilioAccountSID),
-		os.Getenv(envTwilioAuthToken),
-		*sender, stargazer.WithTwilioLogger(log))
+	spec, ok, err := c.spec()
 	if err != nil {
 		return nil, err
 	}
-
-	gazer, err := stargazer.NewGitHubStargazer(
-		*repo,
-		int(*target),
-		*interval,
-		nil,
-		stargazer.WithGitHubLogger(log),
-		stargazer.WithGitHubToken(os.Getenv(envGitHubToken)))
+	accounts, err := c.accounts(n.client)
 	if err != nil {
 		return nil, err
 	}
-	hook := func() error {
-		err := twilio.Send(*phone, fmt.Sprintf(
-			"Hey! GitHub repo %s has reached %d stargazers!",
-			gazer.Repository, gazer.StargazersCount()))
+	controlToken := os.Getenv(envControlToken)
+	m := &manager{
+		notifier:    n,
+		log:         log,
+		file:        c.watchesFile,
+		backfill:    c.backfill,
+		retention:   retention{raw: c.retainRaw, rollups: c.retainRollups},
+		digestEvery: c.leaderboardEvery,
+		feed:        &feed{},
+		keepAlive:   (c.statusAddr != "" && controlToken != "") || len(accounts) > 0,
+	}
+	sd := newSystemd()
+	if sd != nil && sd.watchdog > 0 {
+		m.heartbeat = sd.heartbeat()
+	}
+	if m.store, err = openStore(c.storage, c.storagePath); err != nil {
+		return nil, err
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	if ok {
+		if err := m.put(spec); err != nil {
+			return nil, err
+		}
+	}
+	if len(accounts) > 0 {
+		template, err := c.watchTemplate()
+		if err != nil {
+			return nil, err
+		}
+		filter, err := c.accountFilter()
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range accounts {
+			w := newAccountWatcher(a.name, a.lister, template, filter, m, log)
+			if err := w.sync(); err != nil {
+				return nil, err
+			}
+			m.accounts = append(m.accounts, w)
+			go w.run()
+		}
+	}
+	if len(m.list()) == 0 && !m.keepAlive {
+		return nil, errors.New("repo is required")
+	}
+	var server *statusServer
+	if c.statusAddr != "" {
+		api, err := newAPIValidator(openAPISpec)
 		if err != nil {
-			log.Warnw("unable to send SMS", "err", err)
+			return nil, err
 		}
-		if err := gazer.Star(); err != nil {
-			log.Warnw("unable to star repo", "repo", gazer.Repository, "err", err)
-			return err
+		server = &statusServer{
+			watches:        m,
+			log:            log,
+			unhealthyAfter: c.unhealthy,
+			controlToken:   controlToken,
+			audit:          n.audit,
+			metrics:        n.metrics,
+			logLevel:       level,
+			tlsCert:        c.tlsCert,
+			tlsKey:         c.tlsKey,
+			autocertHost:   c.autocertHost,
+			autocertCache:  c.autocertCache,
+			api:            api,
 		}
-		err = twilio.Send(*phone, fmt.Sprintf(
-			"Hey! GitHub repo %s has been starred by you!",
-			gazer.Repository))
+		server.server = &http.Server{Addr: c.statusAddr, Handler: server.handler()}
+	}
+	var rpc *grpcServer
+	if c.grpcAddr != "" {
+		if controlToken == "" {
+			return nil, errors.Errorf("-grpc-addr requires %s to be set", envControlToken)
+		}
+		if n.events == nil {
+			n.events = &eventLog{}
+		}
+		rpc, err = newGRPCServer(c.grpcAddr, m, n.events, controlToken, c.tlsCert, c.tlsKey, log)
 		if err != nil {
-			log.Warnw("unable to send SMS", "err", err)
+			return nil, err
 		}
-		gazer.Stop()
-		return nil
 	}
-	gazer.SetHook(hook)
-	return gazer, nil
+	var hc *healthcheck
+	if c.healthcheckURL != "" {
+		hc = &healthcheck{url: c.healthcheckURL, client: n.client}
+	}
+	return &app{
+		watches:         m,
+		server:          server,
+		grpc:            rpc,
+		systemd:         sd,
+		healthcheck:     hc,
+		log:             log,
+		shutdownTimeout: c.shutdownTimeout,
+	}, nil
+}
+
+// parseTarget parses a target stargazers count. A leading plus sign marks the
+// target as relative to the current count.
+func parseTarget(target string) (count int, relative bool, err error) {
+	if strings.HasPrefix(target, "+") {
+		relative = true
+		target = target[1:]
+	}
+	count, err = strconv.Atoi(target)
+	if err != nil || count < 1 {
+		return 0, false, errors.New("target stargazers must be greater than zero")
+	}
+	return count, relative, nil
+}
+
+// parseIntList parses a comma-separated list of positive integers. The name
+// describes an element of the list in error messages.
+func parseIntList(list, name string) ([]int, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var ints []int
+	for _, field := range strings.Split(list, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %s %q", name, field)
+		}
+		ints = append(ints, n)
+	}
+	return ints, nil
+}
+
+// parseIntervalList parses a comma-separated list of count=interval pairs,
+// checking that each interval is a valid duration.
+func parseIntervalList(list string) (map[int]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+	intervals := make(map[int]string)
+	for _, field := range strings.Split(list, ",") {
+		count, interval, _ := strings.Cut(strings.TrimSpace(field), "=")
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid interval count %q", field)
+		}
+		if _, err := time.ParseDuration(interval); err != nil {
+			return nil, fmt.Errorf("invalid interval %q", field)
+		}
+		intervals[n] = interval
+	}
+	return intervals, nil
+}
+
+// exitStatus is an error that makes the process exit with a particular
+// status. Its err, if any, is logged first.
+type exitStatus struct {
+	code int
+	err  error
+}
+
+func (es exitStatus) Error() string {
+	if es.err == nil {
+		return fmt.Sprintf("exit status %d", es.code)
+	}
+	return es.err.Error()
 }
 
 func exit(err error) {
 	log.SetFlags(0)
 	log.SetPrefix("")
+	if es, ok := err.(exitStatus); ok {
+		if es.err != nil {
+			log.Print(es.err)
+		}
+		os.Exit(es.code)
+	}
 	log.Fatal(err)
 }
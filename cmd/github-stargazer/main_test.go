@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		target       string
+		wantCount    int
+		wantRelative bool
+		wantErr      bool
+	}{
+		{target: "100", wantCount: 100},
+		{target: "+50", wantCount: 50, wantRelative: true},
+		{target: "1", wantCount: 1},
+		{target: "0", wantErr: true},
+		{target: "+0", wantErr: true},
+		{target: "-5", wantErr: true},
+		{target: "", wantErr: true},
+		{target: "+", wantErr: true},
+		{target: "+-5", wantErr: true},
+		{target: "1k", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			count, relative, err := parseTarget(tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if count != tt.wantCount || relative != tt.wantRelative {
+				t.Errorf("got %d, relative %v, want %d, relative %v", count, relative, tt.wantCount, tt.wantRelative)
+			}
+		})
+	}
+}
+
+func TestParseIntervalList(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    map[int]string
+		wantErr bool
+	}{
+		{list: ""},
+		{list: "100=1m", want: map[int]string{100: "1m"}},
+		{list: "100=1m, 1000=10s", want: map[int]string{100: "1m", 1000: "10s"}},
+		{list: "100=1m,100=30s", want: map[int]string{100: "30s"}},
+		{list: "100", wantErr: true},
+		{list: "100=", wantErr: true},
+		{list: "0=1m", wantErr: true},
+		{list: "x=1m", wantErr: true},
+		{list: "100=soon", wantErr: true},
+		{list: "100=1m,", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, err := parseIntervalList(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+)
+
+// mentionSpec asks to be notified about posts that link to a watched
+// repository on a site such as Hacker News: when they're posted, when they
+// reach the front page, and when their points reach any of Points. On Reddit,
+// only Subreddits are searched, if there are any.
+type mentionSpec struct {
+	Points     []int    `json:"points,omitempty"`
+	Subreddits []string `json:"subreddits,omitempty"`
+}
+
+// repoLink is the address of the repository of spec, without its scheme, as
+// posts would link to it. spec must have been resolved with resolveProvider.
+func repoLink(spec watchSpec) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(spec.BaseURL, "https://"), "http://")
+	if host == "" {
+		for h, provider := range providerHosts {
+			if provider == spec.Provider {
+				host = h
+			}
+		}
+	}
+	return host + "/" + providerPathPrefixes[spec.Provider] + spec.Repo
+}
+
+// newMentionWatchers creates watchers for the posts about the repository of
+// spec that it asks to be notified about. The watchers are not started.
+func (n *notifier) newMentionWatchers(spec watchSpec) ([]*stargazer.MentionWatcher, error) {
+	var watchers []*stargazer.MentionWatcher
+	if spec.HackerNews != nil {
+		source, err := stargazer.NewHackerNewsSource(repoLink(spec), n.hackerNewsOptions...)
+		if err != nil {
+			return nil, err
+		}
+		mw, err := n.newMentionWatcher(spec, source, spec.HackerNews)
+		if err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, mw)
+	}
+	if spec.Reddit != nil {
+		options := append([]func(*stargazer.RedditSource){
+			stargazer.WithSubreddits(spec.Reddit.Subreddits...),
+		}, n.redditOptions...)
+		source, err := stargazer.NewRedditSource(repoLink(spec), options...)
+		if err != nil {
+			return nil, err
+		}
+		mw, err := n.newMentionWatcher(spec, source, spec.Reddit)
+		if err != nil {
+			return nil, err
+		}
+		watchers = append(watchers, mw)
+	}
+	return watchers, nil
+}
+
+// newMentionWatcher creates a watcher for the posts from source that
+// mentions asks to be notified about.
+func (n *notifier) newMentionWatcher(
+	spec watchSpec,
+	source stargazer.MentionSource,
+	mentions *mentionSpec) (*stargazer.MentionWatcher, error) {
+
+	phone := spec.Phone
+	if phone == "" {
+		phone = n.defaultPhone
+	}
+	data := func(m stargazer.Mention) messageData {
+		return messageData{
+			Repo:   spec.Repo,
+			Forum:  m.Site,
+			Title:  m.Title,
+			Link:   m.Link,
+			Points: m.Points,
+		}
+	}
+	options := []func(*stargazer.MentionWatcher){
+		stargazer.WithMentionLogger(n.log),
+		stargazer.WithNewMentionHook(func(m stargazer.Mention) error {
+			return n.notify(spec, phone, "mention", data(m))
+		}),
+		stargazer.WithFrontPageHook(func(m stargazer.Mention) error {
+			return n.notify(spec, phone, "front-page", data(m))
+		}),
+	}
+	if len(mentions.Points) > 0 {
+		pointsHook := func(m stargazer.Mention, threshold int) error {
+			d := data(m)
+			d.Target = threshold
+			return n.notify(spec, phone, "points", d)
+		}
+		options = append(options, stargazer.WithPointsHook(pointsHook, mentions.Points...))
+	}
+	return stargazer.NewMentionWatcher(spec.Repo, source, n.mentionInterval, options...)
+}
+
+// spikeTimeout is how long to look for the source of a spike before sending
+// the velocity notification without it, as the lookup holds up the gazer.
+const spikeTimeout = 5 * time.Second
+
+// spikeSources returns the sources to look for the post most likely to have
+// set off a spike in the count of the watch of spec on: Hacker News, Reddit
+// (only in the subreddits the watch searches, if it searches any) and
+// Lobsters. It returns none if spikeWindow is 0.
+func (n *notifier) spikeSources(spec watchSpec) ([]stargazer.MentionSource, error) {
+	if n.spikeWindow <= 0 {
+		return nil, nil
+	}
+	link := repoLink(spec)
+	hackerNews, err := stargazer.NewHackerNewsSource(link, n.hackerNewsOptions...)
+	if err != nil {
+		return nil, err
+	}
+	redditOptions := n.redditOptions
+	if spec.Reddit != nil {
+		redditOptions = append([]func(*stargazer.RedditSource){
+			stargazer.WithSubreddits(spec.Reddit.Subreddits...),
+		}, redditOptions...)
+	}
+	reddit, err := stargazer.NewRedditSource(link, redditOptions...)
+	if err != nil {
+		return nil, err
+	}
+	lobsters, err := stargazer.NewLobstersSource(link, n.lobstersOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return []stargazer.MentionSource{hackerNews, reddit, lobsters}, nil
+}
+
+// spikeSource looks among sources for the post most likely to have set off a
+// spike in the count of gazer, among those made within spikeWindow of its
+// latest sample, which is timed by the gazer's clock. It returns false if
+// there is none, if there are no sources, or if they take longer than
+// spikeTimeout to answer.
+func (n *notifier) spikeSource(gazer *stargazer.GitHubStargazer, sources []stargazer.MentionSource) (stargazer.Mention, bool) {
+	history := gazer.History()
+	if len(sources) == 0 || len(history) == 0 {
+		return stargazer.Mention{}, false
+	}
+	since := history[len(history)-1].Time.Add(-n.spikeWindow)
+	type result struct {
+		m   stargazer.Mention
+		ok  bool
+		err error
+	}
+	found := make(chan result, 1)
+	go func() {
+		m, ok, err := stargazer.SpikeSource(since, sources...)
+		found <- result{m, ok, err}
+	}()
+	timeout := time.NewTimer(spikeTimeout)
+	defer timeout.Stop()
+	select {
+	case r := <-found:
+		if r.err != nil {
+			n.log.Warnw("unable to look for the source of a spike", "repo", gazer.Repository, "err", r.err)
+		}
+		if r.ok {
+			n.log.Infow("found likely source of spike", "repo", gazer.Repository, "site", r.m.Site, "link", r.m.Link)
+		}
+		return r.m, r.ok
+	case <-timeout.C:
+		n.log.Warnw("timed out looking for the source of a spike", "repo", gazer.Repository, "timeout", spikeTimeout)
+		return stargazer.Mention{}, false
+	}
+}
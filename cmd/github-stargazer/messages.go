@@ -0,0 +1,215 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fallbackLang is the language whose messages are used for any that a
+// catalog doesn't translate.
+const fallbackLang = "en"
+
+// locales holds a catalog of notification message templates per language,
+// each a JSON object of kinds of event to templates.
+//
+//go:embed locales/*.json
+var locales embed.FS
+
+// messageData is what notification templates are executed with. Fields that
+// don't apply to an event are zero. Site is where the repository is hosted,
+// such as GitHub, and Unit is what is counted, such as stargazers, from the
+// unit template for the count in the message's language. Forum, Title, Link
+// and Points describe a post mentioning the repository, such as a Hacker News
+// submission. Duration is how long a stalled watch's count hasn't been
+// fetched for, and Error why the last fetch failed.
+type messageData struct {
+	Site     string
+	Repo     string
+	Unit     string
+	Count    int
+	Target   int
+	Percent  int
+	Velocity float64
+	Deadline time.Time
+	Duration time.Duration
+	Error    string
+	Forum    string
+	Title    string
+	Link     string
+	Points   int
+
+	// Rival is the repository that Repo is compared with, and RivalCount
+	// and Gap its count and how far apart the two are.
+	Rival      string
+	RivalCount int
+	Gap        int
+
+	// Remaining is how many more the count needs to reach Target.
+	Remaining int
+
+	// NewName is what Repo was renamed or transferred to.
+	NewName string
+
+	// Description is the new description of Repo, if it changed.
+	Description string
+
+	// License and PreviousLicense are the new and old license of Repo, if
+	// it changed.
+	License         string
+	PreviousLicense string
+
+	// Since and Language are the period and language of the GitHub
+	// Trending list that Repo entered or left, and Rank its place on it.
+	Since    string
+	Language string
+	Rank     int
+}
+
+// messages are the parsed notification templates by kind of event.
+type messages map[string]*template.Template
+
+// catalog is the notification messages in every language.
+type catalog struct {
+	langs       map[string]messages
+	defaultLang string
+}
+
+// loadCatalog parses the embedded message catalogs. Messages are sent in
+// defaultLang unless a watch asks for another language. The templates in the
+// JSON file at overrides, if it is set, replace those of defaultLang.
+func loadCatalog(defaultLang, overrides string) (*catalog, error) {
+	fallback, err := readTemplates(locales, "locales/"+fallbackLang+".json")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+	c := &catalog{langs: make(map[string]messages), defaultLang: defaultLang}
+	for _, entry := range entries {
+		lang := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+		templates, err := readTemplates(locales, "locales/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if lang == defaultLang && overrides != "" {
+			custom, err := readTemplates(os.DirFS(filepath.Dir(overrides)), filepath.Base(overrides))
+			if err != nil {
+				return nil, err
+			}
+			for kind, text := range custom {
+				templates[kind] = text
+			}
+		}
+		if c.langs[lang], err = parseTemplates(lang, fallback, templates); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := c.langs[defaultLang]; !ok {
+		return nil, errors.Errorf("no messages for language %q", defaultLang)
+	}
+	return c, nil
+}
+
+// readTemplates reads a JSON object of kinds to templates from name in fsys.
+func readTemplates(fsys fs.FS, name string) (map[string]string, error) {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading message templates")
+	}
+	var templates map[string]string
+	if err := json.Unmarshal(b, &templates); err != nil {
+		return nil, errors.Wrapf(err, "error decoding message templates in %s", name)
+	}
+	return templates, nil
+}
+
+// parseTemplates parses the templates for lang, using those in fallback for
+// kinds it doesn't have.
+func parseTemplates(lang string, fallback, templates map[string]string) (messages, error) {
+	ms := make(messages, len(fallback))
+	for kind := range templates {
+		if _, ok := fallback[kind]; !ok {
+			return nil, errors.Errorf("unknown %s message template %q", lang, kind)
+		}
+	}
+	for kind, text := range fallback {
+		if translated, ok := templates[kind]; ok {
+			text = translated
+		}
+		t, err := template.New(kind).Parse(text)
+		if err == nil {
+			// Catch references to fields that don't exist now rather than
+			// when a notification is due.
+			err = t.Execute(io.Discard, messageData{})
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s %s message template", lang, kind)
+		}
+		ms[kind] = t
+	}
+	return ms, nil
+}
+
+// overrides parses templates, a watch's own message templates by kind of
+// event, which replace the catalog's for its notifications.
+func (c *catalog) overrides(templates map[string]string) (messages, error) {
+	ms := make(messages, len(templates))
+	for kind, text := range templates {
+		if _, ok := c.langs[fallbackLang][kind]; !ok {
+			return nil, errors.Errorf("unknown message template %q", kind)
+		}
+		t, err := template.New(kind).Parse(text)
+		if err == nil {
+			err = t.Execute(io.Discard, messageData{})
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s message template", kind)
+		}
+		ms[kind] = t
+	}
+	return ms, nil
+}
+
+// renderOverride executes the template for kind in templates, a watch's own
+// message templates, with data.
+func (c *catalog) renderOverride(templates map[string]string, kind string, data messageData) (string, error) {
+	ms, err := c.overrides(map[string]string{kind: templates[kind]})
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := ms[kind].Execute(&b, data); err != nil {
+		return "", errors.Wrapf(err, "error rendering %s message", kind)
+	}
+	return b.String(), nil
+}
+
+// render executes the template for kind in lang with data. Regional
+// languages such as es-MX fall back to their base language, and unknown ones
+// to the default language.
+func (c *catalog) render(lang, kind string, data messageData) (string, error) {
+	ms, ok := c.langs[lang]
+	if !ok {
+		base, _, _ := strings.Cut(lang, "-")
+		if ms, ok = c.langs[base]; !ok {
+			ms = c.langs[c.defaultLang]
+		}
+	}
+	var b strings.Builder
+	if err := ms[kind].Execute(&b, data); err != nil {
+		return "", errors.Wrapf(err, "error rendering %s message", kind)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// expvarMetrics keeps the measurements of the gazers, the scheduler, the
+// GraphQL batch and the Twilio sender, which the status server serves at
+// /metrics. Each is keyed by its name and tags, such as
+// github.requests{repo=owner/repo,status=200}. Counters add up, gauges hold
+// their last value, and timers add up their count and total seconds.
+type expvarMetrics struct {
+	vars *expvar.Map
+}
+
+func newExpvarMetrics() *expvarMetrics {
+	return &expvarMetrics{vars: new(expvar.Map).Init()}
+}
+
+func (m *expvarMetrics) Counter(name string, delta int64, tags ...string) {
+	m.vars.Add(metricKey(name, tags), delta)
+}
+
+func (m *expvarMetrics) Gauge(name string, value float64, tags ...string) {
+	key := metricKey(name, tags)
+	m.vars.AddFloat(key, 0)
+	m.vars.Get(key).(*expvar.Float).Set(value)
+}
+
+func (m *expvarMetrics) Timer(name string, d time.Duration, tags ...string) {
+	m.vars.Add(metricKey(name+"_count", tags), 1)
+	m.vars.AddFloat(metricKey(name+"_seconds", tags), d.Seconds())
+}
+
+// metricKey returns the key of the named measurement with tags, which are
+// alternating keys and values.
+func metricKey(name string, tags []string) string {
+	if len(tags) < 2 {
+		return name
+	}
+	pairs := make([]string, 0, len(tags)/2)
+	for i := 0; i+1 < len(tags); i += 2 {
+		pairs = append(pairs, tags[i]+"="+tags[i+1])
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// handleMetrics serves GET /metrics, the measurements as a JSON object.
+func (s *statusServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(s.metrics.vars.String()))
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// openAPISpec is the OpenAPI description of the HTTP API of the status
+// server, served at /openapi.json. Requests to the operations it describes
+// are validated against it.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// apiSpec is the part of an OpenAPI document needed to validate requests.
+type apiSpec struct {
+	Paths      map[string]map[string]*apiOperation `json:"paths"`
+	Components struct {
+		Parameters map[string]*apiParameter `json:"parameters"`
+		Schemas    map[string]*apiSchema    `json:"schemas"`
+	} `json:"components"`
+}
+
+// apiOperation is an operation of a path of the API.
+type apiOperation struct {
+	Parameters  []*apiParameter       `json:"parameters"`
+	Security    []map[string][]string `json:"security"`
+	RequestBody *struct {
+		Required bool `json:"required"`
+		Content  map[string]struct {
+			Schema *apiSchema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+// apiParameter is a path or query parameter of an operation, or a reference
+// to one of the parameters of the components.
+type apiParameter struct {
+	Ref      string     `json:"$ref"`
+	Name     string     `json:"name"`
+	In       string     `json:"in"`
+	Required bool       `json:"required"`
+	Schema   *apiSchema `json:"schema"`
+}
+
+// apiSchema is a JSON schema, or a reference to one of the schemas of the
+// components. Only the keywords used by openapi.json are supported.
+type apiSchema struct {
+	Ref        string                `json:"$ref"`
+	Type       string                `json:"type"`
+	Format     string                `json:"format"`
+	Enum       []interface{}         `json:"enum"`
+	Minimum    *float64              `json:"minimum"`
+	Properties map[string]*apiSchema `json:"properties"`
+	Required   []string              `json:"required"`
+	Items      *apiSchema            `json:"items"`
+
+	// AdditionalProperties is the schema of the properties of an object that
+	// aren't in Properties. If NoAdditionalProperties is set, there must be
+	// none.
+	AdditionalProperties   *apiSchema `json:"-"`
+	NoAdditionalProperties bool       `json:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, reading additionalProperties,
+// which is either a boolean or a schema.
+func (s *apiSchema) UnmarshalJSON(b []byte) error {
+	type schema apiSchema
+	var v struct {
+		*schema
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	v.schema = (*schema)(s)
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch string(v.AdditionalProperties) {
+	case "", "true":
+	case "false":
+		s.NoAdditionalProperties = true
+	default:
+		s.AdditionalProperties = new(apiSchema)
+		return json.Unmarshal(v.AdditionalProperties, s.AdditionalProperties)
+	}
+	return nil
+}
+
+// apiRoute is a path of the API, matched by pattern.
+type apiRoute struct {
+	path       string
+	pattern    *regexp.Regexp
+	params     []string
+	operations map[string]*apiOperation
+}
+
+// apiValidator validates requests against the operations of the API.
+type apiValidator struct {
+	spec   apiSpec
+	routes []apiRoute
+}
+
+// pathParam matches the parameters of a path template, like {owner}.
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// newAPIValidator parses an OpenAPI document.
+func newAPIValidator(doc []byte) (*apiValidator, error) {
+	v := new(apiValidator)
+	if err := json.Unmarshal(doc, &v.spec); err != nil {
+		return nil, errors.Wrap(err, "unable to parse OpenAPI spec")
+	}
+	for path, operations := range v.spec.Paths {
+		route := apiRoute{path: path, operations: make(map[string]*apiOperation)}
+		for method, op := range operations {
+			route.operations[strings.ToUpper(method)] = op
+		}
+		parts := pathParam.Split(path, -1)
+		pattern := "^" + regexp.QuoteMeta(parts[0])
+		for i, m := range pathParam.FindAllStringSubmatch(path, -1) {
+			route.params = append(route.params, m[1])
+			pattern += "([^/]+)" + regexp.QuoteMeta(parts[i+1])
+		}
+		pattern += "/?$"
+		route.pattern = regexp.MustCompile(pattern)
+		v.routes = append(v.routes, route)
+	}
+	// Paths with fewer parameters take precedence, so that
+	// /repos/{owner}/{repo}/history isn't matched as an action.
+	sort.Slice(v.routes, func(i, j int) bool {
+		if len(v.routes[i].params) != len(v.routes[j].params) {
+			return len(v.routes[i].params) < len(v.routes[j].params)
+		}
+		return v.routes[i].path < v.routes[j].path
+	})
+	return v, nil
+}
+
+// match returns the operation of the path and method of r, and the values
+// of its path parameters, or nil if the API doesn't describe it.
+func (v *apiValidator) match(r *http.Request) (*apiOperation, map[string]string) {
+	for _, route := range v.routes {
+		m := route.pattern.FindStringSubmatch(r.URL.Path)
+		if m == nil {
+			continue
+		}
+		op := route.operations[r.Method]
+		if op == nil {
+			return nil, nil
+		}
+		params := make(map[string]string, len(route.params))
+		for i, name := range route.params {
+			params[name] = m[i+1]
+		}
+		return op, params
+	}
+	return nil, nil
+}
+
+// validate checks the parameters and body of a request for op. The body of
+// r is read and replaced so that the handler can read it again.
+func (v *apiValidator) validate(r *http.Request, op *apiOperation, pathParams map[string]string) error {
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		if param.Ref != "" {
+			param = v.spec.Components.Parameters[strings.TrimPrefix(param.Ref, "#/components/parameters/")]
+		}
+		var value string
+		var ok bool
+		switch param.In {
+		case "path":
+			value, ok = pathParams[param.Name]
+		case "query":
+			value, ok = query.Get(param.Name), query.Get(param.Name) != ""
+		default:
+			continue
+		}
+		if !ok {
+			if param.Required {
+				return fmt.Errorf("%s parameter %s is required", param.In, param.Name)
+			}
+			continue
+		}
+		if err := v.validateParam(value, param.Schema); err != nil {
+			return errors.Wrapf(err, "%s parameter %s", param.In, param.Name)
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+	// The handlers read bodies as JSON whatever their content type, so they
+	// are validated as JSON too.
+	content, ok := op.RequestBody.Content["application/json"]
+	if !ok {
+		return nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return errors.Wrap(err, "unable to read request body")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return errors.New("request body is required")
+		}
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return errors.Wrap(err, "request body is not valid JSON")
+	}
+	return errors.Wrap(v.validateValue(doc, content.Schema, ""), "request body")
+}
+
+// validateParam checks the value of a path or query parameter against its
+// schema.
+func (v *apiValidator) validateParam(value string, schema *apiSchema) error {
+	schema = v.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	var doc interface{} = value
+	switch schema.Type {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("must be %s", article(schema.Type))
+		}
+		doc = json.Number(value)
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		doc = b
+	}
+	return v.validateValue(doc, schema, "")
+}
+
+// validateValue checks a value decoded from JSON against schema. path is
+// where the value is in the document, for error messages.
+func (v *apiValidator) validateValue(doc interface{}, schema *apiSchema, path string) error {
+	schema = v.resolve(schema)
+	if schema == nil {
+		return nil
+	}
+	where := ""
+	if path != "" {
+		where = path + " "
+	}
+	switch schema.Type {
+	case "object":
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%smust be an object", where)
+		}
+		for _, name := range schema.Required {
+			if _, ok := obj[name]; !ok {
+				return fmt.Errorf("%s is required", joinPath(path, name))
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := schema.Properties[name]
+			switch {
+			case ok:
+			case schema.NoAdditionalProperties:
+				return fmt.Errorf("unknown field %s", joinPath(path, name))
+			default:
+				prop = schema.AdditionalProperties
+			}
+			if err := v.validateValue(obj[name], prop, joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := doc.([]interface{})
+		if !ok {
+			return fmt.Errorf("%smust be an array", where)
+		}
+		for i, item := range arr {
+			if err := v.validateValue(item, schema.Items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := doc.(string)
+		if !ok {
+			return fmt.Errorf("%smust be a string", where)
+		}
+		if schema.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				return fmt.Errorf("%smust be an RFC 3339 time", where)
+			}
+		}
+	case "integer", "number":
+		n, ok := doc.(json.Number)
+		if !ok {
+			return fmt.Errorf("%smust be %s", where, article(schema.Type))
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%smust be %s", where, article(schema.Type))
+		}
+		if _, err := n.Int64(); err != nil && schema.Type == "integer" {
+			return fmt.Errorf("%smust be an integer", where)
+		}
+		if schema.Minimum != nil && f < *schema.Minimum {
+			return fmt.Errorf("%smust be at least %v", where, *schema.Minimum)
+		}
+	case "boolean":
+		if _, ok := doc.(bool); !ok {
+			return fmt.Errorf("%smust be a boolean", where)
+		}
+	}
+	if len(schema.Enum) > 0 {
+		for _, value := range schema.Enum {
+			if fmt.Sprint(value) == fmt.Sprint(doc) {
+				return nil
+			}
+		}
+		values := make([]string, len(schema.Enum))
+		for i, value := range schema.Enum {
+			values[i] = fmt.Sprint(value)
+		}
+		return fmt.Errorf("%smust be one of %s", where, strings.Join(values, ", "))
+	}
+	return nil
+}
+
+// resolve returns the schema that schema refers to, if it is a reference.
+func (v *apiValidator) resolve(schema *apiSchema) *apiSchema {
+	if schema != nil && schema.Ref != "" {
+		return v.spec.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// article returns a numeric type name with its indefinite article, like "an
+// integer".
+func article(typ string) string {
+	if typ == "integer" {
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// validateRequests wraps h, responding 400 to requests that don't match
+// the operations of the API that they are for. Requests for operations that
+// require the control token are only validated once they carry it, and only
+// if it is set, so that they are refused as they would be without
+// validation.
+func (s *statusServer) validateRequests(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		op, params := s.api.match(r)
+		if op == nil || (len(op.Security) > 0 && (s.controlToken == "" || !s.authorized(r))) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if err := s.api.validate(r, op, params); err != nil {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// handleOpenAPI serves GET /openapi.json, the OpenAPI description of the
+// API.
+func (s *statusServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// repoLister lists the repositories of a GitHub organization or user.
+type repoLister interface {
+	Repositories() ([]stargazer.ListedRepository, error)
+}
+
+// accountWatcher starts a watch for every repository of a GitHub organization
+// or user, listing them again each interval of its filter to start watching
+// those that are new.
+type accountWatcher struct {
+	account  string
+	lister   repoLister
+	template watchSpec
+	filter   accountFilter
+	watches  *manager
+	log      *zap.SugaredLogger
+
+	// seen is the repositories that have been listed before, so that a
+	// watch removed through the API isn't started again at the next list.
+	seen map[string]bool
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// accountFilter is which of the repositories of an account are watched, and
+// how often they are listed. Archived repositories aren't watched, nor are
+// forks unless forks is set. If there are include patterns, only
+// repositories matching one of them are watched, and repositories matching an
+// exclude pattern never are.
+type accountFilter struct {
+	forks    bool
+	include  []string
+	exclude  []string
+	interval time.Duration
+}
+
+// newAccountWatcher returns a watcher that starts watches for the
+// repositories that lister lists for account from template.
+func newAccountWatcher(account string, lister repoLister, template watchSpec, filter accountFilter, watches *manager, log *zap.SugaredLogger) *accountWatcher {
+	template.Provider = providerGitHub
+	return &accountWatcher{
+		account:  account,
+		lister:   lister,
+		template: template,
+		filter:   filter,
+		watches:  watches,
+		log:      log,
+		seen:     make(map[string]bool),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// parsePatterns parses a comma-separated list of glob patterns of
+// repositories, like "go-*" or "octocat/*".
+func parsePatterns(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+	patterns := strings.Split(list, ",")
+	for i, pattern := range patterns {
+		patterns[i] = watchKey(strings.TrimSpace(pattern))
+		if _, err := path.Match(patterns[i], ""); err != nil {
+			return nil, errors.Errorf("invalid repo pattern %q", pattern)
+		}
+	}
+	return patterns, nil
+}
+
+// matchRepo reports whether repo, in owner/repo format, matches any of
+// patterns. A pattern with no slash is matched against the name of the
+// repository without its owner.
+func matchRepo(patterns []string, repo string) bool {
+	repo = watchKey(repo)
+	name := repo[strings.LastIndex(repo, "/")+1:]
+	for _, pattern := range patterns {
+		subject := name
+		if strings.Contains(pattern, "/") {
+			subject = repo
+		}
+		if ok, _ := path.Match(pattern, subject); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// wanted reports whether repo should be watched.
+func (a *accountWatcher) wanted(repo stargazer.ListedRepository) bool {
+	if repo.Archived || (repo.Fork && !a.filter.forks) {
+		return false
+	}
+	if len(a.filter.include) > 0 && !matchRepo(a.filter.include, repo.FullName) {
+		return false
+	}
+	return !matchRepo(a.filter.exclude, repo.FullName)
+}
+
+// sync lists the repositories of the account and starts watches for those
+// that haven't been listed before and aren't already watched.
+func (a *accountWatcher) sync() error {
+	repos, err := a.lister.Repositories()
+	if err != nil {
+		return err
+	}
+	started := 0
+	for _, repo := range repos {
+		key := watchKey(repo.FullName)
+		if a.seen[key] || !a.wanted(repo) {
+			continue
+		}
+		a.seen[key] = true
+		spec := a.template
+		spec.Repo = repo.FullName
+		switch err := a.watches.create(spec); err {
+		case nil:
+			started++
+		case errWatchExists:
+		default:
+			a.log.Warnw("unable to watch repository of account",
+				"account", a.account,
+				"repo", repo.FullName,
+				"err", err)
+		}
+	}
+	a.log.Infow("listed repositories of account",
+		"account", a.account,
+		"repos", len(repos),
+		"started", started)
+	return nil
+}
+
+// run lists the repositories of the account each interval until it is
+// stopped.
+func (a *accountWatcher) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(a.filter.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			if err := a.sync(); err != nil {
+				a.log.Warnw("unable to list repositories of account", "account", a.account, "err", err)
+			}
+		}
+	}
+}
+
+// shutdown stops listing the repositories of the account and waits for a
+// list in progress to finish.
+func (a *accountWatcher) shutdown() {
+	a.stopOnce.Do(func() { close(a.stop) })
+	<-a.done
+}
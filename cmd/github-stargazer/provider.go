@@ -0,0 +1,254 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+)
+
+const (
+	envGitLabToken    = "GITLAB_TOKEN"
+	envGiteaToken     = "GITEA_TOKEN"
+	envBitbucketToken = "BITBUCKET_TOKEN"
+)
+
+// Providers that repositories can be watched on.
+const (
+	providerGitHub    = "github"
+	providerGitLab    = "gitlab"
+	providerGitea     = "gitea"
+	providerBitbucket = "bitbucket"
+	providerNpm       = "npm"
+	providerCrates    = "crates"
+	providerPyPI      = "pypi"
+	providerGo        = "go"
+)
+
+// providerHosts are the hosts whose repository URLs are recognized without
+// a provider being given.
+var providerHosts = map[string]string{
+	"github.com":    providerGitHub,
+	"gitlab.com":    providerGitLab,
+	"codeberg.org":  providerGitea,
+	"bitbucket.org": providerBitbucket,
+	"www.npmjs.com": providerNpm,
+	"crates.io":     providerCrates,
+	"pypi.org":      providerPyPI,
+	"pkg.go.dev":    providerGo,
+}
+
+// providerCounts are what can be counted on each provider. The first is the
+// default.
+var providerCounts = map[string][]stargazer.Count{
+	providerGitHub:    {stargazer.Stars, stargazer.Followers, stargazer.Sponsors},
+	providerGitLab:    {stargazer.Stars},
+	providerGitea:     {stargazer.Stars, stargazer.Forks, stargazer.Releases},
+	providerBitbucket: {stargazer.Watchers, stargazer.Forks},
+	providerNpm:       {stargazer.WeeklyDownloads, stargazer.Downloads},
+	providerCrates:    {stargazer.Downloads, stargazer.RecentDownloads, stargazer.ReverseDependencies},
+	providerPyPI:      {stargazer.WeeklyDownloads, stargazer.MonthlyDownloads},
+	providerGo:        {stargazer.Versions, stargazer.ImportedBy},
+}
+
+// providerPathPrefixes are the parts of the paths of URLs on each provider
+// that come before the name of what is watched.
+var providerPathPrefixes = map[string]string{
+	providerNpm:    "package/",
+	providerCrates: "crates/",
+	providerPyPI:   "project/",
+}
+
+// resolveProvider fills in the provider of spec, its base URL if it isn't
+// the provider's usual one, and what is counted. A repo given as a URL, like
+// https://gitlab.com/group/project, is replaced by its path, and the repo
+// can also be a package such as https://crates.io/crates/serde.
+// Otherwise the provider defaults to GitHub, whose repos are checked to be
+// well-formed.
+func resolveProvider(spec watchSpec) (watchSpec, error) {
+	if u, err := url.Parse(spec.Repo); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		if spec.Provider == "" {
+			spec.Provider = providerHosts[u.Host]
+			if spec.Provider == "" {
+				return spec, errors.Errorf("unknown provider for %s: set it with -provider", u.Host)
+			}
+		}
+		if providerHosts[u.Host] != spec.Provider {
+			spec.BaseURL = u.Scheme + "://" + u.Host
+		}
+		spec.Repo = strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+		spec.Repo = strings.TrimPrefix(spec.Repo, providerPathPrefixes[spec.Provider])
+	}
+	if spec.Provider == "" {
+		spec.Provider = providerGitHub
+	}
+	counts, ok := providerCounts[spec.Provider]
+	if !ok {
+		return spec, errors.Errorf("unknown provider %q", spec.Provider)
+	}
+	if spec.Count == "" {
+		spec.Count = string(counts[0])
+	}
+	counted := false
+	for _, count := range counts {
+		counted = counted || string(count) == spec.Count
+	}
+	if !counted {
+		return spec, errors.Errorf("%s cannot count %s", spec.Provider, spec.Count)
+	}
+	if countsGitHubStars(spec) {
+		r, err := stargazer.ParseRepo(spec.Repo)
+		if err != nil {
+			return spec, err
+		}
+		spec.Repo = r.String()
+	}
+	return spec, nil
+}
+
+// siteName is the name of the site that the repository of spec is on, for
+// notifications.
+func siteName(spec watchSpec) string {
+	switch {
+	case spec.Provider == providerGitLab:
+		return "GitLab"
+	case spec.Provider == providerGitea && spec.BaseURL == "":
+		return "Codeberg"
+	case spec.Provider == providerGitea:
+		return "Gitea"
+	case spec.Provider == providerBitbucket:
+		return "Bitbucket"
+	case spec.Provider == providerNpm:
+		return "npm"
+	case spec.Provider == providerCrates:
+		return "crates.io"
+	case spec.Provider == providerPyPI:
+		return "PyPI"
+	case spec.Provider == providerGo:
+		return "Go"
+	default:
+		return "GitHub"
+	}
+}
+
+// countsGitHubStars reports whether the watch of spec counts the stargazers
+// of a GitHub repository, which can be starred and has a star history,
+// rather than something else or a user's followers or sponsors.
+func countsGitHubStars(spec watchSpec) bool {
+	return (spec.Provider == "" || spec.Provider == providerGitHub) &&
+		(spec.Count == "" || spec.Count == string(stargazer.Stars))
+}
+
+// sourceOptions returns the gazer options that point it at the provider and
+// repository of spec, which must have been resolved with resolveProvider.
+// Sources other than GitHub make their requests with the notifier's client.
+func (n *notifier) sourceOptions(spec watchSpec) ([]func(*stargazer.GitHubStargazer), error) {
+	var (
+		source stargazer.Source
+		err    error
+	)
+	switch spec.Provider {
+	case providerGitLab:
+		options := []func(*stargazer.GitLabSource){
+			stargazer.WithGitLabHTTPClient(n.client),
+		}
+		if spec.BaseURL != "" {
+			options = append(options, stargazer.WithGitLabBaseURL(spec.BaseURL))
+		}
+		if n.gitlabToken != nil {
+			options = append(options, stargazer.WithGitLabTokenSource(n.gitlabToken))
+		}
+		source, err = stargazer.NewGitLabSource(spec.Repo, options...)
+	case providerGitea:
+		options := []func(*stargazer.GiteaSource){
+			stargazer.WithGiteaCount(stargazer.Count(spec.Count)),
+			stargazer.WithGiteaHTTPClient(n.client),
+		}
+		if spec.BaseURL != "" {
+			options = append(options, stargazer.WithGiteaBaseURL(spec.BaseURL))
+		}
+		if n.giteaToken != nil {
+			options = append(options, stargazer.WithGiteaTokenSource(n.giteaToken))
+		}
+		source, err = stargazer.NewGiteaSource(spec.Repo, options...)
+	case providerBitbucket:
+		options := []func(*stargazer.BitbucketSource){
+			stargazer.WithBitbucketCount(stargazer.Count(spec.Count)),
+			stargazer.WithBitbucketHTTPClient(n.client),
+		}
+		if spec.BaseURL != "" {
+			options = append(options, stargazer.WithBitbucketBaseURL(spec.BaseURL))
+		}
+		if n.bitbucketToken != nil {
+			options = append(options, stargazer.WithBitbucketTokenSource(n.bitbucketToken))
+		}
+		source, err = stargazer.NewBitbucketSource(spec.Repo, options...)
+	case providerNpm:
+		options := []func(*stargazer.NpmSource){
+			stargazer.WithNpmCount(stargazer.Count(spec.Count)),
+			stargazer.WithNpmHTTPClient(n.client),
+		}
+		if spec.BaseURL != "" {
+			options = append(options, stargazer.WithNpmBaseURL(spec.BaseURL))
+		}
+		source, err = stargazer.NewNpmSource(spec.Repo, options...)
+	case providerCrates:
+		options := []func(*stargazer.CratesSource){
+			stargazer.WithCratesCount(stargazer.Count(spec.Count)),
+			stargazer.WithCratesHTTPClient(n.client),
+		}
+		if spec.BaseURL != "" {
+			options = append(options, stargazer.WithCratesBaseURL(spec.BaseURL))
+		}
+		source, err = stargazer.NewCratesSource(spec.Repo, options...)
+	case providerPyPI:
+		options := []func(*stargazer.PyPISource){
+			stargazer.WithPyPICount(stargazer.Count(spec.Count)),
+			stargazer.WithPyPIHTTPClient(n.client),
+		}
+		if spec.BaseURL != "" {
+			options = append(options, stargazer.WithPyPIBaseURL(spec.BaseURL))
+		}
+		source, err = stargazer.NewPyPISource(spec.Repo, options...)
+	case providerGo:
+		options := []func(*stargazer.GoModuleSource){
+			stargazer.WithGoModuleCount(stargazer.Count(spec.Count)),
+			stargazer.WithGoModuleHTTPClient(n.client),
+		}
+		if spec.BaseURL != "" {
+			options = append(options, stargazer.WithPkgsiteURL(spec.BaseURL))
+		}
+		source, err = stargazer.NewGoModuleSource(spec.Repo, options...)
+	default:
+		var options []func(*stargazer.GitHubStargazer)
+		if spec.Count == string(stargazer.Followers) || spec.Count == string(stargazer.Sponsors) {
+			options = append(options, stargazer.WithGitHubCount(stargazer.Count(spec.Count)))
+		}
+		if spec.BaseURL != "" {
+			return append(options, stargazer.WithGitHubBaseURL(spec.BaseURL)), nil
+		}
+		if n.graphqlBatch != nil && countsGitHubStars(spec) {
+			options = append(options, stargazer.WithGraphQLBatch(n.graphqlBatch))
+		}
+		return options, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []func(*stargazer.GitHubStargazer){stargazer.WithSource(source)}, nil
+}
+
+// tokenSource returns a source of the token in file, if it is set, or
+// otherwise of the token in the environment variable env. It returns nil if
+// neither is set.
+func tokenSource(file, env string) stargazer.TokenSource {
+	if file != "" {
+		return stargazer.FileTokenSource(file)
+	}
+	if token := os.Getenv(env); token != "" {
+		return stargazer.StaticTokenSource(token)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import "time"
+
+const (
+	// rollupResolution is the period that history older than the raw
+	// retention is rolled up to.
+	rollupResolution = time.Hour
+
+	// compactInterval is how often the history is compacted.
+	compactInterval = time.Hour
+)
+
+// retention is how long history is kept in the store. Samples are kept as
+// recorded for raw, then rolled up to one per rollupResolution until rollups,
+// after which they are deleted. A zero duration keeps them forever.
+type retention struct {
+	raw, rollups time.Duration
+}
+
+// startCompaction compacts the history in the store now and then every
+// compactInterval, until the manager shuts down.
+func (m *manager) startCompaction() {
+	if m.retention.raw == 0 && m.retention.rollups == 0 {
+		return
+	}
+	m.compactStop = make(chan struct{})
+	m.compactDone = make(chan struct{})
+	go func() {
+		defer close(m.compactDone)
+		t := time.NewTicker(compactInterval)
+		defer t.Stop()
+		for {
+			m.compactHistory(time.Now())
+			select {
+			case <-t.C:
+			case <-m.compactStop:
+				return
+			}
+		}
+	}()
+}
+
+// stopCompaction stops compacting the history and waits for any compaction
+// in progress to finish.
+func (m *manager) stopCompaction() {
+	if m.compactStop == nil {
+		return
+	}
+	close(m.compactStop)
+	<-m.compactDone
+}
+
+func (m *manager) compactHistory(now time.Time) {
+	var rollupBefore, dropBefore time.Time
+	if m.retention.raw > 0 {
+		rollupBefore = now.Add(-m.retention.raw)
+	}
+	if m.retention.rollups > 0 {
+		dropBefore = now.Add(-m.retention.rollups)
+	}
+	if err := m.store.Compact(rollupBefore, dropBefore, rollupResolution); err != nil {
+		m.log.Warnw("unable to compact history", "err", err)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+)
+
+// Types of channel that notifications can be routed to.
+const (
+	channelSMS       = "sms"
+	channelWebhook   = "webhook"
+	channelPagerDuty = "pagerduty"
+)
+
+// pagerDutyEventsURL is where PagerDuty's Events API v2 accepts events.
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// routedKinds are the kinds of notification that routing rules can route,
+// with their severities.
+var routedKinds = map[string]string{
+	"target":     "info",
+	"milestone":  "info",
+	"progress":   "info",
+	"starred":    "info",
+	"mention":    "info",
+	"front-page": "info",
+	"points":     "info",
+	"report":     "info",
+	"overtook":   "info",
+	"gap":        "info",
+	"overtaken":  "warning",
+	"velocity":   "warning",
+	"deadline":   "warning",
+	"renamed":    "warning",
+	"archived":   "warning",
+	"deleted":    "warning",
+	"license":    "warning",
+	"stalled":    "error",
+
+	// description and readme are changes to the content of a repository
+	// watched with watch_content.
+	"description": "info",
+	"readme":      "info",
+
+	// trending and left-trending are about the GitHub Trending lists a
+	// repository watched with trending enters and leaves.
+	"trending":      "info",
+	"left-trending": "info",
+
+	// leaderboard is the digest of every watch, about no repository.
+	"leaderboard": "info",
+}
+
+// severities orders the severities of notifications, least severe first.
+var severities = map[string]int{"info": 0, "warning": 1, "error": 2}
+
+// channel is somewhere notifications can be sent: by SMS, to the watch's
+// phone number unless To is set, to a webhook such as a Slack incoming
+// webhook, or to PagerDuty through an Events API v2 integration.
+type channel struct {
+	name string
+
+	Type       string `json:"type"`
+	To         string `json:"to,omitempty"`
+	URL        string `json:"url,omitempty"`
+	RoutingKey string `json:"routing_key,omitempty"`
+}
+
+// routeRule routes the notifications it matches to its channels. A rule
+// matches notifications of any of its kinds of event, about repositories
+// matching any of its patterns (like "matryer/*"), counting any of its counts,
+// and at least as severe as its severity. Filters that are empty match
+// everything.
+type routeRule struct {
+	Events   []string `json:"events,omitempty"`
+	Repos    []string `json:"repos,omitempty"`
+	Counts   []string `json:"counts,omitempty"`
+	Severity string   `json:"severity,omitempty"`
+	Channels []string `json:"channels"`
+}
+
+// router routes notifications to channels by the first of its rules that
+// matches them. A rule with no channels drops the notifications it matches.
+// A nil router routes nothing.
+type router struct {
+	channels map[string]channel
+	rules    []routeRule
+}
+
+// loadRouter reads the routing rules from the JSON file at path, or returns
+// nil if path is empty. The file holds the channels by name, and the rules:
+//
+//	{
+//	  "channels": {"slack": {"type": "webhook", "url": "https://hooks.slack.com/..."}},
+//	  "rules": [{"events": ["velocity"], "channels": ["slack"]}]
+//	}
+//
+// The channel named "sms" sends an SMS to the watch's phone number, unless
+// the file defines a channel of its own by that name.
+func loadRouter(path string) (*router, error) {
+	if path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading routes file")
+	}
+	var routes struct {
+		Channels map[string]channel `json:"channels"`
+		Rules    []routeRule        `json:"rules"`
+	}
+	if err := json.Unmarshal(b, &routes); err != nil {
+		return nil, errors.Wrap(err, "error decoding routes file")
+	}
+	r := &router{
+		channels: map[string]channel{channelSMS: {name: channelSMS, Type: channelSMS}},
+		rules:    routes.Rules,
+	}
+	for name, ch := range routes.Channels {
+		if err := ch.validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid channel %q", name)
+		}
+		ch.name = name
+		r.channels[name] = ch
+	}
+	for i, rule := range r.rules {
+		if err := r.validate(rule); err != nil {
+			return nil, errors.Wrapf(err, "invalid rule %d", i+1)
+		}
+	}
+	return r, nil
+}
+
+func (ch channel) validate() error {
+	switch ch.Type {
+	case channelSMS:
+	case channelWebhook:
+		if ch.URL == "" {
+			return errors.New("url is required for a webhook")
+		}
+	case channelPagerDuty:
+		if ch.RoutingKey == "" {
+			return errors.New("routing_key is required for PagerDuty")
+		}
+	default:
+		return errors.Errorf("unknown type %q: must be sms, webhook or pagerduty", ch.Type)
+	}
+	return nil
+}
+
+func (r *router) validate(rule routeRule) error {
+	for _, kind := range rule.Events {
+		if _, ok := routedKinds[kind]; !ok {
+			return errors.Errorf("unknown event %q", kind)
+		}
+	}
+	for _, pattern := range rule.Repos {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Errorf("invalid repo pattern %q", pattern)
+		}
+	}
+	if _, ok := severities[rule.Severity]; rule.Severity != "" && !ok {
+		return errors.Errorf("unknown severity %q: must be info, warning or error", rule.Severity)
+	}
+	for _, name := range rule.Channels {
+		if _, ok := r.channels[name]; !ok {
+			return errors.Errorf("unknown channel %q", name)
+		}
+	}
+	return nil
+}
+
+// route returns the channels that the notification of kind about the watch
+// of spec is routed to, and false if no rule matches it.
+func (r *router) route(spec watchSpec, kind string) ([]channel, bool) {
+	if r == nil {
+		return nil, false
+	}
+	count := spec.Count
+	if count == "" {
+		count = string(stargazer.Stars)
+	}
+	for _, rule := range r.rules {
+		if !rule.matches(watchKey(spec.Repo), count, kind) {
+			continue
+		}
+		return r.named(rule.Channels), true
+	}
+	return nil, false
+}
+
+// channel returns the channel named name. A nil router has only the sms
+// channel.
+func (r *router) channel(name string) (channel, bool) {
+	if r == nil {
+		return channel{name: channelSMS, Type: channelSMS}, name == channelSMS
+	}
+	ch, ok := r.channels[name]
+	return ch, ok
+}
+
+// named returns the channels named names, which must exist.
+func (r *router) named(names []string) []channel {
+	channels := make([]channel, 0, len(names))
+	for _, name := range names {
+		ch, _ := r.channel(name)
+		channels = append(channels, ch)
+	}
+	return channels
+}
+
+func (rule routeRule) matches(repo, count, kind string) bool {
+	if len(rule.Events) > 0 && !contains(rule.Events, kind) {
+		return false
+	}
+	if len(rule.Counts) > 0 && !contains(rule.Counts, count) {
+		return false
+	}
+	if rule.Severity != "" && severities[routedKinds[kind]] < severities[rule.Severity] {
+		return false
+	}
+	if len(rule.Repos) == 0 {
+		return true
+	}
+	for _, pattern := range rule.Repos {
+		if ok, _ := path.Match(watchKey(pattern), repo); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sendTo sends the message for kind of event about repo through ch, to the
+// phone number to if ch sends SMS to the watch's phone number, with the chart
+// at the URL chart attached if it isn't empty, and records that it did so.
+func (n *notifier) sendTo(ch channel, repo, to, kind, message, chart string) error {
+	switch ch.Type {
+	case channelSMS:
+		if ch.To != "" {
+			to = ch.To
+		}
+		return n.send(repo, to, message, chart)
+	case channelWebhook:
+		payload := map[string]interface{}{
+			"text":     message,
+			"repo":     repo,
+			"event":    kind,
+			"severity": routedKinds[kind],
+		}
+		if chart != "" {
+			// Slack shows the image of an attachment; other webhooks
+			// can take the chart's URL from image_url.
+			payload["image_url"] = chart
+			payload["attachments"] = []map[string]string{{"fallback": message, "image_url": chart}}
+		}
+		attempted := time.Now()
+		err := postJSON(n.client, ch.URL, payload)
+		n.record(ch.name, repo, ch.name, message, attempted, err)
+		return err
+	case channelPagerDuty:
+		endpoint := ch.URL
+		if endpoint == "" {
+			endpoint = pagerDutyEventsURL
+		}
+		event := map[string]interface{}{
+			"routing_key":  ch.RoutingKey,
+			"event_action": "trigger",
+			"payload": map[string]string{
+				"summary":  message,
+				"source":   repo,
+				"severity": routedKinds[kind],
+				"group":    "github-stargazer",
+				"class":    kind,
+			},
+		}
+		if chart != "" {
+			event["images"] = []map[string]string{{"src": chart, "alt": "Star history of " + repo}}
+		}
+		attempted := time.Now()
+		err := postJSON(n.client, endpoint, event)
+		n.record(ch.name, repo, ch.name, message, attempted, err)
+		return err
+	}
+	return errors.Errorf("unknown channel type %q", ch.Type)
+}
+
+// postJSON posts v as JSON to endpoint with client, or a default client if
+// it is nil.
+func postJSON(client *http.Client, endpoint string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", stargazer.UserAgent)
+	if client == nil {
+		client = &http.Client{Timeout: 20 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL is left out of the error, as webhook URLs are secrets.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return errors.Wrapf(err, "error posting to %s", req.URL.Host)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return errors.Errorf("error posting to %s: %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
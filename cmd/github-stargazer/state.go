@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+)
+
+// maxNotificationHistory is how many notifications are remembered per watch.
+const maxNotificationHistory = 50
+
+// notificationRecord is a notification that was sent, or that failed to be.
+type notificationRecord struct {
+	Time    time.Time `json:"time"`
+	To      string    `json:"to"`
+	Message string    `json:"message"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// watchState is what is saved in the store for each watch.
+type watchState struct {
+	Gazer         stargazer.State      `json:"gazer"`
+	Notifications []notificationRecord `json:"notifications,omitempty"`
+}
+
+// loadStates reads the saved states from the manager's store.
+func (m *manager) loadStates() error {
+	m.states = make(map[string]watchState)
+	saved, err := m.store.States()
+	if err != nil {
+		return err
+	}
+	for key, b := range saved {
+		var st watchState
+		if err := json.Unmarshal(b, &st); err != nil {
+			return errors.Wrapf(err, "error decoding state for %s", key)
+		}
+		m.states[key] = st
+		m.notifier.restoreHistory(key, st.Notifications)
+	}
+	return nil
+}
+
+// savedState returns the saved state for the watch of repo.
+func (m *manager) savedState(repo string) (watchState, bool) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	st, ok := m.states[watchKey(repo)]
+	return st, ok
+}
+
+// checkpoint saves the state of gazer. It must be called from the goroutine
+// running the gazer, or after the gazer has stopped.
+func (m *manager) checkpoint(gazer *stargazer.GitHubStargazer) {
+	m.saveState(gazer.Repository, gazer.State())
+}
+
+// saveState saves gazerState, the state of the gazer watching repo, along
+// with the watch's notification history.
+func (m *manager) saveState(repo string, gazerState stargazer.State) {
+	key := watchKey(repo)
+	st := watchState{
+		Gazer:         gazerState,
+		Notifications: m.notifier.history(key),
+	}
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	m.states[key] = st
+	b, err := json.Marshal(st)
+	if err == nil {
+		err = m.store.PutState(key, b)
+	}
+	if err != nil {
+		m.log.Warnw("unable to save state", "repo", repo, "err", err)
+	}
+}
+
+// forgetState drops the saved state for repo.
+func (m *manager) forgetState(repo string) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+	delete(m.states, watchKey(repo))
+	if err := m.store.DeleteState(watchKey(repo)); err != nil {
+		m.log.Warnw("unable to delete state", "repo", repo, "err", err)
+	}
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+type statusResponse struct {
+	Repository       string              `json:"repository"`
+	StargazersCount  int                 `json:"stargazers_count"`
+	StargazersTarget int                 `json:"stargazers_target"`
+	Milestones       []int               `json:"milestones,omitempty"`
+	Velocity         stargazer.Velocity  `json:"velocity"`
+	RateLimit        stargazer.RateLimit `json:"rate_limit"`
+	Paused           bool                `json:"paused"`
+
+	// Retired is whether the watch parked itself, by pausing, because the
+	// repository was archived or deleted.
+	Retired bool `json:"retired"`
+
+	// Schedule is how the watch's polls have been scheduled, if they are
+	// paced by the shared scheduler.
+	Schedule *stargazer.ScheduleStats `json:"schedule,omitempty"`
+
+	// Circuit is the state of the watch's circuit breaker, if it has one.
+	Circuit *stargazer.Circuit `json:"circuit,omitempty"`
+
+	// GraphQLRateLimit is the rate limit of GitHub's GraphQL API, if the
+	// watch's count is fetched in a -graphql-batch. Throttled is whether
+	// the watch is polling less often than scheduled, to conserve the rate
+	// limit or because GitHub asked it to back off.
+	GraphQLRateLimit *stargazer.RateLimit `json:"graphql_rate_limit,omitempty"`
+	Throttled        bool                 `json:"throttled"`
+
+	// Metadata describes the repository, if its stars on GitHub are
+	// watched.
+	Metadata *stargazer.RepoMetadata `json:"metadata,omitempty"`
+
+	// Referrers are the top referrers of the repository, if they are
+	// fetched with -referrers-every, and ReferrersAt when they were.
+	Referrers   []stargazer.Referrer `json:"referrers,omitempty"`
+	ReferrersAt *time.Time           `json:"referrers_at,omitempty"`
+}
+
+func newStatusResponse(gazer *stargazer.GitHubStargazer) statusResponse {
+	target, milestones := gazer.Targets()
+	var schedule *stargazer.ScheduleStats
+	if st, ok := gazer.ScheduleStats(); ok {
+		schedule = &st
+	}
+	var circuit *stargazer.Circuit
+	if c, ok := gazer.Circuit(); ok {
+		circuit = &c
+	}
+	var graphqlRateLimit *stargazer.RateLimit
+	if rl, ok := gazer.GraphQLRateLimit(); ok {
+		graphqlRateLimit = &rl
+	}
+	var metadata *stargazer.RepoMetadata
+	if md, ok := gazer.Metadata(); ok {
+		metadata = &md
+	}
+	referrers, referrersAt, ok := gazer.Referrers()
+	var at *time.Time
+	if ok {
+		at = &referrersAt
+	}
+	return statusResponse{
+		Repository:       gazer.Repository,
+		StargazersCount:  gazer.StargazersCount(),
+		StargazersTarget: target,
+		Milestones:       milestones,
+		Velocity:         gazer.Velocity(),
+		RateLimit:        gazer.RateLimit(),
+		Paused:           gazer.Paused(),
+		Retired:          gazer.Retired(),
+		Schedule:         schedule,
+		Circuit:          circuit,
+		GraphQLRateLimit: graphqlRateLimit,
+		Throttled:        gazer.Throttled(),
+		Metadata:         metadata,
+		Referrers:        referrers,
+		ReferrersAt:      at,
+	}
+}
+
+// statusServer serves HTTP endpoints that report on the progress and health
+// of the watches, and that allow them to be controlled and managed.
+type statusServer struct {
+	watches *manager
+	log     *zap.SugaredLogger
+
+	// unhealthyAfter is the number of consecutive fetch failures after which
+	// /healthz reports the watcher as unhealthy.
+	unhealthyAfter int
+
+	// controlToken is the bearer token required by the control and watch
+	// management endpoints. They are not served if it is empty.
+	controlToken string
+
+	// audit is the log of notification attempts served at /notifications,
+	// if it is set.
+	audit *auditLog
+
+	// metrics are the measurements served at /metrics.
+	metrics *expvarMetrics
+
+	// logLevel is the level of the log, which can be changed through
+	// /log-level.
+	logLevel zap.AtomicLevel
+
+	// tlsCert and tlsKey are the certificate and key files to serve HTTPS
+	// with. Alternatively, autocertHost is a host name for which to obtain a
+	// certificate from Let's Encrypt, cached in autocertCache.
+	tlsCert, tlsKey string
+	autocertHost    string
+	autocertCache   string
+
+	// api validates requests against the OpenAPI description of the API,
+	// which is served at /openapi.json.
+	api *apiValidator
+
+	// server is the HTTP server that serve runs. Its handler should be
+	// the statusServer's handler.
+	server *http.Server
+}
+
+func (s *statusServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleDashboard)
+	mux.HandleFunc("/events", s.handleEvents)
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.HandleFunc("/history/", s.handleHistory)
+	mux.HandleFunc("/feed.atom", s.handleFeed)
+	mux.HandleFunc("/leaderboard", s.handleLeaderboard)
+	mux.HandleFunc("/chart/", s.handleChart)
+	mux.HandleFunc("/grafana/", s.handleGrafana)
+	mux.HandleFunc("/repos/", s.handleRepos)
+	mux.HandleFunc("/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	if s.controlToken != "" {
+		for _, action := range []string{"pause", "resume", "stop"} {
+			mux.HandleFunc("/"+action, s.requireToken(s.handleControl))
+		}
+		mux.HandleFunc("/watches", s.requireToken(s.handleWatches))
+		mux.HandleFunc("/watches/", s.requireToken(s.handleWatch))
+		if s.audit != nil {
+			mux.HandleFunc("/notifications", s.requireToken(s.handleNotifications))
+		}
+		mux.Handle("/log-level", s.requireToken(s.logLevel.ServeHTTP))
+	}
+	return s.validateRequests(mux)
+}
+
+// serve runs the HTTP server, using TLS if it has been configured. It runs
+// until the server fails or is shut down.
+func (s *statusServer) serve() {
+	server, addr := s.server, s.server.Addr
+	var err error
+	switch {
+	case s.autocertHost != "":
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(s.autocertHost),
+			Cache:      autocert.DirCache(s.autocertCache),
+		}
+		server.TLSConfig = m.TLSConfig()
+		s.log.Infow("serving status over TLS", "addr", addr, "host", s.autocertHost)
+		err = server.ListenAndServeTLS("", "")
+	case s.tlsCert != "":
+		s.log.Infow("serving status over TLS", "addr", addr)
+		err = server.ListenAndServeTLS(s.tlsCert, s.tlsKey)
+	default:
+		s.log.Infow("serving status", "addr", addr)
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		s.log.Errorw("status server failed", "addr", addr, "err", err)
+	}
+}
+
+// shutdown stops the server gracefully, giving in-flight requests until ctx
+// is done to complete.
+func (s *statusServer) shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+func (s *statusServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	resp := []statusResponse{}
+	for _, wt := range s.watches.list() {
+		resp = append(resp, newStatusResponse(wt.gazer))
+	}
+	s.writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *statusServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	failures := make(map[string]int)
+	for _, wt := range s.watches.list() {
+		n := wt.gazer.ConsecutiveFailures()
+		failures[wt.gazer.Repository] = n
+		if s.unhealthyAfter > 0 && n >= s.unhealthyAfter {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	s.writeJSON(w, status, map[string]interface{}{"consecutive_failures": failures})
+}
+
+func (s *statusServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ready := true
+	for _, wt := range s.watches.list() {
+		ready = ready && wt.gazer.Ready()
+	}
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	s.writeJSON(w, status, map[string]bool{"ready": ready})
+}
+
+// handleControl serves POST /{action} for every watch, and POST
+// /repos/{owner}/{repo}/{action} for a single one, for the pause, resume and
+// stop actions.
+func (s *statusServer) handleControl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	targets := s.watches.list()
+	action := strings.Trim(r.URL.Path, "/")
+	if strings.HasPrefix(action, "repos/") {
+		i := strings.LastIndex(action, "/")
+		wt, ok := s.watches.get(strings.TrimPrefix(action[:i], "repos/"))
+		if !ok {
+			s.writeError(w, http.StatusNotFound, errWatchNotFound.Error())
+			return
+		}
+		targets, action = []*watch{wt}, action[i+1:]
+	}
+	var control func(*stargazer.GitHubStargazer)
+	switch action {
+	case "pause":
+		control = (*stargazer.GitHubStargazer).Pause
+	case "resume":
+		control = (*stargazer.GitHubStargazer).Resume
+	case "stop":
+		control = (*stargazer.GitHubStargazer).Stop
+	default:
+		s.writeError(w, http.StatusNotFound, "unknown action")
+		return
+	}
+	repos := []string{}
+	for _, wt := range targets {
+		control(wt.gazer)
+		repos = append(repos, wt.gazer.Repository)
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"repositories": repos, "action": action})
+}
+
+// handleWatches serves GET /watches to list the watches, and POST /watches to
+// create one.
+func (s *statusServer) handleWatches(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		specs := []watchSpec{}
+		for _, wt := range s.watches.list() {
+			specs = append(specs, wt.spec)
+		}
+		s.writeJSON(w, http.StatusOK, specs)
+	case http.MethodPost:
+		var spec watchSpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.watches.create(spec); err != nil {
+			s.writeWatchError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusCreated, spec)
+	default:
+		s.methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// handleWatch serves GET, PUT and DELETE /watches/{owner}/{repo} to read,
+// replace and remove a single watch.
+func (s *statusServer) handleWatch(w http.ResponseWriter, r *http.Request) {
+	repo := strings.Trim(strings.TrimPrefix(r.URL.Path, "/watches/"), "/")
+	switch r.Method {
+	case http.MethodGet:
+		wt, ok := s.watches.get(repo)
+		if !ok {
+			s.writeError(w, http.StatusNotFound, errWatchNotFound.Error())
+			return
+		}
+		s.writeJSON(w, http.StatusOK, wt.spec)
+	case http.MethodPut:
+		var spec watchSpec
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			s.writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := s.watches.update(repo, spec); err != nil {
+			s.writeWatchError(w, err)
+			return
+		}
+		wt, _ := s.watches.get(repo)
+		s.writeJSON(w, http.StatusOK, wt.spec)
+	case http.MethodDelete:
+		if err := s.watches.remove(repo); err != nil {
+			s.writeWatchError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// requireToken wraps a handler so that it is only called for requests that
+// carry the control token as a bearer token.
+func (s *statusServer) requireToken(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			s.writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		h(w, r)
+	}
+}
+
+// authorized reports whether r carries the control token as a bearer token.
+func (s *statusServer) authorized(r *http.Request) bool {
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, "Bearer ") {
+		return false
+	}
+	token := strings.TrimPrefix(h, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.controlToken)) == 1
+}
+
+func (s *statusServer) writeWatchError(w http.ResponseWriter, err error) {
+	switch err {
+	case errWatchExists:
+		s.writeError(w, http.StatusConflict, err.Error())
+	case errWatchNotFound:
+		s.writeError(w, http.StatusNotFound, err.Error())
+	default:
+		s.writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+func (s *statusServer) methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	s.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
+
+func (s *statusServer) writeError(w http.ResponseWriter, status int, message string) {
+	s.writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (s *statusServer) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.log.Warnw("unable to write response", "err", err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/ianfoo/github-stargazer/boltstore"
+	"github.com/ianfoo/github-stargazer/sqlitestore"
+	"github.com/pkg/errors"
+)
+
+// store persists the state of watches and the history of the counts they
+// observe. Each storage driver provides one.
+type store interface {
+	Record(repo string, sample stargazer.Sample) error
+	RecordAll(repo string, samples []stargazer.Sample) error
+	History(repo string, from, to time.Time) ([]stargazer.Sample, error)
+	Compact(rollupBefore, dropBefore time.Time, resolution time.Duration) error
+	PutState(key string, value []byte) error
+	States() (map[string][]byte, error)
+	DeleteState(key string) error
+	Close() error
+}
+
+// errNoHistory is returned by stores that don't record history.
+var errNoHistory = errors.New("storage driver does not record history")
+
+// openStore opens the store for the named storage driver at path.
+func openStore(driver, path string) (store, error) {
+	switch driver {
+	case "file":
+		return &fileStore{path: path}, nil
+	case "sqlite":
+		return sqlitestore.Open(path)
+	case "bolt":
+		return boltstore.Open(path)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+// fileStore keeps state in a JSON file, and doesn't record history. With an
+// empty path, nothing is persisted at all.
+type fileStore struct {
+	path string
+
+	mu     sync.Mutex
+	states map[string]json.RawMessage
+}
+
+func (fs *fileStore) Record(repo string, sample stargazer.Sample) error {
+	return nil
+}
+
+func (fs *fileStore) RecordAll(repo string, samples []stargazer.Sample) error {
+	return nil
+}
+
+func (fs *fileStore) History(repo string, from, to time.Time) ([]stargazer.Sample, error) {
+	return nil, errNoHistory
+}
+
+func (fs *fileStore) Compact(rollupBefore, dropBefore time.Time, resolution time.Duration) error {
+	return nil
+}
+
+func (fs *fileStore) States() (map[string][]byte, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.states = make(map[string]json.RawMessage)
+	if fs.path == "" {
+		return nil, nil
+	}
+	b, err := os.ReadFile(fs.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading state file")
+	}
+	if err := json.Unmarshal(b, &fs.states); err != nil {
+		return nil, errors.Wrap(err, "error decoding state file")
+	}
+	states := make(map[string][]byte, len(fs.states))
+	for k, v := range fs.states {
+		states[k] = v
+	}
+	return states, nil
+}
+
+func (fs *fileStore) PutState(key string, value []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.states == nil {
+		fs.states = make(map[string]json.RawMessage)
+	}
+	fs.states[key] = value
+	return fs.write()
+}
+
+func (fs *fileStore) DeleteState(key string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	delete(fs.states, key)
+	return fs.write()
+}
+
+func (fs *fileStore) Close() error {
+	return nil
+}
+
+// write saves the states to the file. The caller must hold fs.mu.
+func (fs *fileStore) write() error {
+	if fs.path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(fs.states, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := fs.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return errors.Wrap(err, "error writing state file")
+	}
+	return errors.Wrap(os.Rename(tmp, fs.path), "error writing state file")
+}
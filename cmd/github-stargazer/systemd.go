@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// systemd is the notification socket of the systemd service the watcher runs
+// as, when it is run with Type=notify.
+type systemd struct {
+	socket string
+
+	// watchdog is how often systemd expects to be told the watcher is
+	// alive. It is zero if the service has no watchdog.
+	watchdog time.Duration
+}
+
+// newSystemd returns the service's notification socket from the environment
+// systemd runs it with, or nil if it isn't run by systemd with Type=notify.
+func newSystemd() *systemd {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	sd := &systemd{socket: socket}
+	// The watchdog is meant for this process only if WATCHDOG_PID, when
+	// it is set, is our PID.
+	if pid := os.Getenv("WATCHDOG_PID"); pid == "" || pid == strconv.Itoa(os.Getpid()) {
+		if usec, err := strconv.Atoi(os.Getenv("WATCHDOG_USEC")); err == nil && usec > 0 {
+			sd.watchdog = time.Duration(usec) * time.Microsecond
+		}
+	}
+	return sd
+}
+
+// notify sends state, such as READY=1, to systemd.
+func (sd *systemd) notify(state string) error {
+	addr := &net.UnixAddr{Name: sd.socket, Net: "unixgram"}
+	if addr.Name[0] == '@' {
+		// A leading @ is an abstract socket.
+		addr.Name = "\x00" + addr.Name[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return errors.Wrap(err, "error connecting to systemd notification socket")
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return errors.Wrap(err, "error notifying systemd")
+}
+
+// heartbeat is how often watches beat when systemd has a watchdog: often
+// enough that a watch that is alive beats several times per watchdog period.
+func (sd *systemd) heartbeat() time.Duration {
+	return sd.watchdog / 4
+}
+
+// supervise tells systemd the watcher is ready once every watch has fetched
+// its count, and pings the watchdog for as long as every watch keeps beating,
+// until stop is closed.
+func (a *app) supervise(sd *systemd, stop <-chan struct{}) {
+	tick := time.Second
+	if sd.watchdog > 0 {
+		tick = sd.watchdog / 2
+	}
+	t := time.NewTicker(tick)
+	defer t.Stop()
+	ready := false
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-t.C:
+			if !ready && a.watches.ready() {
+				if err := sd.notify("READY=1"); err != nil {
+					a.log.Warnw("unable to notify systemd", "err", err)
+				}
+				ready = true
+			}
+			if sd.watchdog > 0 && a.watches.alive(now.Add(-sd.watchdog)) {
+				if err := sd.notify("WATCHDOG=1"); err != nil {
+					a.log.Warnw("unable to ping systemd watchdog", "err", err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxSummaryLength is how long a summary of held back notifications can get,
+// within the 1,600 characters that Twilio splits a long SMS into.
+const maxSummaryLength = 1500
+
+// throttle limits how many notifications are sent across every watch with a
+// token bucket, which holds up to limit tokens and gains limit of them each
+// period. Notifications that find the bucket empty are held back, and sent
+// together as one summary per recipient once a token is available. It is
+// safe for concurrent use.
+type throttle struct {
+	limit  int
+	period time.Duration
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	held   map[string][]heldNotification
+
+	// flushing is true while a goroutine is waiting to send the held back
+	// notifications.
+	flushing bool
+}
+
+// heldNotification is a notification held back by the throttle.
+type heldNotification struct {
+	repo    string
+	message string
+}
+
+// newThrottle returns a throttle that allows limit notifications each
+// period, starting with a full bucket, or nil if limit is 0.
+func newThrottle(limit int, period time.Duration) (*throttle, error) {
+	if limit == 0 {
+		return nil, nil
+	}
+	if limit < 0 || period <= 0 {
+		return nil, errors.New("-notify-limit must not be negative, and -notify-period must be positive")
+	}
+	return &throttle{
+		limit:  limit,
+		period: period,
+		tokens: float64(limit),
+		last:   time.Now(),
+		held:   make(map[string][]heldNotification),
+	}, nil
+}
+
+// refill adds the tokens gained since the bucket was last refilled. The
+// caller must hold t.mu.
+func (t *throttle) refill(now time.Time) {
+	t.tokens += now.Sub(t.last).Seconds() * float64(t.limit) / t.period.Seconds()
+	if t.tokens > float64(t.limit) {
+		t.tokens = float64(t.limit)
+	}
+	t.last = now
+}
+
+// allow takes a token for a notification about repo to the recipient to,
+// reporting whether there was one. If there wasn't, the notification is held
+// back, and allow reports whether the caller should start flushing the held
+// back notifications. A nil throttle allows every notification.
+func (t *throttle) allow(repo, to, message string) (ok, flush bool) {
+	if t == nil {
+		return true, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.refill(time.Now())
+	if t.tokens >= 1 && len(t.held) == 0 {
+		t.tokens--
+		return true, false
+	}
+	t.held[to] = append(t.held[to], heldNotification{repo: repo, message: message})
+	flush = !t.flushing
+	t.flushing = true
+	return false, flush
+}
+
+// next waits for a token and takes it, returning the notifications held back
+// for one recipient to send in its place, or false once there are none left.
+func (t *throttle) next() (string, []heldNotification, bool) {
+	for {
+		t.mu.Lock()
+		if len(t.held) == 0 {
+			t.flushing = false
+			t.mu.Unlock()
+			return "", nil, false
+		}
+		t.refill(time.Now())
+		if t.tokens >= 1 {
+			t.tokens--
+			var to string
+			for recipient := range t.held {
+				to = recipient
+				break
+			}
+			held := t.held[to]
+			delete(t.held, to)
+			t.mu.Unlock()
+			return to, held, true
+		}
+		wait := time.Duration((1 - t.tokens) * t.period.Seconds() / float64(t.limit) * float64(time.Second))
+		t.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// summarize returns the repository that held back notifications are about,
+// or an empty string if they are about several, and a message summarizing
+// them under header.
+func summarize(header string, held []heldNotification) (string, string) {
+	if len(held) == 1 {
+		return held[0].repo, held[0].message
+	}
+	repo := held[0].repo
+	for _, h := range held {
+		if watchKey(h.repo) != watchKey(repo) {
+			repo = ""
+		}
+	}
+	var b strings.Builder
+	b.WriteString(header)
+	for i, h := range held {
+		line := "\n- " + h.message
+		if b.Len()+len(line) > maxSummaryLength {
+			fmt.Fprintf(&b, "\n…and %d more", len(held)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	return repo, b.String()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThrottleRefill(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{name: "no time passed", tokens: 1.5, want: 1.5},
+		{name: "part of a token", tokens: 0, elapsed: 30 * time.Second, want: 0.5},
+		{name: "several tokens", tokens: 0.5, elapsed: 3 * time.Minute, want: 3.5},
+		{name: "no more than the limit", tokens: 59.5, elapsed: time.Hour, want: 60},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th, err := newThrottle(60, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			th.tokens = tt.tokens
+			th.refill(th.last.Add(tt.elapsed))
+			if th.tokens != tt.want {
+				t.Errorf("got %v tokens, want %v", th.tokens, tt.want)
+			}
+		})
+	}
+}
+
+func TestThrottleHoldsBackAndFlushes(t *testing.T) {
+	th, err := newThrottle(2, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allows := []struct {
+		repo, to, message string
+		wantOK, wantFlush bool
+	}{
+		{"owner/a", "+15005550001", "a 1", true, false},
+		{"owner/b", "+15005550001", "b 1", true, false},
+		{"owner/a", "+15005550001", "a 2", false, true},
+		{"owner/b", "+15005550002", "b 2", false, false},
+		{"owner/b", "+15005550001", "b 3", false, false},
+	}
+	for _, a := range allows {
+		ok, flush := th.allow(a.repo, a.to, a.message)
+		if ok != a.wantOK || flush != a.wantFlush {
+			t.Errorf("allow %q returned %v, %v, want %v, %v", a.message, ok, flush, a.wantOK, a.wantFlush)
+		}
+	}
+
+	// Refill the bucket rather than wait half an hour for each token.
+	th.mu.Lock()
+	th.tokens = 2
+	th.mu.Unlock()
+	want := map[string]string{
+		"+15005550001": "a 2, b 3",
+		"+15005550002": "b 2",
+	}
+	got := make(map[string]string)
+	for {
+		to, held, ok := th.next()
+		if !ok {
+			break
+		}
+		var messages []string
+		for _, h := range held {
+			messages = append(messages, h.message)
+		}
+		got[to] = strings.Join(messages, ", ")
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("flushed %v, want %v", got, want)
+	}
+	if th.flushing {
+		t.Error("still flushing once every held back notification was flushed")
+	}
+	if ok, _ := th.allow("owner/a", "+15005550001", "a 3"); ok {
+		t.Error("allowed a notification before the bucket refilled")
+	}
+}
+
+func TestNilThrottle(t *testing.T) {
+	var th *throttle
+	for i := 0; i < 3; i++ {
+		if ok, flush := th.allow("owner/repo", "+15005550001", "message"); !ok || flush {
+			t.Fatalf("nil throttle returned %v, %v, want true, false", ok, flush)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	held := func(repo string, n int, length int) []heldNotification {
+		var hs []heldNotification
+		for i := 0; i < n; i++ {
+			hs = append(hs, heldNotification{repo: repo, message: strings.Repeat(fmt.Sprint(i%10), length)})
+		}
+		return hs
+	}
+	lines := func(hs []heldNotification) string {
+		var b strings.Builder
+		for _, h := range hs {
+			b.WriteString("\n- " + h.message)
+		}
+		return b.String()
+	}
+	long := held("owner/a", 20, 100)
+	tests := []struct {
+		name        string
+		held        []heldNotification
+		wantRepo    string
+		wantMessage string
+	}{
+		{
+			name:        "one notification",
+			held:        []heldNotification{{repo: "owner/a", message: "owner/a reached 100 stars"}},
+			wantRepo:    "owner/a",
+			wantMessage: "owner/a reached 100 stars",
+		},
+		{
+			name: "one repository",
+			held: []heldNotification{
+				{repo: "owner/a", message: "first"},
+				{repo: "Owner/A", message: "second"},
+			},
+			wantRepo:    "owner/a",
+			wantMessage: "Held back:\n- first\n- second",
+		},
+		{
+			name: "several repositories",
+			held: []heldNotification{
+				{repo: "owner/a", message: "first"},
+				{repo: "owner/b", message: "second"},
+			},
+			wantMessage: "Held back:\n- first\n- second",
+		},
+		{
+			name:        "too long",
+			held:        long,
+			wantRepo:    "owner/a",
+			wantMessage: "Held back:" + lines(long[:14]) + "\n…and 6 more",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, message := summarize("Held back:", tt.held)
+			if repo != tt.wantRepo {
+				t.Errorf("got repo %q, want %q", repo, tt.wantRepo)
+			}
+			if message != tt.wantMessage {
+				t.Errorf("got message %q, want %q", message, tt.wantMessage)
+			}
+		})
+	}
+}
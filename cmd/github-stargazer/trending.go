@@ -0,0 +1,54 @@
+package main
+
+import (
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+)
+
+// trendingSpec asks to be notified when a watched repository enters or
+// leaves a GitHub Trending list: those of every language for each period in
+// Since (daily and weekly if it is empty), and those of each of Languages.
+type trendingSpec struct {
+	Since     []string `json:"since,omitempty"`
+	Languages []string `json:"languages,omitempty"`
+}
+
+// newTrendingWatcher creates a watcher for the GitHub Trending lists that
+// spec asks to be notified about, or returns nil if it asks for none. The
+// watcher is not started.
+func (n *notifier) newTrendingWatcher(spec watchSpec) (*stargazer.TrendingWatcher, error) {
+	if spec.Trending == nil {
+		return nil, nil
+	}
+	if (spec.Provider != "" && spec.Provider != providerGitHub) || spec.BaseURL != "" {
+		return nil, errors.New("trending is only supported for repositories on github.com")
+	}
+	if spec.Count == string(stargazer.Followers) || spec.Count == string(stargazer.Sponsors) {
+		return nil, errors.New("trending is only supported for repositories, not followers or sponsors")
+	}
+	phone := spec.Phone
+	if phone == "" {
+		phone = n.defaultPhone
+	}
+	hook := func(t stargazer.Trending) error {
+		kind := "left-trending"
+		if t.Entered {
+			kind = "trending"
+		}
+		return n.notify(spec, phone, kind, messageData{
+			Repo:     spec.Repo,
+			Since:    t.Since,
+			Language: t.Language,
+			Rank:     t.Rank,
+		})
+	}
+	options := append([]func(*stargazer.TrendingWatcher){
+		stargazer.WithTrendingLogger(n.log),
+		stargazer.WithTrendingHook(hook),
+		stargazer.WithTrendingLanguages(spec.Trending.Languages...),
+	}, n.trendingOptions...)
+	if len(spec.Trending.Since) > 0 {
+		options = append(options, stargazer.WithTrendingPeriods(spec.Trending.Since...))
+	}
+	return stargazer.NewTrendingWatcher(spec.Repo, n.trendingInterval, options...)
+}
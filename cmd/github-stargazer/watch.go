@@ -0,0 +1,1124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// watchSpec describes a repository to watch. It is what the watch management
+// API accepts and what gets persisted to the watches file.
+type watchSpec struct {
+	Repo          string         `json:"repo"`
+	Provider      string         `json:"provider,omitempty"`
+	BaseURL       string         `json:"base_url,omitempty"`
+	Count         string         `json:"count,omitempty"`
+	Target        string         `json:"target"`
+	Milestones    []int          `json:"milestones,omitempty"`
+	Progress      []int          `json:"progress,omitempty"`
+	Interval      string         `json:"interval,omitempty"`
+	IntervalAt    map[int]string `json:"interval_at,omitempty"`
+	Schedule      string         `json:"schedule,omitempty"`
+	Report        string         `json:"report,omitempty"`
+	Deadline      string         `json:"deadline,omitempty"`
+	VelocityAlert float64        `json:"velocity_alert,omitempty"`
+	Phone         string         `json:"phone,omitempty"`
+	Lang          string         `json:"lang,omitempty"`
+	HackerNews    *mentionSpec   `json:"hacker_news,omitempty"`
+	Reddit        *mentionSpec   `json:"reddit,omitempty"`
+
+	// Channels are where the watch's notifications go that no routing rule
+	// routes, instead of by SMS to Phone, and Templates replace the message
+	// templates for its notifications by event.
+	Channels  []string          `json:"channels,omitempty"`
+	Templates map[string]string `json:"templates,omitempty"`
+
+	// Rival is a repository on the same provider to compare the count with,
+	// notifying when either overtakes the other, and when they come within
+	// RivalGap of each other if it is set.
+	Rival    string `json:"rival,omitempty"`
+	RivalGap int    `json:"rival_gap,omitempty"`
+
+	// WatchContent has the watch notify when the description or README of
+	// the repository changes, which is only watched on GitHub.
+	WatchContent bool `json:"watch_content,omitempty"`
+
+	// Trending has the watch notify when the repository enters or leaves
+	// the GitHub Trending lists it asks for.
+	Trending *trendingSpec `json:"trending,omitempty"`
+}
+
+// watch is a running gazer and the spec it was created from, along with the
+// watchers of posts mentioning its repository and of GitHub Trending.
+type watch struct {
+	spec     watchSpec
+	gazer    *stargazer.GitHubStargazer
+	mentions []*stargazer.MentionWatcher
+	trending *stargazer.TrendingWatcher
+
+	// beat is when the gazer last beat, in Unix nanoseconds, if the manager
+	// has a heartbeat.
+	beat atomic.Int64
+
+	// fetched is when the gazer last fetched its count, in Unix
+	// nanoseconds.
+	fetched atomic.Int64
+}
+
+// notifier builds gazers for watch specs, wiring their hooks up to send SMS
+// messages.
+type notifier struct {
+	log          *zap.SugaredLogger
+	sms          stargazer.SMSSender
+	gazerOptions []func(*stargazer.GitHubStargazer)
+
+	// graphqlBatch, if it is set, fetches the counts of GitHub watches on
+	// the default GitHub host together.
+	graphqlBatch    *stargazer.GraphQLBatch
+	gitlabToken     stargazer.TokenSource
+	giteaToken      stargazer.TokenSource
+	bitbucketToken  stargazer.TokenSource
+	defaultPhone    string
+	defaultInterval time.Duration
+
+	// stallAfter is how long a watch's count can go without being fetched
+	// before an SMS is sent about it, or 0 if none is.
+	stallAfter time.Duration
+
+	// mentionInterval is how often sites are searched for posts mentioning
+	// repositories, using hackerNewsOptions for Hacker News and
+	// redditOptions for Reddit.
+	mentionInterval   time.Duration
+	hackerNewsOptions []func(*stargazer.HackerNewsSource)
+	redditOptions     []func(*stargazer.RedditSource)
+
+	// trendingInterval is how often GitHub Trending is read for watches
+	// that ask to be notified about it, using trendingOptions.
+	trendingInterval time.Duration
+	trendingOptions  []func(*stargazer.TrendingWatcher)
+
+	// spikeWindow is how far back to look for posts that set off a spike
+	// in the count of a watch, to name the likely source in its velocity
+	// notification, or 0 if they aren't looked for. Lobsters is read using
+	// lobstersOptions.
+	spikeWindow     time.Duration
+	lobstersOptions []func(*stargazer.LobstersSource)
+
+	// notified is called from a gazer's hooks after they have sent
+	// notifications, and fired with a gazer's state when it fires
+	// something, before its hooks send notifications for it.
+	notified func(*stargazer.GitHubStargazer)
+	fired    func(repo string, st stargazer.State)
+
+	// alerts alerts a fallback channel when SMS notifications keep failing,
+	// if it is set.
+	alerts *outageAlerter
+
+	// router routes notifications to channels other than SMS, if it is
+	// set, and client is what they are sent with.
+	router *router
+	client *http.Client
+
+	// throttle limits how many notifications are sent, if it is set.
+	throttle *throttle
+
+	// audit keeps every notification attempt, if it is set.
+	audit *auditLog
+
+	// metrics receives the measurements of the gazers, the scheduler, the
+	// GraphQL batch and the SMS sender.
+	metrics *expvarMetrics
+
+	// events logs the events of the watches and the result of every
+	// notification, if it is set.
+	events *eventLog
+
+	// messages are the templates for the notifications sent for events, in
+	// every language.
+	messages *catalog
+
+	// publicURL is the URL at which the status server can be reached by
+	// the services notifications are sent through, if it is set, so that
+	// charts served by it can be attached to notifications.
+	publicURL string
+
+	// unstarOnExit has unstarAll remove the stars that watches added when
+	// they reached their targets, which are recorded in starred.
+	unstarOnExit bool
+
+	mu            sync.Mutex
+	notifications map[string][]notificationRecord
+	starred       []*stargazer.GitHubStargazer
+}
+
+// notify sends the message for kind of event about the watch of spec,
+// rendered with data in the watch's language, to the phone number to.
+// It is sent by SMS unless the routing rules route it elsewhere, in which
+// case an error is returned only if it couldn't be sent through any of the
+// channels it was routed to, so that retries don't repeat it through those
+// that it was.
+func (n *notifier) notify(spec watchSpec, to, kind string, data messageData) error {
+	message, err := n.message(spec, kind, data)
+	if err != nil {
+		return err
+	}
+	return n.notifyMessage(spec, to, kind, message)
+}
+
+// notifyMessage sends a message already rendered for kind of event about the
+// watch of spec like notify does.
+func (n *notifier) notifyMessage(spec watchSpec, to, kind, message string) error {
+	channels, routed := n.router.route(spec, kind)
+	if !routed && len(spec.Channels) > 0 {
+		channels, routed = n.router.named(spec.Channels), true
+	}
+	chart := n.chartFor(spec, kind)
+	if !routed {
+		return n.send(spec.Repo, to, message, chart)
+	}
+	if len(channels) == 0 {
+		n.log.Debugw("notification dropped by routing rules", "repo", spec.Repo, "event", kind)
+		return nil
+	}
+	var (
+		sent bool
+		err  error
+	)
+	for _, ch := range channels {
+		if err = n.sendTo(ch, spec.Repo, to, kind, message, chart); err != nil {
+			n.log.Warnw("unable to send notification", "repo", spec.Repo, "channel", ch.name, "err", err)
+			continue
+		}
+		sent = true
+	}
+	if sent {
+		return nil
+	}
+	return err
+}
+
+// message renders the message for kind of event about the watch of spec
+// with data in the watch's language.
+func (n *notifier) message(spec watchSpec, kind string, data messageData) (string, error) {
+	count := spec.Count
+	if count == "" {
+		count = string(stargazer.Stars)
+	}
+	data.Site = siteName(spec)
+	unit, err := n.messages.render(spec.Lang, "unit."+count, messageData{})
+	if err != nil {
+		return "", err
+	}
+	data.Unit = unit
+	if _, ok := spec.Templates[kind]; ok {
+		return n.messages.renderOverride(spec.Templates, kind, data)
+	}
+	return n.messages.render(spec.Lang, kind, data)
+}
+
+// send sends an SMS about repo, with the image at the URL chart attached if
+// it isn't empty, and records that it did so, unless the throttle holds it
+// back to be sent later in a summary, without the image.
+func (n *notifier) send(repo, to, message, chart string) error {
+	ok, flush := n.throttle.allow(repo, to, message)
+	if ok {
+		return n.deliver(repo, to, message, chart)
+	}
+	n.log.Infow("notification held back by -notify-limit", "repo", repo, "to", to)
+	now := time.Now()
+	audit := auditRecord{
+		Repository: repo,
+		Channel:    "sms",
+		To:         to,
+		Message:    message,
+		Result:     "held",
+		Attempted:  now,
+		Completed:  now,
+	}
+	if err := n.audit.append(audit); err != nil {
+		n.log.Warnw("unable to record notification in audit log", "repo", repo, "err", err)
+	}
+	event := eventRecord{
+		Time:       now,
+		Type:       "notification",
+		Repository: repo,
+		Channel:    audit.Channel,
+		To:         to,
+		Message:    message,
+		Result:     audit.Result,
+	}
+	if err := n.events.write(event); err != nil {
+		n.log.Warnw("unable to record notification in event log", "repo", repo, "err", err)
+	}
+	if flush {
+		go n.flushHeld()
+	}
+	return nil
+}
+
+// flushHeld sends the notifications held back by the throttle, as a summary
+// for each recipient, as the throttle allows.
+func (n *notifier) flushHeld() {
+	for {
+		to, held, ok := n.throttle.next()
+		if !ok {
+			return
+		}
+		header, err := n.messages.render("", "held", messageData{Count: len(held)})
+		if err != nil {
+			n.log.Warnw("unable to render held back notifications", "err", err)
+			header = fmt.Sprintf("%d notifications held back:", len(held))
+		}
+		repo, message := summarize(header, held)
+		if err := n.deliver(repo, to, message, ""); err != nil {
+			n.log.Warnw("unable to send held back notifications", "to", to, "held", len(held), "err", err)
+		}
+	}
+}
+
+// deliver sends an SMS about repo, and records that it did so. If chart
+// isn't empty and the sender can send MMS, the image at the URL chart is
+// attached.
+func (n *notifier) deliver(repo, to, message, chart string) error {
+	attempted := time.Now()
+	var err error
+	if mms, ok := n.sms.(stargazer.MMSSender); ok && chart != "" {
+		err = mms.SendMedia(to, message, chart)
+	} else {
+		err = n.sms.Send(to, message)
+	}
+	n.record("sms", repo, to, message, attempted, err)
+	return err
+}
+
+// record records an attempt made at attempted to send a notification about
+// repo through channel, which failed with err if it isn't nil, in the audit
+// log, the event log and the watch's history, and with the outage alerter.
+func (n *notifier) record(channel, repo, to, message string, attempted time.Time, err error) {
+	n.alerts.record(channel, err, n.events)
+	rec := notificationRecord{Time: time.Now(), To: to, Message: message}
+	audit := auditRecord{
+		Repository: repo,
+		Channel:    channel,
+		To:         to,
+		Message:    message,
+		Result:     "sent",
+		Attempted:  attempted,
+		Completed:  rec.Time,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+		audit.Result, audit.Error = "failed", err.Error()
+	}
+	if err := n.audit.append(audit); err != nil {
+		n.log.Warnw("unable to record notification in audit log", "repo", repo, "err", err)
+	}
+	event := eventRecord{
+		Time:       rec.Time,
+		Type:       "notification",
+		Repository: repo,
+		Channel:    audit.Channel,
+		To:         to,
+		Message:    message,
+		Result:     audit.Result,
+		Error:      audit.Error,
+	}
+	if err := n.events.write(event); err != nil {
+		n.log.Warnw("unable to record notification in event log", "repo", repo, "err", err)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.notifications == nil {
+		n.notifications = make(map[string][]notificationRecord)
+	}
+	history := append(n.notifications[watchKey(repo)], rec)
+	if len(history) > maxNotificationHistory {
+		history = history[len(history)-maxNotificationHistory:]
+	}
+	n.notifications[watchKey(repo)] = history
+}
+
+// history returns the notifications recorded for the watch with key.
+func (n *notifier) history(key string) []notificationRecord {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]notificationRecord(nil), n.notifications[key]...)
+}
+
+func (n *notifier) restoreHistory(key string, history []notificationRecord) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.notifications == nil {
+		n.notifications = make(map[string][]notificationRecord)
+	}
+	n.notifications[key] = history
+}
+
+func (n *notifier) afterNotify(gazer *stargazer.GitHubStargazer) {
+	if n.notified != nil {
+		n.notified(gazer)
+	}
+}
+
+// newGazer creates a gazer for spec. The gazer is not started.
+func (n *notifier) newGazer(spec watchSpec, extra ...func(*stargazer.GitHubStargazer)) (*stargazer.GitHubStargazer, error) {
+	if spec.Repo == "" {
+		return nil, errors.New("repo is required")
+	}
+	targetCount, relative, err := parseTarget(spec.Target)
+	if err != nil {
+		return nil, err
+	}
+	phone := spec.Phone
+	if phone == "" {
+		phone = n.defaultPhone
+	}
+	if phone == "" && (len(spec.Channels) == 0 || contains(spec.Channels, channelSMS)) {
+		return nil, errors.New("phone number is required")
+	}
+	for _, name := range spec.Channels {
+		if _, ok := n.router.channel(name); !ok {
+			return nil, errors.Errorf("unknown channel %q: channels other than sms must be defined in the -routes file", name)
+		}
+	}
+	if _, err := n.messages.overrides(spec.Templates); err != nil {
+		return nil, err
+	}
+	interval := n.defaultInterval
+	if spec.Interval != "" {
+		if interval, err = time.ParseDuration(spec.Interval); err != nil {
+			return nil, errors.Wrap(err, "invalid interval")
+		}
+	}
+
+	options := append([]func(*stargazer.GitHubStargazer){},
+		n.gazerOptions...)
+	sourceOptions, err := n.sourceOptions(spec)
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, sourceOptions...)
+	options = append(options, extra...)
+	options = append(options, stargazer.WithMilestones(spec.Milestones...))
+	options = append(options, stargazer.WithStateHook(func(st stargazer.State) error {
+		if n.fired != nil {
+			n.fired(spec.Repo, st)
+		}
+		return nil
+	}))
+	for count, iv := range spec.IntervalAt {
+		d, err := time.ParseDuration(iv)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid interval at %d stargazers", count)
+		}
+		options = append(options, stargazer.WithIntervalAt(count, d))
+	}
+	if spec.Schedule != "" {
+		schedule, err := stargazer.ParseCron(spec.Schedule)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid schedule")
+		}
+		options = append(options, stargazer.WithSchedule(schedule))
+	}
+	if relative {
+		options = append(options, stargazer.WithRelativeTargets())
+	}
+	// gazer is assigned once it has been created, before any hook can run.
+	var gazer *stargazer.GitHubStargazer
+	if len(spec.Progress) > 0 {
+		progressHook := func(p stargazer.Progress) error {
+			defer n.afterNotify(gazer)
+			return n.notify(spec, phone, "progress", messageData{
+				Repo:    p.Repository,
+				Count:   p.StargazersCount,
+				Target:  p.Target,
+				Percent: p.Percent,
+			})
+		}
+		options = append(options, stargazer.WithProgressCheckpoints(progressHook, spec.Progress...))
+	}
+	if spec.Deadline != "" {
+		deadline, err := time.Parse(time.RFC3339, spec.Deadline)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid deadline")
+		}
+		deadlineHook := func(d stargazer.Deadline) error {
+			defer n.afterNotify(gazer)
+			return n.notify(spec, phone, "deadline", messageData{
+				Repo:     d.Repository,
+				Count:    d.StargazersCount,
+				Target:   d.Target,
+				Deadline: d.Deadline,
+			})
+		}
+		options = append(options, stargazer.WithDeadline(deadline, deadlineHook))
+	}
+	if n.stallAfter > 0 {
+		stallHook := func(s stargazer.Stall) error {
+			data := messageData{
+				Repo:     s.Repository,
+				Count:    gazer.StargazersCount(),
+				Target:   gazer.StargazersTarget,
+				Duration: s.For.Round(time.Second),
+			}
+			if s.Err != nil {
+				data.Error = s.Err.Error()
+			}
+			return n.notify(spec, phone, "stalled", data)
+		}
+		options = append(options, stargazer.WithWatchdog(n.stallAfter, stallHook))
+	}
+	renameHook := func(r stargazer.Rename) error {
+		return n.notify(spec, phone, "renamed", messageData{
+			Repo:    r.Previous,
+			Count:   gazer.StargazersCount(),
+			NewName: r.NewName,
+		})
+	}
+	options = append(options, stargazer.WithRenameHook(renameHook))
+	retireHook := func(r stargazer.Retirement) error {
+		kind := "deleted"
+		if r.Archived {
+			kind = "archived"
+		}
+		data := messageData{Repo: r.Repository, Count: gazer.StargazersCount()}
+		if r.Err != nil {
+			data.Error = r.Err.Error()
+		}
+		return n.notify(spec, phone, kind, data)
+	}
+	options = append(options, stargazer.WithRetireHook(retireHook))
+	licenseHook := func(c stargazer.LicenseChange) error {
+		return n.notify(spec, phone, "license", messageData{
+			Repo:            c.Repository,
+			Count:           gazer.StargazersCount(),
+			License:         c.License,
+			PreviousLicense: c.Previous,
+		})
+	}
+	options = append(options, stargazer.WithLicenseHook(licenseHook))
+	if spec.Report != "" {
+		schedule, err := stargazer.ParseCron(spec.Report)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid report schedule")
+		}
+		reportHook := func(r stargazer.Report) error {
+			data := messageData{
+				Repo:     r.Repository,
+				Count:    r.StargazersCount,
+				Target:   r.Next,
+				Velocity: r.Velocity.PerHour,
+			}
+			if r.Next > 0 {
+				data.Remaining = r.Next - r.StargazersCount
+			}
+			return n.notify(spec, phone, "report", data)
+		}
+		options = append(options, stargazer.WithReport(schedule, reportHook))
+	}
+	if spec.Rival != "" {
+		rival, err := n.rivalSource(spec)
+		if err != nil {
+			return nil, err
+		}
+		rivalHook := func(r stargazer.Rivalry) error {
+			defer n.afterNotify(gazer)
+			return n.notify(spec, phone, string(r.Kind), messageData{
+				Repo:       r.Repository,
+				Count:      r.StargazersCount,
+				Target:     gazer.StargazersTarget,
+				Rival:      r.Rival,
+				RivalCount: r.RivalCount,
+				Gap:        r.Gap,
+			})
+		}
+		options = append(options, stargazer.WithRival(spec.Rival, rival, spec.RivalGap, rivalHook))
+	}
+	if spec.WatchContent {
+		if !countsGitHubStars(spec) {
+			return nil, errors.New("watch_content is only supported for the stars of GitHub repositories")
+		}
+		contentHook := func(c stargazer.ContentChange) error {
+			return n.notify(spec, phone, c.Part, messageData{
+				Repo:        c.Repository,
+				Count:       gazer.StargazersCount(),
+				Description: c.Description,
+				Link:        c.URL,
+			})
+		}
+		options = append(options, stargazer.WithContentWatch(contentHook))
+	}
+	if spec.VelocityAlert > 0 {
+		spikeSources, err := n.spikeSources(spec)
+		if err != nil {
+			return nil, err
+		}
+		velocityHook := func(v stargazer.Velocity) error {
+			defer n.afterNotify(gazer)
+			data := messageData{
+				Repo:     spec.Repo,
+				Count:    gazer.StargazersCount(),
+				Target:   gazer.StargazersTarget,
+				Velocity: v.PerHour,
+			}
+			if m, ok := n.spikeSource(gazer, spikeSources); ok {
+				data.Forum, data.Title, data.Link, data.Points = m.Site, m.Title, m.Link, m.Points
+			}
+			return n.notify(spec, phone, "velocity", data)
+		}
+		options = append(options, stargazer.WithVelocityAlert(spec.VelocityAlert, velocityHook))
+	}
+	gazer, err = stargazer.NewGitHubStargazer(
+		spec.Repo,
+		targetCount,
+		interval,
+		nil,
+		options...)
+	if err != nil {
+		return nil, err
+	}
+	gazer.AddHook(func(m stargazer.Milestone) error {
+		defer n.afterNotify(gazer)
+		kind := "milestone"
+		if m.Target == gazer.StargazersTarget {
+			kind = "target"
+		}
+		return n.notify(spec, phone, kind, messageData{
+			Repo:     m.Repository,
+			Count:    m.StargazersCount,
+			Target:   m.Target,
+			Velocity: gazer.Velocity().PerHour,
+		})
+	})
+	if countsGitHubStars(spec) {
+		// starred is whether the hook starred the repository, so that a
+		// retry of the hook still sends the SMS about it.
+		var starred bool
+		gazer.AddHook(func(m stargazer.Milestone) error {
+			if !m.Final {
+				return nil
+			}
+			if !starred {
+				already, err := gazer.Starred()
+				if err != nil {
+					return err
+				}
+				if already {
+					n.log.Infow("repository already starred", "repo", gazer.Repository)
+					return nil
+				}
+				if err := gazer.Star(); err != nil {
+					return err
+				}
+				starred = true
+				n.recordStarred(gazer)
+			}
+			defer n.afterNotify(gazer)
+			return n.notify(spec, phone, "starred", messageData{
+				Repo:   gazer.Repository,
+				Count:  m.StargazersCount,
+				Target: m.Target,
+			})
+		})
+	}
+	return gazer, nil
+}
+
+// recordStarred records that gazer starred its repository, for unstarAll to
+// unstar, if unstarOnExit is set.
+func (n *notifier) recordStarred(gazer *stargazer.GitHubStargazer) {
+	if !n.unstarOnExit {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, starred := range n.starred {
+		if starred == gazer {
+			return
+		}
+	}
+	n.starred = append(n.starred, gazer)
+}
+
+// unstarAll removes the stars recorded by recordStarred.
+func (n *notifier) unstarAll() {
+	n.mu.Lock()
+	starred := n.starred
+	n.starred = nil
+	n.mu.Unlock()
+	for _, gazer := range starred {
+		if err := gazer.Unstar(); err != nil {
+			n.log.Warnw("unable to unstar repository", "repo", gazer.Repository, "err", err)
+		}
+	}
+}
+
+// rivalSource returns the source of the count of the rival of spec, which
+// is on the same provider and counts the same thing.
+func (n *notifier) rivalSource(spec watchSpec) (stargazer.Source, error) {
+	rival, err := resolveProvider(watchSpec{
+		Repo:     spec.Rival,
+		Provider: spec.Provider,
+		BaseURL:  spec.BaseURL,
+		Count:    spec.Count,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid rival")
+	}
+	if watchKey(rival.Repo) == watchKey(spec.Repo) {
+		return nil, errors.New("a repo cannot be its own rival")
+	}
+	sourceOptions, err := n.sourceOptions(rival)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid rival")
+	}
+	// The gazer is only used to fetch the rival's count, so its target
+	// doesn't matter.
+	return stargazer.NewGitHubStargazer(rival.Repo, 1, 0, nil,
+		append(append([]func(*stargazer.GitHubStargazer){}, n.gazerOptions...), sourceOptions...)...)
+}
+
+// manager runs a set of watches, which can be changed while it runs. If it
+// has a file, the specs of its watches are saved there whenever they change.
+// The state of each watch, and the history of the counts it observes, are
+// saved in its store. State is saved after each notification is sent and when
+// the manager shuts down.
+type manager struct {
+	notifier *notifier
+	log      *zap.SugaredLogger
+	file     string
+	store    store
+
+	// backfill makes watches of repositories with no recorded history fetch
+	// the full star history into the store before they start.
+	backfill bool
+
+	// retention is how long history is kept in the store, which is compacted
+	// periodically until shutdown.
+	retention   retention
+	compactStop chan struct{}
+	compactDone chan struct{}
+
+	// heartbeat, if it is set, is how often each watch's gazer beats while
+	// it is alive, so that a hung watch can be noticed.
+	heartbeat time.Duration
+
+	// feed is the latest milestone and release events of the watches.
+	feed *feed
+
+	// keepAlive makes wait block even when there is nothing left to watch,
+	// so that watches can be added later through the API.
+	keepAlive bool
+
+	// accounts start watches for the repositories of GitHub organizations
+	// and users as they are created.
+	accounts []*accountWatcher
+
+	mu       sync.Mutex
+	watches  map[string]*watch
+	wg       sync.WaitGroup
+	stopping bool
+
+	// digestEvery is how often the leaderboard is sent, or 0 if it isn't.
+	digestEvery time.Duration
+	digestStop  chan struct{}
+	digestDone  chan struct{}
+
+	stateMu sync.Mutex
+	states  map[string]watchState
+}
+
+// velocityWindow is the gazers' default velocity window, which is also how
+// much history the dashboard shows.
+const velocityWindow = 24 * time.Hour
+
+var (
+	errWatchExists   = errors.New("repository is already being watched")
+	errWatchNotFound = errors.New("repository is not being watched")
+)
+
+// absoluteDeadline turns a deadline given as a duration from now into an RFC
+// 3339 time, so that it isn't extended when the watch is restarted. Deadlines
+// given as times are returned as they are.
+func absoluteDeadline(deadline string, now time.Time) (string, error) {
+	if deadline == "" {
+		return "", nil
+	}
+	if d, err := time.ParseDuration(deadline); err == nil {
+		return now.Add(d).Format(time.RFC3339), nil
+	}
+	if _, err := time.Parse(time.RFC3339, deadline); err != nil {
+		return "", errors.Errorf("invalid deadline %q: must be a duration or RFC 3339 time", deadline)
+	}
+	return deadline, nil
+}
+
+func watchKey(repo string) string {
+	return strings.ToLower(repo)
+}
+
+// load restores saved state and starts watches for the specs saved in the
+// manager's file, if it has one and it exists.
+func (m *manager) load() error {
+	m.notifier.notified = m.checkpoint
+	m.notifier.fired = m.saveState
+	if err := m.loadStates(); err != nil {
+		return err
+	}
+	m.startCompaction()
+	m.startDigest()
+	if m.file == "" {
+		return nil
+	}
+	specs, err := readWatches(m.file)
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		if err := m.put(spec); err != nil {
+			return errors.Wrapf(err, "error starting watch for %s", spec.Repo)
+		}
+	}
+	return nil
+}
+
+// readWatches reads the specs saved in a watches file. A file that doesn't
+// exist holds no specs.
+func readWatches(file string) ([]watchSpec, error) {
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading watches file")
+	}
+	var specs []watchSpec
+	if err := json.Unmarshal(b, &specs); err != nil {
+		return nil, errors.Wrap(err, "error decoding watches file")
+	}
+	return specs, nil
+}
+
+// save writes the specs of the current watches to the manager's file. The
+// caller must hold m.mu.
+func (m *manager) save() error {
+	if m.file == "" {
+		return nil
+	}
+	specs := []watchSpec{}
+	for _, w := range m.sorted() {
+		specs = append(specs, w.spec)
+	}
+	b, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return errors.Wrap(err, "error writing watches file")
+	}
+	return errors.Wrap(os.Rename(tmp, m.file), "error writing watches file")
+}
+
+// shutdown stops every watch and waits for them to finish what they were
+// doing, including sending any notifications, then saves the watches so they
+// resume on the next start. No watches can be started once shutdown has been
+// called.
+func (m *manager) shutdown() error {
+	for _, a := range m.accounts {
+		a.shutdown()
+	}
+	m.stopDigest()
+	m.mu.Lock()
+	m.stopping = true
+	for _, w := range m.watches {
+		w.gazer.Stop()
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, w := range m.watches {
+		m.checkpoint(w.gazer)
+	}
+	m.stopCompaction()
+	if err := m.store.Close(); err != nil {
+		m.log.Warnw("unable to close store", "err", err)
+	}
+	return m.save()
+}
+
+// create starts a new watch, failing if the repository is already watched.
+func (m *manager) create(spec watchSpec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.watches[watchKey(spec.Repo)]; ok {
+		return errWatchExists
+	}
+	return m.start(spec)
+}
+
+// update replaces the watch for repo with one created from spec.
+func (m *manager) update(repo string, spec watchSpec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old, ok := m.watches[watchKey(repo)]
+	if !ok {
+		return errWatchNotFound
+	}
+	spec.Repo = old.spec.Repo
+	return m.start(spec)
+}
+
+// put starts a watch for spec, replacing any existing watch of the same
+// repository.
+func (m *manager) put(spec watchSpec) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.start(spec)
+}
+
+// remove stops the watch for repo and forgets it.
+func (m *manager) remove(repo string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.watches[watchKey(repo)]
+	if !ok {
+		return errWatchNotFound
+	}
+	delete(m.watches, watchKey(repo))
+	w.gazer.Stop()
+	m.forgetState(repo)
+	return m.save()
+}
+
+// start creates a gazer for spec and runs it, stopping any gazer already
+// watching the same repository. The caller must hold m.mu.
+func (m *manager) start(spec watchSpec) error {
+	if m.stopping {
+		return errors.New("shutting down")
+	}
+	spec, err := resolveProvider(spec)
+	if err != nil {
+		return err
+	}
+	if spec.Deadline, err = absoluteDeadline(spec.Deadline, time.Now()); err != nil {
+		return err
+	}
+	var options []func(*stargazer.GitHubStargazer)
+	st, restored := m.savedState(spec.Repo)
+	if restored {
+		options = append(options, stargazer.WithState(st.Gazer))
+	}
+	w := &watch{spec: spec}
+	options = append(options, m.historyOptions(w, !restored)...)
+	if m.heartbeat > 0 {
+		w.beat.Store(time.Now().UnixNano())
+		options = append(options, stargazer.WithHeartbeat(m.heartbeat, func() {
+			w.beat.Store(time.Now().UnixNano())
+		}))
+	}
+	if w.gazer, err = m.notifier.newGazer(spec, options...); err != nil {
+		return err
+	}
+	if w.mentions, err = m.notifier.newMentionWatchers(spec); err != nil {
+		return err
+	}
+	if w.trending, err = m.notifier.newTrendingWatcher(spec); err != nil {
+		return err
+	}
+	if m.watches == nil {
+		m.watches = make(map[string]*watch)
+	}
+	key := watchKey(spec.Repo)
+	if old, ok := m.watches[key]; ok {
+		old.gazer.Stop()
+	}
+	m.watches[key] = w
+	m.wg.Add(1)
+	go m.run(key, w)
+	return m.save()
+}
+
+// historyOptions returns gazer options to record the counts observed for the
+// repo of w in the store, and when w last fetched one, and, if seed is true,
+// to seed the velocity window from the store.
+func (m *manager) historyOptions(w *watch, seed bool) []func(*stargazer.GitHubStargazer) {
+	repo := w.spec.Repo
+	options := []func(*stargazer.GitHubStargazer){
+		stargazer.WithSampleHook(func(s stargazer.Sample) error {
+			w.fetched.Store(s.Time.UnixNano())
+			return m.store.Record(repo, s)
+		}),
+	}
+	if !seed {
+		return options
+	}
+	now := time.Now()
+	samples, err := m.store.History(repo, now.Add(-velocityWindow), now)
+	if err == errNoHistory {
+		return options
+	}
+	if err != nil {
+		m.log.Warnw("unable to read history", "repo", repo, "err", err)
+		return options
+	}
+	return append(options, stargazer.WithHistory(samples...))
+}
+
+// backfillHistory records the star history of repo, fetched with client, in
+// the store, unless the store already has history for it.
+func (m *manager) backfillHistory(repo string, client stargazer.GitHubClient) {
+	existing, err := m.store.History(repo, time.Unix(0, 0), time.Now())
+	if err == errNoHistory || len(existing) > 0 {
+		return
+	}
+	if err != nil {
+		m.log.Warnw("unable to read history", "repo", repo, "err", err)
+		return
+	}
+	m.log.Infow("backfilling star history", "repo", repo)
+	samples, err := client.StarHistory()
+	if err != nil {
+		m.log.Warnw("unable to fetch complete star history", "repo", repo, "err", err)
+	}
+	if err := m.store.RecordAll(repo, samples); err != nil {
+		m.log.Warnw("unable to record star history", "repo", repo, "err", err)
+		return
+	}
+	m.log.Infow("backfilled star history", "repo", repo, "stars", len(samples))
+}
+
+// run gazes until the watch is stopped, then forgets it unless it has
+// already been replaced.
+func (m *manager) run(key string, w *watch) {
+	defer m.wg.Done()
+	// Only GitHub has the history of when each star was given.
+	if m.backfill && countsGitHubStars(w.spec) {
+		m.backfillHistory(w.gazer.Repository, w.gazer)
+	}
+	events := w.gazer.Events()
+	recorded := make(chan struct{})
+	go func() {
+		defer close(recorded)
+		for e := range events {
+			m.record(w, e)
+			if err := m.notifier.events.write(newEventRecord(e)); err != nil {
+				m.log.Warnw("unable to record event", "repo", w.spec.Repo, "err", err)
+			}
+		}
+	}()
+	var mentions sync.WaitGroup
+	for _, mw := range w.mentions {
+		mentions.Add(1)
+		go func(mw *stargazer.MentionWatcher) {
+			defer mentions.Done()
+			mw.Watch()
+		}(mw)
+	}
+	if w.trending != nil {
+		mentions.Add(1)
+		go func() {
+			defer mentions.Done()
+			w.trending.Watch()
+		}()
+	}
+	w.gazer.Gaze()
+	for _, mw := range w.mentions {
+		mw.Stop()
+	}
+	if w.trending != nil {
+		w.trending.Stop()
+	}
+	mentions.Wait()
+	<-recorded
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopping || m.watches[key] != w {
+		return
+	}
+	m.checkpoint(w.gazer)
+	delete(m.watches, key)
+	if err := m.save(); err != nil {
+		m.log.Warnw("unable to save watches", "err", err)
+	}
+}
+
+// get returns the watch for repo.
+func (m *manager) get(repo string) (*watch, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.watches[watchKey(repo)]
+	return w, ok
+}
+
+// list returns the current watches ordered by repository.
+func (m *manager) list() []*watch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sorted()
+}
+
+// sorted returns the current watches ordered by repository. The caller must
+// hold m.mu.
+func (m *manager) sorted() []*watch {
+	watches := make([]*watch, 0, len(m.watches))
+	for _, w := range m.watches {
+		watches = append(watches, w)
+	}
+	sort.Slice(watches, func(i, j int) bool {
+		return watchKey(watches[i].spec.Repo) < watchKey(watches[j].spec.Repo)
+	})
+	return watches
+}
+
+// ready reports whether every watch has fetched its count successfully.
+func (m *manager) ready() bool {
+	for _, w := range m.list() {
+		if !w.gazer.Ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchedSince reports whether every watch that isn't paused has fetched its
+// count since since.
+func (m *manager) fetchedSince(since time.Time) bool {
+	for _, w := range m.list() {
+		if !w.gazer.Paused() && w.fetched.Load() <= since.UnixNano() {
+			return false
+		}
+	}
+	return true
+}
+
+// alive reports whether every watch has beaten since since.
+func (m *manager) alive(since time.Time) bool {
+	for _, w := range m.list() {
+		if w.beat.Load() < since.UnixNano() {
+			return false
+		}
+	}
+	return true
+}
+
+// wait blocks until every watch has stopped, or forever if the manager is
+// being kept alive.
+func (m *manager) wait() {
+	if m.keepAlive {
+		select {}
+	}
+	m.wg.Wait()
+}
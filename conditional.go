@@ -0,0 +1,171 @@
+package stargazer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// CachedResponse is the body of a successful GitHub response along with the
+// validators needed to make a conditional request for it again.
+type CachedResponse struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+// maxCachedResponses is how many responses a conditionalCache keeps. A gazer
+// polls only a few endpoints, such as its repository and README, but those
+// change when the repository is renamed, and every response kept is saved
+// with its State.
+const maxCachedResponses = 16
+
+// conditionalCache remembers GitHub responses by URL so that repeated
+// requests can be made conditional. GitHub doesn't count 304 Not Modified
+// responses against the rate limit. It keeps at most maxCachedResponses,
+// evicting the least recently used. It is safe for concurrent use.
+type conditionalCache struct {
+	mu      sync.Mutex
+	entries map[string]CachedResponse
+
+	// used records when each entry was last got or put, counted in uses of
+	// the cache.
+	used  map[string]uint64
+	clock uint64
+}
+
+func newConditionalCache() *conditionalCache {
+	return &conditionalCache{
+		entries: make(map[string]CachedResponse),
+		used:    make(map[string]uint64),
+	}
+}
+
+func (cc *conditionalCache) get(endpoint string) (CachedResponse, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cr, ok := cc.entries[endpoint]
+	if ok {
+		cc.touch(endpoint)
+	}
+	return cr, ok
+}
+
+// snapshot returns a copy of the cached responses.
+func (cc *conditionalCache) snapshot() map[string]CachedResponse {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	entries := make(map[string]CachedResponse, len(cc.entries))
+	for k, v := range cc.entries {
+		entries[k] = v
+	}
+	return entries
+}
+
+func (cc *conditionalCache) put(endpoint string, cr CachedResponse) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.entries[endpoint] = cr
+	cc.touch(endpoint)
+	for len(cc.entries) > maxCachedResponses {
+		cc.evict()
+	}
+}
+
+// touch marks endpoint as just used. The caller must hold cc.mu.
+func (cc *conditionalCache) touch(endpoint string) {
+	cc.clock++
+	cc.used[endpoint] = cc.clock
+}
+
+// evict removes the least recently used entry. The caller must hold cc.mu.
+func (cc *conditionalCache) evict() {
+	var oldest string
+	for endpoint, used := range cc.used {
+		if oldest == "" || used < cc.used[oldest] {
+			oldest = endpoint
+		}
+	}
+	delete(cc.entries, oldest)
+	delete(cc.used, oldest)
+}
+
+// conditionalGet makes a GET request to a GitHub API endpoint, sending
+// If-None-Match and If-Modified-Since when a previous response is cached. The
+// body of the response is returned, or the cached body if GitHub reports that
+// it has not been modified.
+func (sg *GitHubStargazer) conditionalGet(endpoint, accept string) ([]byte, error) {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", accept)
+	cached, haveCached := sg.cache.get(endpoint)
+	if haveCached {
+		if cached.ETag != "" {
+			req.Header.Add("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Add("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := sg.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reaching GitHub API: %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	sg.updateRateLimit(resp)
+	if resp.StatusCode == http.StatusNotModified && haveCached {
+		sg.log.Debugw("GitHub response not modified", "url", endpoint)
+		return cached.Body, nil
+	}
+	body, err := sg.readBody(resp, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag != "" || lastModified != "" {
+		sg.cache.put(endpoint, CachedResponse{
+			ETag:         etag,
+			LastModified: lastModified,
+			Body:         body,
+		})
+	}
+	return body, nil
+}
+
+// get makes a GET request to a GitHub API endpoint and returns the body of
+// the response without caching it. It is for endpoints that are fetched once
+// rather than polled, such as the pages of stargazers.
+func (sg *GitHubStargazer) get(endpoint, accept string) ([]byte, error) {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", accept)
+	resp, err := sg.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reaching GitHub API: %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	sg.updateRateLimit(resp)
+	return sg.readBody(resp, endpoint)
+}
+
+// readBody returns the body of resp, a response from endpoint, or the error
+// that it reports.
+func (sg *GitHubStargazer) readBody(resp *http.Response, endpoint string) ([]byte, error) {
+	if rlErr := rateLimitErrorFromResponse(resp, sg.clock.Now()); rlErr != nil {
+		return nil, rlErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("GitHub", resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading GitHub API response: %s: %w", endpoint, err)
+	}
+	return body, nil
+}
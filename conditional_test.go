@@ -0,0 +1,189 @@
+package stargazer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConditionalGet(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// etag and lastModified are whether the API sends those validators,
+		// and changed whether the response changes between the requests.
+		etag         bool
+		lastModified bool
+		changed      bool
+
+		wantConditional bool
+		wantNotModified bool
+	}{
+		{
+			name:            "ETag",
+			etag:            true,
+			wantConditional: true,
+			wantNotModified: true,
+		},
+		{
+			name:            "Last-Modified",
+			lastModified:    true,
+			wantConditional: true,
+			wantNotModified: true,
+		},
+		{
+			name:            "ETag and Last-Modified",
+			etag:            true,
+			lastModified:    true,
+			wantConditional: true,
+			wantNotModified: true,
+		},
+		{
+			name:            "modified",
+			etag:            true,
+			lastModified:    true,
+			changed:         true,
+			wantConditional: true,
+		},
+		{
+			name: "no validators",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, conditional, notModified := 1, 0, 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				etag := fmt.Sprintf(`"v%d"`, version)
+				lastModified := time.Date(2024, 6, version, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+				inm, ims := r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since")
+				if inm != "" || ims != "" {
+					conditional++
+				}
+				if (inm != "" && inm == etag) || (inm == "" && ims != "" && ims == lastModified) {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if tt.etag {
+					w.Header().Set("ETag", etag)
+				}
+				if tt.lastModified {
+					w.Header().Set("Last-Modified", lastModified)
+				}
+				fmt.Fprintf(w, `{"stargazers_count": %d}`, version)
+			}))
+			defer srv.Close()
+			sg, err := NewGitHubStargazer("matryer/moq", 100, time.Minute, nil, WithGitHubBaseURL(srv.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			endpoint := srv.URL + "/repos/matryer/moq"
+
+			if _, err := sg.conditionalGet(endpoint, "application/json"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.changed {
+				version++
+			}
+			body, err := sg.conditionalGet(endpoint, "application/json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := fmt.Sprintf(`{"stargazers_count": %d}`, version); string(body) != want {
+				t.Errorf("got body %s, want %s", body, want)
+			}
+			if got := conditional == 1; got != tt.wantConditional {
+				t.Errorf("made %d conditional requests, want conditional: %v", conditional, tt.wantConditional)
+			}
+			if got := notModified == 1; got != tt.wantNotModified {
+				t.Errorf("got %d Not Modified responses, want Not Modified: %v", notModified, tt.wantNotModified)
+			}
+		})
+	}
+}
+
+func TestStarHistoryNotCached(t *testing.T) {
+	const stars = 150
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var body []map[string]time.Time
+		for i := (page - 1) * 100; i < stars && i < page*100; i++ {
+			body = append(body, map[string]time.Time{
+				"starred_at": time.Date(2024, 1, 1, 0, i, 0, 0, time.UTC),
+			})
+		}
+		w.Header().Set("ETag", fmt.Sprintf(`"page%d"`, page))
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer srv.Close()
+	sg, err := NewGitHubStargazer("matryer/moq", 200, time.Minute, nil, WithGitHubBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, err := sg.StarHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != stars {
+		t.Errorf("got %d samples, want %d", len(samples), stars)
+	}
+	for endpoint := range sg.State().Responses {
+		if strings.Contains(endpoint, "/stargazers") {
+			t.Errorf("page of stargazers %s cached", endpoint)
+		}
+	}
+}
+
+func TestConditionalCacheEvicts(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// got is the endpoint got from the full cache before one more is put.
+		got       string
+		wantKept  string
+		wantGone  string
+		wantCount int
+	}{
+		{
+			name:      "oldest evicted",
+			wantKept:  "/1",
+			wantGone:  "/0",
+			wantCount: maxCachedResponses,
+		},
+		{
+			name:      "recently got kept",
+			got:       "/0",
+			wantKept:  "/0",
+			wantGone:  "/1",
+			wantCount: maxCachedResponses,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := newConditionalCache()
+			for i := 0; i < maxCachedResponses; i++ {
+				cc.put(fmt.Sprintf("/%d", i), CachedResponse{ETag: strconv.Itoa(i)})
+			}
+			if tt.got != "" {
+				cc.get(tt.got)
+			}
+			cc.put("/new", CachedResponse{ETag: "new"})
+
+			if _, ok := cc.get(tt.wantKept); !ok {
+				t.Errorf("%s evicted, want it kept", tt.wantKept)
+			}
+			if _, ok := cc.get(tt.wantGone); ok {
+				t.Errorf("%s kept, want it evicted", tt.wantGone)
+			}
+			if got := len(cc.snapshot()); got != tt.wantCount {
+				t.Errorf("got %d cached responses, want %d", got, tt.wantCount)
+			}
+		})
+	}
+}
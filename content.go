@@ -0,0 +1,141 @@
+package stargazer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Parts of a repository whose changes WithContentWatch reports.
+const (
+	ContentDescription = "description"
+	ContentReadme      = "readme"
+)
+
+// ContentChange describes a change to the description or README of a
+// repository.
+type ContentChange struct {
+	Repository string
+	Time       time.Time
+
+	// Part is what changed: ContentDescription or ContentReadme.
+	Part string
+
+	// Description and PreviousDescription are the new and old description,
+	// if that is what changed.
+	Description         string
+	PreviousDescription string
+
+	// URL is where the README can be read, if that is what changed. It is
+	// empty if the README was removed.
+	URL string
+}
+
+// WithContentWatch is an option that can be passed to NewGitHubStargazer to
+// have hook run when the description or README of the repository changes,
+// for keeping an eye on projects depended on. They are compared by hash
+// after each successful poll, which costs a conditional request for the
+// README. Nothing is reported for the first poll, which only records what
+// they are.
+func WithContentWatch(hook func(ContentChange) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.ContentHook = hook
+	}
+}
+
+// checkContent runs the content hook for each part of the repository whose
+// hash differs from the one last recorded for it, recording the new ones.
+func (sg *GitHubStargazer) checkContent() {
+	if sg.ContentHook == nil || !sg.watchesRepository() {
+		return
+	}
+	hashes := make(map[string]string)
+	var description, readmeURL string
+	sg.mu.Lock()
+	if sg.metadata != nil {
+		description = sg.metadata.Description
+		sum := sha256.Sum256([]byte(description))
+		hashes[ContentDescription] = hex.EncodeToString(sum[:])
+	}
+	sg.mu.Unlock()
+	sha, url, err := sg.fetchReadme()
+	if err != nil {
+		sg.log.Warnw("unable to fetch README", "repo", sg.Repository, "err", err)
+	} else {
+		hashes[ContentReadme] = sha
+		readmeURL = url
+	}
+
+	sg.mu.Lock()
+	if sg.contentHashes == nil {
+		sg.contentHashes = make(map[string]string)
+	}
+	var changed []string
+	for _, part := range []string{ContentDescription, ContentReadme} {
+		hash, ok := hashes[part]
+		if !ok {
+			continue
+		}
+		previous, seen := sg.contentHashes[part]
+		if seen && previous != hash {
+			changed = append(changed, part)
+		}
+		sg.contentHashes[part] = hash
+	}
+	previousDescription := sg.lastDescription
+	if _, ok := hashes[ContentDescription]; ok {
+		sg.lastDescription = description
+	}
+	sg.mu.Unlock()
+	if len(changed) == 0 {
+		return
+	}
+	sg.saveState()
+	for _, part := range changed {
+		c := ContentChange{
+			Repository: sg.Repository,
+			Time:       sg.clock.Now(),
+			Part:       part,
+		}
+		if part == ContentDescription {
+			c.Description = description
+			c.PreviousDescription = previousDescription
+		} else {
+			c.URL = readmeURL
+		}
+		sg.log.Infow("repository content changed", "repo", sg.Repository, "part", part)
+		sg.emit(ContentChanged{c})
+		err := sg.ContentHook(c)
+		if err != nil {
+			sg.log.Infow("error calling content hook function",
+				"repo", sg.Repository,
+				"err", err)
+		}
+		sg.hookDone("content", err)
+	}
+}
+
+// fetchReadme fetches the blob SHA of the repository's README, which changes
+// whenever its content does, and the URL it can be read at. Both are empty
+// if the repository has no README.
+func (sg *GitHubStargazer) fetchReadme() (sha, url string, err error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/readme", sg.apiBaseURL, sg.CurrentName())
+	body, err := sg.conditionalGet(endpoint, "application/vnd.github+json")
+	if errors.Is(err, ErrNotFound) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	var readme struct {
+		SHA     string `json:"sha"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(body, &readme); err != nil {
+		return "", "", fmt.Errorf("error decoding GitHub JSON response: %w", err)
+	}
+	return readme.SHA, readme.HTMLURL, nil
+}
@@ -0,0 +1,131 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CratesSource is a Source of the number of downloads or reverse
+// dependencies of a crate on crates.io.
+type CratesSource struct {
+	// Crate is the name of the crate.
+	Crate string
+
+	// Count is what is counted: Downloads unless set with WithCratesCount,
+	// RecentDownloads or ReverseDependencies.
+	Count Count
+
+	baseURL string
+	client  *http.Client
+	editors []RequestEditor
+}
+
+// NewCratesSource returns a Source of the number of downloads of crate,
+// unless another count is set with WithCratesCount.
+func NewCratesSource(crate string, options ...func(*CratesSource)) (*CratesSource, error) {
+	if crate == "" {
+		return nil, errors.New("crate must be specified")
+	}
+	cs := &CratesSource{
+		Crate:   crate,
+		Count:   Downloads,
+		baseURL: "https://crates.io",
+		client:  &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, o := range options {
+		o(cs)
+	}
+	switch cs.Count {
+	case Downloads, RecentDownloads, ReverseDependencies:
+	default:
+		return nil, errors.Errorf("crates.io cannot count %s", cs.Count)
+	}
+	if _, err := url.Parse(cs.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid crates.io base URL")
+	}
+	return cs, nil
+}
+
+// WithCratesBaseURL is an option that can be passed to NewCratesSource to
+// use another base URL for the crates.io API than https://crates.io.
+func WithCratesBaseURL(baseURL string) func(*CratesSource) {
+	return func(cs *CratesSource) {
+		cs.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithCratesHTTPClient is an option that can be passed to NewCratesSource to
+// make its requests with client instead of a default client with a 20 second
+// timeout.
+func WithCratesHTTPClient(client *http.Client) func(*CratesSource) {
+	return func(cs *CratesSource) {
+		if client != nil {
+			cs.client = client
+		}
+	}
+}
+
+// WithCratesRequestEditor is an option that can be passed to NewCratesSource
+// to have editors edit every request it makes, in order, after its User-Agent
+// is set.
+func WithCratesRequestEditor(editors ...RequestEditor) func(*CratesSource) {
+	return func(cs *CratesSource) {
+		cs.editors = append(cs.editors, editors...)
+	}
+}
+
+// WithCratesCount is an option that can be passed to NewCratesSource to
+// count RecentDownloads or ReverseDependencies instead of Downloads.
+func WithCratesCount(count Count) func(*CratesSource) {
+	return func(cs *CratesSource) {
+		cs.Count = count
+	}
+}
+
+// Fetch fetches the count of the crate.
+func (cs *CratesSource) Fetch() (int, error) {
+	if cs.Count == ReverseDependencies {
+		// The total is reported with each page of reverse dependencies, so
+		// only the smallest page is fetched.
+		var page struct {
+			Meta struct {
+				Total int `json:"total"`
+			} `json:"meta"`
+		}
+		if err := cs.get("reverse_dependencies?per_page=1", &page); err != nil {
+			return -1, err
+		}
+		return page.Meta.Total, nil
+	}
+	var crate struct {
+		Crate struct {
+			Downloads       int `json:"downloads"`
+			RecentDownloads int `json:"recent_downloads"`
+		} `json:"crate"`
+	}
+	if err := cs.get("", &crate); err != nil {
+		return -1, err
+	}
+	if cs.Count == RecentDownloads {
+		return crate.Crate.RecentDownloads, nil
+	}
+	return crate.Crate.Downloads, nil
+}
+
+// get fetches path under the crate's API endpoint into v.
+func (cs *CratesSource) get(path string, v interface{}) error {
+	endpoint := fmt.Sprintf("%s/api/v1/crates/%s", cs.baseURL, url.PathEscape(cs.Crate))
+	if path != "" {
+		endpoint += "/" + path
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return getJSON(cs.client, cs.editors, req, "crates.io", v)
+}
@@ -0,0 +1,123 @@
+package stargazer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Errors that the errors returned by calls to GitHub, Twilio and the other
+// APIs can be matched against with errors.Is, to tell conditions that are
+// worth retrying from those that aren't.
+var (
+	// ErrNotFound means that the repository, package or other resource
+	// doesn't exist, or can't be seen with the credentials used.
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized means that credentials were missing or wrong, or
+	// lack the permission needed.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrRateLimited means that a rate limit was exceeded. The error is a
+	// *RateLimitError, saying when to retry, if GitHub said so.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrRepoMoved means that the repository has been renamed or
+	// transferred. The error is a *RepoMovedError.
+	ErrRepoMoved = errors.New("repository moved")
+
+	// ErrInvalidRepo means that a repository was given in a malformed
+	// owner/name or URL, as reported by ParseRepo.
+	ErrInvalidRepo = errors.New("invalid repository")
+)
+
+// IsRetriable reports whether the request that failed with err might succeed
+// if it is made again, as it might after a rate limit resets or a network
+// failure, but not if the repository doesn't exist, the credentials are
+// wrong or the repository has moved.
+func IsRetriable(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrNotFound) &&
+		!errors.Is(err, ErrUnauthorized) &&
+		!errors.Is(err, ErrRepoMoved)
+}
+
+// APIError is an error response from an API. It matches ErrNotFound,
+// ErrUnauthorized or ErrRateLimited with errors.Is, according to its status.
+type APIError struct {
+	// API names the API, such as "GitHub" or "Twilio".
+	API string
+
+	// Status and StatusCode are the status of the response.
+	Status     string
+	StatusCode int
+
+	// Code is the API's own error code, such as Twilio's, if it gave one,
+	// and Message its description of the error.
+	Code    int
+	Message string
+
+	URL string
+}
+
+func (e *APIError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("%s error %d: %s", e.API, e.Code, e.Message)
+	}
+	if e.Message != "" && e.Message != http.StatusText(e.StatusCode) {
+		return fmt.Sprintf("error during %s API call: %s: %s (url: %s)", e.API, e.Status, e.Message, e.URL)
+	}
+	return fmt.Sprintf("error during %s API call: %s (url: %s)", e.API, e.Status, e.URL)
+}
+
+// Is reports whether the status of the response means target.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// newAPIError returns the error for resp, an error response from api, with
+// the message from its JSON body if it has one.
+func newAPIError(api string, resp *http.Response) *APIError {
+	e := &APIError{
+		API:        api,
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		URL:        resp.Request.URL.String(),
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if b, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10)); err == nil && json.Unmarshal(b, &body) == nil {
+		e.Message = body.Message
+	}
+	return e
+}
+
+// RepoMovedError is returned when a repository has been renamed or
+// transferred, so that requests for it are redirected. It matches
+// ErrRepoMoved with errors.Is.
+type RepoMovedError struct {
+	Repository string
+
+	// Location is where requests for the repository are redirected to.
+	Location string
+}
+
+func (e *RepoMovedError) Error() string {
+	return fmt.Sprintf("repository %s has moved to %s", e.Repository, e.Location)
+}
+
+// Is reports whether target is ErrRepoMoved.
+func (e *RepoMovedError) Is(target error) bool {
+	return target == ErrRepoMoved
+}
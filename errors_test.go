@@ -0,0 +1,112 @@
+package stargazer_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/ianfoo/github-stargazer/githubtest"
+)
+
+func TestTypedErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		call          func(srv *githubtest.Server) error
+		want          error
+		wantRetriable bool
+	}{
+		{
+			name: "repository not found",
+			call: func(srv *githubtest.Server) error {
+				sg, err := stargazer.NewGitHubStargazer("ghost/missing", 100, time.Minute, nil,
+					stargazer.WithGitHubBaseURL(srv.URL))
+				if err != nil {
+					return err
+				}
+				return sg.Prime()
+			},
+			want: stargazer.ErrNotFound,
+		},
+		{
+			name: "bad credentials",
+			call: func(srv *githubtest.Server) error {
+				srv.RequireToken("secret")
+				sg, err := stargazer.NewGitHubStargazer("matryer/moq", 100, time.Minute, nil,
+					stargazer.WithGitHubBaseURL(srv.URL),
+					stargazer.WithGitHubToken("wrong"))
+				if err != nil {
+					return err
+				}
+				return sg.Prime()
+			},
+			want: stargazer.ErrUnauthorized,
+		},
+		{
+			name: "rate limited",
+			call: func(srv *githubtest.Server) error {
+				srv.SetRateLimit(60, 0, time.Now().Add(time.Hour))
+				sg, err := stargazer.NewGitHubStargazer("matryer/moq", 100, time.Minute, nil,
+					stargazer.WithGitHubBaseURL(srv.URL))
+				if err != nil {
+					return err
+				}
+				return sg.Prime()
+			},
+			want:          stargazer.ErrRateLimited,
+			wantRetriable: true,
+		},
+		{
+			name: "organization listing",
+			call: func(srv *githubtest.Server) error {
+				org, err := stargazer.NewGitHubOrg("ghost", stargazer.WithOrgBaseURL(srv.URL))
+				if err != nil {
+					return err
+				}
+				_, err = org.Repositories()
+				return err
+			},
+			want: stargazer.ErrNotFound,
+		},
+		{
+			name: "user listing",
+			call: func(srv *githubtest.Server) error {
+				user, err := stargazer.NewGitHubUser("ghost", stargazer.WithUserBaseURL(srv.URL))
+				if err != nil {
+					return err
+				}
+				_, err = user.Repositories()
+				return err
+			},
+			want: stargazer.ErrNotFound,
+		},
+		{
+			name: "mentions from every source",
+			call: func(srv *githubtest.Server) error {
+				lobsters, err := stargazer.NewLobstersSource("github.com/matryer/moq",
+					stargazer.WithLobstersBaseURL(srv.URL))
+				if err != nil {
+					return err
+				}
+				_, _, err = stargazer.SpikeSource(time.Time{}, lobsters)
+				return err
+			},
+			want: stargazer.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := githubtest.NewServer()
+			defer srv.Close()
+			srv.SetStargazers("matryer/moq", 10)
+
+			err := tt.call(srv)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want one matching %v", err, tt.want)
+			}
+			if got := stargazer.IsRetriable(err); got != tt.wantRetriable {
+				t.Errorf("IsRetriable(%v) = %v, want %v", err, got, tt.wantRetriable)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package stargazer
+
+import (
+	"sync"
+	"time"
+)
+
+// eventBuffer is how many events the channel returned by Events holds. Events
+// are dropped rather than blocking the gazer when it is full.
+const eventBuffer = 64
+
+// Event is something that happened to a gazer, sent on the channel returned
+// by Events. It is one of CountChanged, ThresholdCrossed, FetchFailed,
+// Degraded, Recovered, Stalled, Renamed, Retired, LicenseChanged,
+// ContentChanged, Paused, Resumed or Stopped.
+type Event interface {
+	isEvent()
+}
+
+// CountChanged is sent when the stargazers count changes.
+type CountChanged struct {
+	Change
+}
+
+// ThresholdCrossed is sent when a target or milestone is reached.
+type ThresholdCrossed struct {
+	Milestone
+}
+
+// FetchFailed is sent when fetching the stargazers count fails.
+type FetchFailed struct {
+	Failure
+}
+
+// Degraded is sent when the gazer's circuit breaker opens, suspending
+// polling, after the fetch that failed one time too many.
+type Degraded struct {
+	Failure
+	Time time.Time
+}
+
+// Recovered is sent when the gazer's circuit breaker closes again after a
+// fetch succeeds. Down is how long the circuit was open or half-open.
+type Recovered struct {
+	Repository string
+	Time       time.Time
+	Down       time.Duration
+}
+
+// Stalled is sent when the stargazers count hasn't been fetched for longer
+// than the gazer's watchdog allows.
+type Stalled struct {
+	Stall
+}
+
+// Renamed is sent when the repository turns out to have been renamed or
+// transferred.
+type Renamed struct {
+	Rename
+}
+
+// Retired is sent when the repository is found to have been archived or
+// deleted, before the gazer pauses.
+type Retired struct {
+	Retirement
+}
+
+// LicenseChanged is sent when the license of the repository changes.
+type LicenseChanged struct {
+	LicenseChange
+}
+
+// ContentChanged is sent when the description or README of the repository
+// changes, if they are watched with WithContentWatch.
+type ContentChanged struct {
+	ContentChange
+}
+
+// Paused is sent when the gazer is paused.
+type Paused struct {
+	Repository string
+	Time       time.Time
+}
+
+// Resumed is sent when the gazer is resumed.
+type Resumed struct {
+	Repository string
+	Time       time.Time
+}
+
+// Stopped is sent when the gazer stops gazing. It is the last event sent,
+// and the channel is closed after it.
+type Stopped struct {
+	Repository string
+	Time       time.Time
+}
+
+func (CountChanged) isEvent()     {}
+func (ThresholdCrossed) isEvent() {}
+func (FetchFailed) isEvent()      {}
+func (Degraded) isEvent()         {}
+func (Recovered) isEvent()        {}
+func (Stalled) isEvent()          {}
+func (Renamed) isEvent()          {}
+func (Retired) isEvent()          {}
+func (LicenseChanged) isEvent()   {}
+func (ContentChanged) isEvent()   {}
+func (Paused) isEvent()           {}
+func (Resumed) isEvent()          {}
+func (Stopped) isEvent()          {}
+
+// eventStream is the channel of events for a gazer, created when Events is
+// first called. It is safe for concurrent use.
+type eventStream struct {
+	mu     sync.Mutex
+	ch     chan Event
+	closed bool
+}
+
+func (es *eventStream) channel() <-chan Event {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.ch == nil {
+		es.ch = make(chan Event, eventBuffer)
+		if es.closed {
+			close(es.ch)
+		}
+	}
+	return es.ch
+}
+
+// emit sends e if anyone has asked for events and there is room for it.
+func (es *eventStream) emit(e Event) bool {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.ch == nil || es.closed {
+		return true
+	}
+	select {
+	case es.ch <- e:
+		return true
+	default:
+		return false
+	}
+}
+
+func (es *eventStream) close() {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if es.closed {
+		return
+	}
+	es.closed = true
+	if es.ch != nil {
+		close(es.ch)
+	}
+}
+
+// Events returns a channel on which the gazer sends an Event for everything
+// that happens to it, until it stops gazing. Every call returns the same
+// channel. Events are dropped if the channel is full, so it should be
+// drained promptly.
+func (sg *GitHubStargazer) Events() <-chan Event {
+	return sg.events.channel()
+}
+
+func (sg *GitHubStargazer) emit(e Event) {
+	if !sg.events.emit(e) {
+		sg.log.Warnw("event channel full; dropping event", "repo", sg.Repository)
+	}
+}
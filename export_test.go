@@ -0,0 +1,7 @@
+package stargazer
+
+// Poll polls once, as Gaze does, so that tests outside the package can
+// drive a gazer one poll at a time.
+func (sg *GitHubStargazer) Poll() {
+	sg.poll()
+}
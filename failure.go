@@ -0,0 +1,55 @@
+package stargazer
+
+// Failure describes a failed fetch of the stargazers count, or a failed run
+// of a notification hook.
+type Failure struct {
+	Repository string
+
+	// Op is what failed: "fetch", or the hook that was run: "milestone",
+	// "progress", "velocity", "deadline", "stall", "rival", "report",
+	// "rename", "retire", "license" or "content".
+	Op  string
+	Err error
+
+	// ConsecutiveFailures is how many fetches, or how many hook runs,
+	// including this one have failed in a row.
+	ConsecutiveFailures int
+}
+
+// WithErrorHook is an option that can be passed to NewGitHubStargazer to have
+// hook called whenever fetching the stargazers count or running a
+// notification hook fails.
+func WithErrorHook(hook func(Failure) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.ErrorHook = hook
+	}
+}
+
+// hookDone records the outcome of running a notification hook, running the
+// error hook if it failed.
+func (sg *GitHubStargazer) hookDone(op string, err error) {
+	if err == nil {
+		sg.hookFailures = 0
+		return
+	}
+	sg.hookFailures++
+	sg.fireError(op, err, sg.hookFailures)
+}
+
+// fireError runs the error hook, if there is one.
+func (sg *GitHubStargazer) fireError(op string, err error, consecutive int) {
+	if sg.ErrorHook == nil {
+		return
+	}
+	f := Failure{
+		Repository:          sg.Repository,
+		Op:                  op,
+		Err:                 err,
+		ConsecutiveFailures: consecutive,
+	}
+	if err := sg.ErrorHook(f); err != nil {
+		sg.log.Infow("error calling error hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+}
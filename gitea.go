@@ -0,0 +1,137 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GiteaSource is a Source of the number of stars, forks or releases of a
+// repository on Codeberg or a self-hosted Gitea or Forgejo instance.
+type GiteaSource struct {
+	// Repository is the name of the repository in owner/repo format.
+	Repository string
+
+	// Count is what is counted. It is Stars unless set with WithGiteaCount.
+	Count Count
+
+	baseURL string
+	token   TokenSource
+	client  *http.Client
+	editors []RequestEditor
+}
+
+// NewGiteaSource returns a Source of the number of stars of repo on
+// Codeberg, unless another instance is set with WithGiteaBaseURL or another
+// count with WithGiteaCount.
+func NewGiteaSource(repo string, options ...func(*GiteaSource)) (*GiteaSource, error) {
+	if repo == "" {
+		return nil, errors.New("repository must be specified")
+	}
+	gs := &GiteaSource{
+		Repository: strings.Trim(repo, "/"),
+		Count:      Stars,
+		baseURL:    "https://codeberg.org",
+		client:     &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, o := range options {
+		o(gs)
+	}
+	switch gs.Count {
+	case Stars, Forks, Releases:
+	default:
+		return nil, errors.Errorf("Gitea cannot count %s", gs.Count)
+	}
+	if _, err := url.Parse(gs.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid Gitea base URL")
+	}
+	return gs, nil
+}
+
+// WithGiteaBaseURL is an option that can be passed to NewGiteaSource to
+// fetch from a self-hosted instance, such as https://gitea.example.com.
+func WithGiteaBaseURL(baseURL string) func(*GiteaSource) {
+	return func(gs *GiteaSource) {
+		gs.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithGiteaHTTPClient is an option that can be passed to NewGiteaSource to
+// make its requests with client instead of a default client with a 20 second
+// timeout.
+func WithGiteaHTTPClient(client *http.Client) func(*GiteaSource) {
+	return func(gs *GiteaSource) {
+		if client != nil {
+			gs.client = client
+		}
+	}
+}
+
+// WithGiteaRequestEditor is an option that can be passed to NewGiteaSource to
+// have editors edit every request it makes, in order, after its User-Agent is
+// set.
+func WithGiteaRequestEditor(editors ...RequestEditor) func(*GiteaSource) {
+	return func(gs *GiteaSource) {
+		gs.editors = append(gs.editors, editors...)
+	}
+}
+
+// WithGiteaCount is an option that can be passed to NewGiteaSource to count
+// Forks or Releases instead of Stars.
+func WithGiteaCount(count Count) func(*GiteaSource) {
+	return func(gs *GiteaSource) {
+		gs.Count = count
+	}
+}
+
+// WithGiteaToken is an option that can be passed to NewGiteaSource to
+// authenticate with an access token, which private repositories require.
+func WithGiteaToken(token string) func(*GiteaSource) {
+	return WithGiteaTokenSource(StaticTokenSource(token))
+}
+
+// WithGiteaTokenSource is an option that can be passed to NewGiteaSource to
+// supply the access token from source each time it is needed.
+func WithGiteaTokenSource(source TokenSource) func(*GiteaSource) {
+	return func(gs *GiteaSource) {
+		gs.token = source
+	}
+}
+
+// Fetch fetches the count of the repository.
+func (gs *GiteaSource) Fetch() (int, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/repos/%s", gs.baseURL, gs.Repository)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return -1, err
+	}
+	if gs.token != nil {
+		token, err := gs.token()
+		if err != nil {
+			return -1, errors.Wrap(err, "error getting Gitea token")
+		}
+		if token != "" {
+			req.Header.Add("Authorization", "token "+token)
+		}
+	}
+	var repo struct {
+		StarsCount     int `json:"stars_count"`
+		ForksCount     int `json:"forks_count"`
+		ReleaseCounter int `json:"release_counter"`
+	}
+	if err := getJSON(gs.client, gs.editors, req, "Gitea", &repo); err != nil {
+		return -1, err
+	}
+	switch gs.Count {
+	case Forks:
+		return repo.ForksCount, nil
+	case Releases:
+		return repo.ReleaseCounter, nil
+	default:
+		return repo.StarsCount, nil
+	}
+}
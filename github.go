@@ -1,51 +1,271 @@
 package stargazer
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
-	"go.uber.org/zap"
 )
 
 // GitHubStargazer watches a GitHub repo for a configured number of
 // stargazers and calls a function when this target is reached.
+//
+// Gaze runs hooks on its own goroutine. The methods that report on the gazer,
+// such as StargazersCount, Targets, State and Paused, and Pause, Resume, Stop,
+// AddHook, RemoveHook and Fetch may be called from any goroutine while it
+// gazes. Its exported fields must not be changed once Gaze has been called.
 type GitHubStargazer struct {
 	// Repository is the name of the respository to watch in owner/repo format.
 	Repository string
 
-	// StargazersTarget is the number of stargazers at which the TargetHitHook
-	// should be invoked.
+	// StargazersTarget is the number of stargazers at which the milestone
+	// hooks should be run. It changes when relative targets are resolved, so
+	// it should be read with Targets outside of hooks while the gazer gazes.
 	StargazersTarget int
 
-	// Interval is how often the stargazer count will be checked.
+	// Milestones are additional stargazer counts at which the milestone
+	// hooks should be run. Milestones larger than
+	// StargazersTarget extend the watch past it. Like StargazersTarget, they
+	// should be read with Targets outside of hooks.
+	Milestones []int
+
+	// Interval is how often the stargazer count will be checked, unless a
+	// schedule has been set with WithSchedule.
 	Interval time.Duration
 
-	// ThresholdCrossedHook gets run when the target number of stargazers is reached,
-	// or immediately if the actual number exceeds the target upon first check.
-	ThresholdCrossedHook func() error
+	// ProgressHook gets run when the stargazers count reaches one of the
+	// configured progress checkpoints on the way to StargazersTarget.
+	ProgressHook func(Progress) error
+
+	// SampleHook gets run with every stargazers count that is fetched, so
+	// that the history of counts can be recorded.
+	SampleHook func(Sample) error
+
+	// ChangeHook gets run whenever a fetched stargazers count differs from
+	// the previous one, so that changes can be streamed elsewhere.
+	ChangeHook func(Change) error
+
+	// VelocityAlertHook gets run when the star velocity rises above the
+	// configured alert rate. It will not be run again until the velocity has
+	// dropped back below the alert rate.
+	VelocityAlertHook func(Velocity) error
+
+	// ErrorHook gets run when fetching the stargazers count or running a
+	// notification hook fails.
+	ErrorHook func(Failure) error
+
+	// DeadlineHook gets run if the deadline set with WithDeadline passes
+	// before every target and milestone has been reached. The gazer stops
+	// after running it.
+	DeadlineHook func(Deadline) error
+
+	// StateHook gets run with the gazer's state whenever it marks a target,
+	// milestone, progress checkpoint, velocity alert, rivalry or deadline as
+	// fired, before the hooks for it run. See WithStateHook.
+	StateHook func(State) error
+
+	// StallHook gets run when the stargazers count hasn't been fetched for
+	// longer than the watchdog set with WithWatchdog allows.
+	StallHook func(Stall) error
+
+	// RivalHook gets run when the repository overtakes the rival set with
+	// WithRival, is overtaken by it, or comes within its gap of it.
+	RivalHook func(Rivalry) error
+
+	// ReportHook gets run with a report of the stargazers count at the
+	// times given by the schedule set with WithReport.
+	ReportHook func(Report) error
+
+	// RenameHook gets run when the repository turns out to have been
+	// renamed or transferred. See WithRenameHook.
+	RenameHook func(Rename) error
+
+	// RetireHook gets run when the repository is found to have been
+	// archived or deleted. See WithRetireHook.
+	RetireHook func(Retirement) error
+
+	// ContentHook gets run when the description or README of the
+	// repository changes. See WithContentWatch.
+	ContentHook func(ContentChange) error
+
+	// LicenseHook gets run when the license of the repository changes. See
+	// WithLicenseHook.
+	LicenseHook func(LicenseChange) error
+
+	// mu guards the state below that changes while the gazer gazes. It is
+	// held to change that state and to read it from other goroutines than
+	// the one gazing, which may read what only it changes without it. It is
+	// never held while running hooks, which may call back into the gazer.
+	mu sync.Mutex
 
 	stargazersCount int
+	targets         []int
+	fired           map[int]bool
+	relative        bool
+	resolved        bool
+	baseline        int
+	restored        *State
 
+	checkpoints   []int
+	progressFired map[int]bool
+
+	velocity          *velocityTracker
+	velocityAlertRate float64
+	velocityAlerted   bool
+	missedDeadline    time.Time
+
+	source     Source
+	count      Count
+	batch      *GraphQLBatch
 	apiBaseURL string
 	client     *http.Client
-	token      string
-	etag       string
+	editors    []RequestEditor
+	clock      Clock
+	token      TokenSource
+	cache      *conditionalCache
+	rateLimit  RateLimit
+	retryAt    time.Time
+	throttled  bool
+	metadata   *RepoMetadata
+	repoID     int64
+	renamedTo  string
+	rename     *Rename
+	retired    bool
+	license    *string
+
+	// batched is the name the repository is fetched by with batch while the
+	// gazer gazes, which follows it when it is renamed or transferred.
+	batched string
+
+	// contentHashes are the hashes of the parts of the repository watched
+	// by WithContentWatch, by part, and lastDescription the description
+	// the latest hash is of.
+	contentHashes   map[string]string
+	lastDescription string
+
+	// referrersEvery is how often the top referrers are fetched, if they
+	// are, referrers those fetched at referrersAt, and referrersNext when
+	// they are next due.
+	referrersEvery time.Duration
+	referrers      []Referrer
+	referrersAt    time.Time
+	referrersNext  time.Time
+
+	deadline    time.Time
+	thresholds  []intervalThreshold
+	schedule    Schedule
+	jitter      time.Duration
+	adaptiveMin time.Duration
+	adaptiveMax time.Duration
+
+	lastSuccess  time.Time
+	lastErr      error
+	failures     int
+	skipReached  bool
+	hookFailures int
+	paused       bool
+
+	heartbeatInterval time.Duration
+	heartbeatHook     func()
 
-	log    *zap.SugaredLogger
-	stopCh chan struct{}
+	// reportSchedule is when the report hook runs.
+	reportSchedule Schedule
+
+	// watchdogAfter is how long the watchdog allows without a successful
+	// fetch, counted from watchdogFrom if that is later than the last one,
+	// and stalledAt is when it last found the gazer stalled.
+	watchdogAfter time.Duration
+	watchdogFrom  time.Time
+	stalled       bool
+	stalledAt     time.Time
+
+	// rival is the source of the count the gazer's count is compared with,
+	// if it is set. rivalAhead is whether the rival was ahead at the last
+	// comparison, or nil before the first, and rivalGapAlerted whether the
+	// rival hook has run for the gap since the counts were last further
+	// apart.
+	rival           Source
+	rivalName       string
+	rivalGap        int
+	rivalAhead      *bool
+	rivalGapAlerted bool
+
+	// breakerFailures is how many fetches in a row must fail for the
+	// circuit breaker to open, or 0 if there is none, and breakerCooldown
+	// how long it stays open.
+	breakerFailures int
+	breakerCooldown time.Duration
+	circuit         Circuit
+
+	// scheduler paces the gazer's polls, if it is set, and scheduled is the
+	// gazer's place in its schedule while it gazes.
+	scheduler *Scheduler
+	scheduled *scheduled
+
+	hooks     *hookSet
+	hookRetry HookRetry
+	rearmed   []rearmedHook
+	events    *eventStream
+	log       Logger
+	metrics   Metrics
+	stopCh    chan struct{}
+}
+
+// Milestone describes a stargazer target that has been reached.
+type Milestone struct {
+	Repository      string
+	Target          int
+	StargazersCount int
+
+	// Final is true when Target is the largest of the gazer's targets and
+	// milestones, meaning there is nothing left to watch for.
+	Final bool
+}
+
+// Change describes a change in the stargazers count between two polls.
+type Change struct {
+	Repository      string
+	Time            time.Time
+	Previous        int
+	StargazersCount int
+}
+
+// Deadline describes a watch that ran out of time before reaching all of its
+// targets. Target is the next target that was not reached.
+type Deadline struct {
+	Repository      string
+	Deadline        time.Time
+	Target          int
+	StargazersCount int
+}
+
+// Progress describes a checkpoint reached on the way to the stargazers
+// target.
+type Progress struct {
+	Repository      string
+	Percent         int
+	Target          int
+	StargazersCount int
 }
 
 // NewGitHubStargazer returns a new gazer to watch the number of subscribers a
-// GitHub repo has, and execute hook when target is crossed.
+// GitHub repo has, and execute hook when target is crossed. hook may be nil,
+// and further hooks can be registered with AddHook. The repo is parsed with
+// ParseRepo, so it may be given as a URL, and a malformed one is an error
+// matching ErrInvalidRepo.
 func NewGitHubStargazer(
 	repo string,
 	target int,
 	interval time.Duration,
-	hook func() error,
+	hook func(Milestone) error,
 	options ...func(*GitHubStargazer)) (*GitHubStargazer, error) {
 
 	if repo == "" {
@@ -56,32 +276,111 @@ func NewGitHubStargazer(
 	}
 	const githubAPIBaseURL = "https://api.github.com"
 	sg := &GitHubStargazer{
-		Repository:           repo,
-		StargazersTarget:     target,
-		Interval:             interval,
-		ThresholdCrossedHook: hook,
-		client:               &http.Client{Timeout: 20 * time.Second},
-		apiBaseURL:           githubAPIBaseURL,
-		log:                  zap.NewNop().Sugar(),
+		Repository:       repo,
+		StargazersTarget: target,
+		Interval:         interval,
+		fired:            make(map[int]bool),
+		progressFired:    make(map[int]bool),
+		client:           &http.Client{Timeout: 20 * time.Second},
+		clock:            realClock{},
+		apiBaseURL:       githubAPIBaseURL,
+		cache:            newConditionalCache(),
+		stopCh:           make(chan struct{}, 1),
+		log:              nopLogger{},
+		metrics:          nopMetrics{},
+		hooks:            &hookSet{},
+		events:           &eventStream{},
+		velocity:         newVelocityTracker(24 * time.Hour),
+	}
+	if hook != nil {
+		sg.hooks.add(hook)
 	}
 	for _, o := range options {
 		o(sg)
 	}
+	for _, m := range sg.Milestones {
+		if m < 1 {
+			return nil, errors.New("milestones must be at least 1")
+		}
+	}
+	if sg.adaptiveMax > 0 && (sg.adaptiveMin <= 0 || sg.adaptiveMin > sg.adaptiveMax) {
+		return nil, errors.New("adaptive interval minimum must be positive and no more than the maximum")
+	}
+	if sg.breakerFailures < 0 || (sg.breakerFailures > 0 && sg.breakerCooldown <= 0) {
+		return nil, errors.New("circuit breaker failures must not be negative, and its cooldown must be positive")
+	}
+	if sg.heartbeatHook != nil && sg.heartbeatInterval <= 0 {
+		return nil, errors.New("heartbeat interval must be positive")
+	}
+	if sg.ReportHook != nil && sg.reportSchedule == nil {
+		return nil, errors.New("report schedule must be set")
+	}
+	if sg.StallHook != nil && sg.watchdogAfter <= 0 {
+		return nil, errors.New("watchdog duration must be positive")
+	}
+	if sg.rival != nil && (sg.rivalName == "" || sg.rivalGap < 0) {
+		return nil, errors.New("rival must be named, and its gap must not be negative")
+	}
+	for _, th := range sg.thresholds {
+		if th.count < 1 || th.interval <= 0 {
+			return nil, errors.New("interval thresholds must be at least 1 with a positive interval")
+		}
+	}
+	sort.Slice(sg.thresholds, func(i, j int) bool {
+		return sg.thresholds[i].count < sg.thresholds[j].count
+	})
+	for _, pct := range sg.checkpoints {
+		if pct < 1 || pct > 99 {
+			return nil, errors.New("progress checkpoints must be between 1 and 99 percent")
+		}
+	}
+	sort.Ints(sg.checkpoints)
+	if _, err := url.Parse(sg.apiBaseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid GitHub API base URL")
+	}
+	switch sg.count {
+	case "", Stars:
+		if sg.source == nil {
+			r, err := ParseRepo(sg.Repository)
+			if err != nil {
+				return nil, err
+			}
+			sg.Repository = r.String()
+		}
+	case Followers, Sponsors:
+		if strings.Contains(sg.Repository, "/") {
+			return nil, errors.Errorf("%s are counted for a user or organization, not a repository like %s", sg.count, sg.Repository)
+		}
+		if err := validOwner(sg.Repository); err != nil {
+			return nil, errors.Wrapf(err, "invalid user or organization %q", sg.Repository)
+		}
+	default:
+		return nil, errors.Errorf("GitHub cannot count %s", sg.count)
+	}
+	sg.targets = sortedTargets(sg.StargazersTarget, sg.Milestones)
+	if sg.restored != nil {
+		sg.restore(*sg.restored)
+		sg.restored = nil
+	}
 	return sg, nil
 }
 
-// SetHook allows the caller to set the threshold-crossed function hook
-// after the GitHubStargazer has been instantiated, in case the function
-// needs a reference to it.
-func (sg *GitHubStargazer) SetHook(hook func() error) {
-	sg.ThresholdCrossedHook = hook
+// SetHook replaces every registered milestone hook with hook.
+//
+// Deprecated: Use AddHook and RemoveHook, which allow several independent
+// hooks to be registered.
+func (sg *GitHubStargazer) SetHook(hook func(Milestone) error) {
+	for _, rh := range sg.hooks.snapshot() {
+		sg.hooks.remove(rh.id)
+	}
+	sg.hooks.add(hook)
 }
 
 // WithGitHubLogger is an option that can be passed to NewGitHubStargazer to
-// set the *zap.SugaredLogger that the GitHubStargazer will use internally.  If
+// set the Logger that the GitHubStargazer will use internally.  If
 // this option is not passed to NewGitHubStargazer, a no-op log will be used
 // internally.
-func WithGitHubLogger(logger *zap.SugaredLogger) func(*GitHubStargazer) {
+func WithGitHubLogger(logger Logger) func(*GitHubStargazer) {
 	return func(sg *GitHubStargazer) {
 		sg.log = logger
 	}
@@ -92,53 +391,299 @@ func WithGitHubLogger(logger *zap.SugaredLogger) func(*GitHubStargazer) {
 // against the GitHub API, like starring a repository.
 func WithGitHubToken(token string) func(*GitHubStargazer) {
 	return func(sg *GitHubStargazer) {
-		sg.token = token
+		sg.token = StaticTokenSource(token)
+	}
+}
+
+// WithGitHubTokenSource is an option that can be passed to NewGitHubStargazer
+// to have the GitHub API token supplied by source whenever it is needed, for
+// example by FileTokenSource.
+func WithGitHubTokenSource(source TokenSource) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.token = source
+	}
+}
+
+// WithGitHubBaseURL is an option that can be passed to NewGitHubStargazer to
+// talk to a GitHub Enterprise Server instance instead of github.com. Either
+// the server's root URL or its API root (ending in /api/v3) may be given.
+func WithGitHubBaseURL(baseURL string) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.apiBaseURL = githubAPIRoot(baseURL)
+	}
+}
+
+// WithGitHubCount is an option that can be passed to NewGitHubStargazer to
+// count something other than the stargazers of the repository. The other
+// counts are Followers and Sponsors, for which the repository is instead the
+// login of a user or organization, whose followers or sponsors are counted.
+// Neither can be starred, has a star history or is fetched in a
+// GraphQLBatch, and Sponsors requires a GitHub token.
+func WithGitHubCount(count Count) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.count = count
+	}
+}
+
+// WithGitHubHTTPClient is an option that can be passed to NewGitHubStargazer
+// to make requests to the GitHub API with client, for example one that is
+// instrumented or goes through a proxy, instead of a default client with a
+// 20 second timeout.
+func WithGitHubHTTPClient(client *http.Client) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		if client != nil {
+			sg.client = client
+		}
+	}
+}
+
+// WithGitHubRequestEditor is an option that can be passed to
+// NewGitHubStargazer to have editors edit every request made to the GitHub
+// API, in order, after its User-Agent is set.
+func WithGitHubRequestEditor(editors ...RequestEditor) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.editors = append(sg.editors, editors...)
+	}
+}
+
+// WithMilestones is an option that can be passed to NewGitHubStargazer to
+// watch for additional stargazer counts besides the target. The milestone
+// hooks are run once for each of them.
+func WithMilestones(milestones ...int) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.Milestones = append(sg.Milestones, milestones...)
+	}
+}
+
+// WithIntervalAt is an option that can be passed to NewGitHubStargazer to
+// poll every interval instead of Interval once the stargazers count reaches
+// count, such as to poll more often as the target nears. It may be passed
+// more than once; the interval for the highest count reached applies. Like
+// milestones, count is relative if the targets are.
+func WithIntervalAt(count int, interval time.Duration) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.thresholds = append(sg.thresholds, intervalThreshold{count: count, interval: interval})
+	}
+}
+
+// intervalThreshold is a stargazers count from which the gazer polls at a
+// different interval.
+type intervalThreshold struct {
+	count    int
+	interval time.Duration
+}
+
+// WithRelativeTargets is an option that can be passed to NewGitHubStargazer
+// to treat the target and milestones as numbers of stargazers to gain rather
+// than absolute counts. They are resolved against the stargazers count on the
+// first successful check.
+func WithRelativeTargets() func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.relative = true
+	}
+}
+
+// WithProgressCheckpoints is an option that can be passed to
+// NewGitHubStargazer to have hook called when the stargazers count reaches
+// each of the given percentages of the target. If several checkpoints are
+// reached at once, hook is only called for the highest of them.
+func WithProgressCheckpoints(hook func(Progress) error, percents ...int) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.ProgressHook = hook
+		sg.checkpoints = append(sg.checkpoints, percents...)
+	}
+}
+
+// WithSampleHook is an option that can be passed to NewGitHubStargazer to have
+// hook called with every stargazers count that is fetched.
+func WithSampleHook(hook func(Sample) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.SampleHook = hook
+	}
+}
+
+// WithChangeHook is an option that can be passed to NewGitHubStargazer to have
+// hook called whenever the fetched stargazers count changes.
+func WithChangeHook(hook func(Change) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.ChangeHook = hook
+	}
+}
+
+// WithHistory is an option that can be passed to NewGitHubStargazer to seed the
+// velocity window with previously recorded samples, oldest first.
+func WithHistory(samples ...Sample) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		for _, s := range samples {
+			sg.velocity.record(s.Time, s.Count)
+		}
+	}
+}
+
+// WithDeadline is an option that can be passed to NewGitHubStargazer to stop
+// gazing at deadline, running hook with the final count if the targets have
+// not all been reached by then.
+func WithDeadline(deadline time.Time, hook func(Deadline) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.deadline = deadline
+		sg.DeadlineHook = hook
+	}
+}
+
+// WithJitter is an option that can be passed to NewGitHubStargazer to delay
+// each poll by a random amount of up to jitter, so that many gazers polling
+// on the same interval or schedule don't all make their requests at once.
+func WithJitter(jitter time.Duration) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.jitter = jitter
+	}
+}
+
+// WithHeartbeat is an option that can be passed to NewGitHubStargazer to have
+// hook run every interval while the gazer is gazing, paused or not. Beats stop
+// if the gazer stops or gets stuck, so they can be fed to a watchdog.
+func WithHeartbeat(interval time.Duration, hook func()) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.heartbeatInterval = interval
+		sg.heartbeatHook = hook
+	}
+}
+
+// WithVelocityWindow is an option that can be passed to NewGitHubStargazer to
+// set how far back the star velocity calculation looks. The default window is
+// 24 hours.
+func WithVelocityWindow(window time.Duration) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.velocity.window = window
+	}
+}
+
+// WithVelocityAlert is an option that can be passed to NewGitHubStargazer to
+// have hook called when the repository starts gaining more than perHour stars
+// per hour.
+func WithVelocityAlert(perHour float64, hook func(Velocity) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.velocityAlertRate = perHour
+		sg.VelocityAlertHook = hook
 	}
 }
 
 // Gaze starts a loop that will poll the GitHub API every interval and call
 // the target hit hook if the number of stargazers reaches the configured
-// target. If the stargazers count target has already been reached on the first
-// check, the hook will be called.
+// target. The first poll is made immediately. If the stargazers count target
+// has already been reached on the first check, the hook will be called, unless
+// WithSkipReachedTargets was given. Gaze returns once every target has been
+// reached and no milestone hooks re-armed by WithHookRetry are waiting to be
+// run again.
 func (sg *GitHubStargazer) Gaze() {
+	defer sg.stopped()
 	sg.log.Infow("watching for stargazers",
 		"repo", sg.Repository,
 		"target", sg.StargazersTarget,
 		"poll_interval", sg.Interval)
 
-	t := time.NewTicker(sg.Interval)
-	sg.stopCh = make(chan struct{}, 1)
+	if sg.reachedAllTargets() {
+		sg.log.Infow("all targets already reached", "repo", sg.Repository)
+		return
+	}
+	sg.mu.Lock()
+	missed := !sg.deadline.IsZero() && sg.missedDeadline.Equal(sg.deadline)
+	sg.mu.Unlock()
+	if missed {
+		sg.log.Infow("deadline already missed", "repo", sg.Repository, "deadline", sg.deadline)
+		return
+	}
+	sg.resetWatchdog()
+	if sg.batch != nil && sg.watchesRepository() {
+		sg.mu.Lock()
+		sg.batched = sg.currentName()
+		sg.mu.Unlock()
+		sg.batch.add(sg.batched)
+		defer func() {
+			sg.mu.Lock()
+			batched := sg.batched
+			sg.batched = ""
+			sg.mu.Unlock()
+			sg.batch.remove(batched)
+		}()
+	}
+	// Polls come from the scheduler, if there is one, and otherwise from a
+	// ticker, after a first poll made now.
 	var (
-		count int
-		err   error
+		ticker Ticker
+		tick   <-chan time.Time
+		due    <-chan struct{}
 	)
-	// TODO Make this run immediately and not just after the interval.
+	if sg.scheduler != nil {
+		scheduled := sg.scheduler.add(sg.Repository, sg.clock.Now())
+		defer sg.scheduler.remove(scheduled)
+		sg.mu.Lock()
+		sg.scheduled = scheduled
+		sg.mu.Unlock()
+		due = scheduled.due
+	} else {
+		if !sg.Paused() {
+			sg.poll()
+			if sg.finished() {
+				return
+			}
+		}
+		ticker = sg.clock.NewTicker(sg.nextInterval())
+		defer ticker.Stop()
+		tick = ticker.C()
+	}
+	var deadline <-chan time.Time
+	if !sg.deadline.IsZero() {
+		dt := sg.clock.NewTimer(sg.deadline.Sub(sg.clock.Now()))
+		defer dt.Stop()
+		deadline = dt.C()
+	}
+	var heartbeat <-chan time.Time
+	if sg.heartbeatHook != nil {
+		ht := sg.clock.NewTicker(sg.heartbeatInterval)
+		defer ht.Stop()
+		heartbeat = ht.C()
+	}
+	var (
+		reportTimer Timer
+		report      <-chan time.Time
+	)
+	if sg.ReportHook != nil {
+		reportTimer = sg.nextReport()
+		defer func() { reportTimer.Stop() }()
+		report = reportTimer.C()
+	}
 	for {
 		select {
-		case <-t.C:
-			if count, err = sg.fetchStargazersCount(); err != nil {
-				// TODO Interpret error; determine retriability.
-				// TODO Back off if too many consecutive retriable errors
-				sg.log.Errorw("error fetching stargazers count",
-					"repo", sg.Repository,
-					"err", err.Error())
+		case <-tick:
+			if sg.Paused() {
 				continue
 			}
-			previous := sg.updateStargazersCount(count)
-			if count != previous {
-				sg.log.Infow("setting stargazers count",
-					"repo", sg.Repository,
-					"stargazers_count", count,
-					"prev_stargazers_count", previous)
+			sg.poll()
+			if sg.finished() {
+				return
 			}
-			if sg.didNotPassThreshold(previous, count) {
-				continue
+			ticker.Reset(sg.nextInterval())
+		case <-due:
+			if !sg.Paused() {
+				sg.poll()
+				if sg.finished() {
+					return
+				}
 			}
-			if err := sg.ThresholdCrossedHook(); err != nil {
-				sg.log.Infow("error calling stargazer target hit hook function",
-					"repo", sg.Repository,
-					"err", err)
+			sg.scheduler.done(sg.scheduled, sg.nextInterval())
+		case <-heartbeat:
+			sg.heartbeatHook()
+		case <-report:
+			sg.fireReport()
+			reportTimer = sg.nextReport()
+			report = reportTimer.C()
+		case <-deadline:
+			sg.poll()
+			if !sg.reachedAllTargets() {
+				sg.missDeadline()
 			}
+			return
 		case <-sg.stopCh:
 			sg.log.Infow("my work here is done")
 			return
@@ -146,91 +691,887 @@ func (sg *GitHubStargazer) Gaze() {
 	}
 }
 
-// Stop the gazing madness.
+// poll fetches the stargazers count once and runs whichever hooks the new
+// count calls for, unless the circuit breaker is open, and then checks the
+// watchdog.
+func (sg *GitHubStargazer) poll() {
+	defer sg.checkWatchdog()
+	if !sg.circuitAllows() {
+		sg.log.Debugw("circuit breaker open; skipping poll", "repo", sg.Repository)
+		return
+	}
+	sample, previous, err := sg.update()
+	if err != nil {
+		return
+	}
+	count := sample.Count
+	sg.checkVelocity()
+	if count != previous {
+		sg.log.Infow("setting stargazers count",
+			"repo", sg.Repository,
+			"stargazers_count", count,
+			"prev_stargazers_count", previous)
+		sg.fireChange(sample.Time, previous, count)
+	}
+	sg.fireProgress(count)
+	sg.checkRival(count)
+	sg.runRearmed()
+	sg.fireMilestones(count)
+}
+
+// Prime fetches the stargazers count once and records it, without running
+// the milestone, progress, change or velocity hooks, so that the gazer can
+// report the count before it starts gazing. Gaze still runs the milestone
+// hooks for targets that the count has already reached, unless
+// WithSkipReachedTargets was given.
+func (sg *GitHubStargazer) Prime() error {
+	_, _, err := sg.update()
+	return err
+}
+
+// WithSkipReachedTargets is an option that can be passed to NewGitHubStargazer
+// to treat the targets, milestones and progress checkpoints that the first
+// stargazers count fetched has already reached as fired, so that their hooks
+// aren't run for a repository that was past them before the gazer started.
+func WithSkipReachedTargets() func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.skipReached = true
+	}
+}
+
+// update fetches the stargazers count and records it, returning the sample
+// and the count before it. Failures are counted and reported to the error
+// hook.
+func (sg *GitHubStargazer) update() (Sample, int, error) {
+	count, err := sg.fetchStargazersCount()
+	if err != nil {
+		sg.mu.Lock()
+		sg.failures++
+		sg.lastErr = err
+		failures := sg.failures
+		sg.mu.Unlock()
+		sg.metrics.Counter(MetricPollFailures, 1, "repo", sg.Repository)
+		sg.fireError("fetch", err, failures)
+		f := Failure{
+			Repository:          sg.Repository,
+			Op:                  "fetch",
+			Err:                 err,
+			ConsecutiveFailures: failures,
+		}
+		sg.emit(FetchFailed{f})
+		// A rate limit is waited out rather than counted against the
+		// circuit breaker, as GitHub says when it will serve the gazer again.
+		if _, ok := asRateLimitError(err); !ok {
+			sg.circuitFailed(f)
+		}
+	}
+	if rlErr, ok := asRateLimitError(err); ok {
+		sg.mu.Lock()
+		sg.retryAt = sg.clock.Now().Add(rlErr.RetryAfter)
+		sg.mu.Unlock()
+		sg.log.Warnw("rate limited by GitHub; waiting to retry",
+			"repo", sg.Repository,
+			"secondary", rlErr.Secondary,
+			"retry_after", rlErr.RetryAfter)
+		return Sample{}, 0, err
+	}
+	if err != nil {
+		// TODO Back off if too many consecutive retriable errors
+		sg.log.Errorw("error fetching stargazers count",
+			"repo", sg.Repository,
+			"retriable", IsRetriable(err),
+			"err", err.Error())
+		sg.checkRetired(err)
+		return Sample{}, 0, err
+	}
+	sg.mu.Lock()
+	first := sg.lastSuccess.IsZero()
+	sg.failures = 0
+	sg.lastErr = nil
+	sg.lastSuccess = sg.clock.Now()
+	if sg.relative {
+		sg.resolveRelativeTargets(count)
+	}
+	if first && sg.skipReached {
+		sg.skipReachedTargets(count)
+	}
+	previous := sg.stargazersCount
+	sg.stargazersCount = count
+	sg.mu.Unlock()
+	sg.log.Debugw("fetched stargazers count",
+		"repo", sg.Repository,
+		"stargazers_count", count)
+	sg.metrics.Gauge(MetricStargazers, float64(count), "repo", sg.Repository)
+	sg.circuitSucceeded()
+	sg.fireRename()
+	sg.checkRetired(nil)
+	sg.checkLicense()
+	sg.checkContent()
+	sg.checkReferrers()
+	sample := Sample{Time: sg.clock.Now(), Count: count}
+	sg.velocity.record(sample.Time, sample.Count)
+	if sg.SampleHook != nil {
+		if err := sg.SampleHook(sample); err != nil {
+			sg.log.Infow("error calling sample hook function",
+				"repo", sg.Repository,
+				"err", err)
+		}
+	}
+	return sample, previous, nil
+}
+
+// skipReachedTargets marks the targets and progress checkpoints that count has
+// already reached as fired. The caller must hold sg.mu.
+func (sg *GitHubStargazer) skipReachedTargets(count int) {
+	var skipped []int
+	for _, target := range sg.targets {
+		if count >= target && !sg.fired[target] {
+			sg.fired[target] = true
+			skipped = append(skipped, target)
+		}
+	}
+	for _, pct := range sg.checkpoints {
+		if count >= sg.checkpointCount(pct) {
+			sg.progressFired[pct] = true
+		}
+	}
+	if len(skipped) > 0 {
+		sg.log.Infow("skipping targets already reached",
+			"repo", sg.Repository,
+			"stargazers_count", count,
+			"targets", skipped)
+	}
+}
+
+// missDeadline runs the deadline hook for the first target that has not been
+// reached.
+func (sg *GitHubStargazer) missDeadline() {
+	d := Deadline{
+		Repository:      sg.Repository,
+		Deadline:        sg.deadline,
+		StargazersCount: sg.StargazersCount(),
+	}
+	sg.mu.Lock()
+	for _, target := range sg.targets {
+		if !sg.fired[target] {
+			d.Target = target
+			break
+		}
+	}
+	sg.missedDeadline = sg.deadline
+	sg.mu.Unlock()
+	sg.saveState()
+	sg.log.Infow("deadline passed before reaching target",
+		"repo", sg.Repository,
+		"target", d.Target,
+		"stargazers_count", d.StargazersCount)
+	if sg.DeadlineHook == nil {
+		return
+	}
+	err := sg.DeadlineHook(d)
+	if err != nil {
+		sg.log.Infow("error calling deadline hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+	sg.hookDone("deadline", err)
+}
+
+// nextInterval returns how long to wait before polling again. This is the
+// scheduled interval unless the rate limit is running low, in which case
+// polls are stretched out so the remaining requests last until it resets, or
+// GitHub has asked us to back off for longer than that.
+func (sg *GitHubStargazer) nextInterval() time.Duration {
+	now := sg.clock.Now()
+	base := sg.baseInterval(now)
+	sg.mu.Lock()
+	retryAt, rateLimit := sg.retryAt, sg.rateLimit
+	sg.mu.Unlock()
+	if wait := retryAt.Sub(now); wait > base {
+		sg.setThrottled(true)
+		return wait
+	}
+	interval := rateLimit.pollInterval(base, now)
+	if interval != base {
+		sg.log.Infow("stretching poll interval to conserve rate limit",
+			"repo", sg.Repository,
+			"poll_interval", interval,
+			"rate_limit_remaining", rateLimit.Remaining,
+			"rate_limit_reset", rateLimit.Reset)
+	}
+	sg.setThrottled(interval != base)
+	return sg.jittered(interval)
+}
+
+// setThrottled records whether the next poll is later than scheduled because
+// of the rate limit.
+func (sg *GitHubStargazer) setThrottled(throttled bool) {
+	sg.mu.Lock()
+	sg.throttled = throttled
+	sg.mu.Unlock()
+	var value float64
+	if throttled {
+		value = 1
+	}
+	sg.metrics.Gauge(MetricThrottled, value, "repo", sg.Repository)
+}
+
+// jittered adds a random delay of up to the configured jitter to interval.
+func (sg *GitHubStargazer) jittered(interval time.Duration) time.Duration {
+	if sg.jitter <= 0 {
+		return interval
+	}
+	return interval + rand.N(sg.jitter)
+}
+
+// baseInterval returns how long to wait from now until the next poll
+// according to the schedule, or the adaptive interval, or otherwise the
+// interval for the highest threshold reached, falling back to Interval.
+func (sg *GitHubStargazer) baseInterval(now time.Time) time.Duration {
+	switch {
+	case sg.schedule != nil:
+		return sg.schedule.Next(now).Sub(now)
+	case sg.adaptiveMax > 0:
+		return sg.adaptiveInterval()
+	default:
+		interval := sg.Interval
+		for _, th := range sg.thresholds {
+			if sg.stargazersCount >= th.count {
+				interval = th.interval
+			}
+		}
+		return interval
+	}
+}
+
+// stopped sends the Stopped event and closes the event channel.
+func (sg *GitHubStargazer) stopped() {
+	sg.emit(Stopped{Repository: sg.Repository, Time: sg.clock.Now()})
+	sg.events.close()
+}
+
+// Stop the gazing madness. Calling Stop more than once has no further effect.
 func (sg *GitHubStargazer) Stop() {
-	sg.stopCh <- struct{}{}
+	select {
+	case sg.stopCh <- struct{}{}:
+	default:
+	}
+}
+
+// Pause suspends polling until Resume is called. The gazer keeps its state,
+// so no hooks are missed for counts that were already seen.
+func (sg *GitHubStargazer) Pause() {
+	sg.mu.Lock()
+	sg.paused = true
+	sg.mu.Unlock()
+	sg.log.Infow("paused", "repo", sg.Repository)
+	sg.emit(Paused{Repository: sg.Repository, Time: sg.clock.Now()})
+}
+
+// Resume continues polling after Pause.
+func (sg *GitHubStargazer) Resume() {
+	sg.mu.Lock()
+	sg.paused = false
+	sg.watchdogFrom = sg.clock.Now()
+	sg.mu.Unlock()
+	sg.log.Infow("resumed", "repo", sg.Repository)
+	sg.emit(Resumed{Repository: sg.Repository, Time: sg.clock.Now()})
+}
+
+// Paused reports whether polling has been suspended with Pause.
+func (sg *GitHubStargazer) Paused() bool {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.paused
 }
 
 // Star adds a star to the repository if a token has been set.
-func (sg GitHubStargazer) Star() error {
-	if sg.token == "" {
-		return fmt.Errorf("cannot star %s: GitHub token is empty", sg.Repository)
+func (sg *GitHubStargazer) Star() error {
+	_, err := sg.userStarred(http.MethodPut, "star",
+		http.StatusOK, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
 	}
-	endpoint := fmt.Sprintf("%s/user/starred/%s", sg.apiBaseURL, sg.Repository)
-	req, err := http.NewRequest("PUT", endpoint, nil)
+	sg.log.Infow("starred repository", "repo", sg.Repository)
+	return nil
+}
+
+// Unstar removes the star from the repository if a token has been set,
+// undoing Star. Unstarring a repository that isn't starred succeeds.
+func (sg *GitHubStargazer) Unstar() error {
+	_, err := sg.userStarred(http.MethodDelete, "unstar",
+		http.StatusOK, http.StatusNoContent)
 	if err != nil {
 		return err
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("token %s", sg.token))
-	resp, err := sg.client.Do(req)
+	sg.log.Infow("unstarred repository", "repo", sg.Repository)
+	return nil
+}
+
+// Starred reports whether the user whose token has been set has starred the
+// repository, so that Star can be skipped if they already have.
+func (sg *GitHubStargazer) Starred() (bool, error) {
+	status, err := sg.userStarred(http.MethodGet, "check the star of",
+		http.StatusNoContent, http.StatusNotFound)
+	return status == http.StatusNoContent, err
+}
+
+// userStarred makes a request with method for the repository among the
+// authenticated user's starred repositories: GET to check whether it is
+// starred, PUT to star it and DELETE to unstar it. It returns the status of
+// the response, which is an error unless it is one of ok. The verb names
+// what is being done in error messages.
+func (sg *GitHubStargazer) userStarred(method, verb string, ok ...int) (int, error) {
+	if !sg.watchesRepository() {
+		return 0, fmt.Errorf("cannot %s %s: %w", verb, sg.Repository, errNotGitHub)
+	}
+	token, err := sg.authToken()
 	if err != nil {
-		return errors.Wrap(err, "error reaching GitHub API")
+		return 0, fmt.Errorf("cannot %s %s: %w", verb, sg.Repository, err)
 	}
-	if resp.StatusCode != http.StatusOK &&
-		resp.StatusCode != http.StatusCreated &&
-		resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("error starring %s: %s", sg.Repository, resp.Status)
+	if token == "" {
+		return 0, fmt.Errorf("cannot %s %s: GitHub token is empty: %w", verb, sg.Repository, ErrUnauthorized)
 	}
-	sg.log.Infow("starred repository", "repo", sg.Repository)
-	return nil
+	endpoint := fmt.Sprintf("%s/user/starred/%s", sg.apiBaseURL, sg.CurrentName())
+	req, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("token %s", token))
+	resp, err := sg.do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error reaching GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+	sg.updateRateLimit(resp)
+	if rlErr := rateLimitErrorFromResponse(resp, sg.clock.Now()); rlErr != nil {
+		return 0, rlErr
+	}
+	// A redirect to a renamed repository is followed as a GET, which
+	// doesn't star or unstar it, and is for the wrong repository anyway.
+	if resp.Request.Method != req.Method || resp.Request.URL.Path != req.URL.Path {
+		return 0, &RepoMovedError{Repository: sg.Repository, Location: resp.Request.URL.String()}
+	}
+	for _, status := range ok {
+		if resp.StatusCode == status {
+			return status, nil
+		}
+	}
+	return resp.StatusCode, newAPIError("GitHub", resp)
+}
+
+// watchesRepository reports whether the gazer watches the stargazers of a
+// GitHub repository, rather than another source or a user's followers or
+// sponsors.
+func (sg *GitHubStargazer) watchesRepository() bool {
+	return sg.source == nil && sg.count != Followers && sg.count != Sponsors
+}
+
+// authToken returns the current GitHub API token, or an empty string if none
+// has been configured.
+func (sg *GitHubStargazer) authToken() (string, error) {
+	if sg.token == nil {
+		return "", nil
+	}
+	return sg.token()
+}
+
+// Ready reports whether the stargazers count has been fetched successfully at
+// least once.
+func (sg *GitHubStargazer) Ready() bool {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return !sg.lastSuccess.IsZero()
+}
+
+// ConsecutiveFailures returns the number of times in a row that fetching the
+// stargazers count has failed. It is reset by a successful fetch.
+func (sg *GitHubStargazer) ConsecutiveFailures() int {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.failures
+}
+
+// RateLimit returns the GitHub API rate limit as of the most recent response.
+func (sg *GitHubStargazer) RateLimit() RateLimit {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.rateLimit
+}
+
+// GraphQLRateLimit returns the GitHub GraphQL API rate limit as of the most
+// recent response, if the gazer fetches its count with a GraphQLBatch.
+func (sg *GitHubStargazer) GraphQLRateLimit() (RateLimit, bool) {
+	if sg.batch == nil {
+		return RateLimit{}, false
+	}
+	return sg.batch.RateLimit(), true
+}
+
+// Throttled reports whether the gazer's next poll is later than scheduled,
+// either to make the remaining requests of the rate limit last until it
+// resets or because GitHub asked it to back off.
+func (sg *GitHubStargazer) Throttled() bool {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.throttled
+}
+
+func (sg *GitHubStargazer) updateRateLimit(resp *http.Response) {
+	if rl, ok := rateLimitFromHeader(resp.Header); ok {
+		sg.mu.Lock()
+		sg.rateLimit = rl
+		sg.mu.Unlock()
+		sg.metrics.Gauge(MetricGitHubRateLimitRemaining, float64(rl.Remaining), "repo", sg.Repository)
+	}
+}
+
+// do sends a request to the GitHub API, reporting it to the gazer's metrics
+// and counting it against the hourly budget of its scheduler, if it has one.
+func (sg *GitHubStargazer) do(req *http.Request) (*http.Response, error) {
+	if err := editRequest(req, sg.editors); err != nil {
+		return nil, err
+	}
+	if sg.scheduler != nil {
+		if err := sg.scheduler.reserve(); err != nil {
+			return nil, err
+		}
+	}
+	start := sg.clock.Now()
+	resp, err := sg.client.Do(req)
+	observeRequest(sg.metrics, MetricGitHubRequests, MetricGitHubRequestDuration,
+		sg.clock.Now().Sub(start), resp, "repo", sg.Repository)
+	return resp, err
+}
+
+// Fetch fetches the current number of stargazers once, without checking it
+// against any targets or running any hooks. It can be used instead of Gaze by
+// callers that only want the count.
+func (sg *GitHubStargazer) Fetch() (int, error) {
+	return sg.fetchStargazersCount()
 }
 
 // StargazersCount returns the most recent number of stargazers fetched by the
 // gazer.
-func (sg GitHubStargazer) StargazersCount() int {
+func (sg *GitHubStargazer) StargazersCount() int {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
 	return sg.stargazersCount
 }
 
-func (sg *GitHubStargazer) updateStargazersCount(latest int) int {
-	old := sg.stargazersCount
-	sg.stargazersCount = latest
-	return old
+// Targets returns the stargazers target and milestones, which change when
+// relative targets are resolved.
+func (sg *GitHubStargazer) Targets() (target int, milestones []int) {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.StargazersTarget, append([]int(nil), sg.Milestones...)
+}
+
+// Velocity returns the rate at which the repository has been gaining
+// stargazers over the velocity window.
+func (sg *GitHubStargazer) Velocity() Velocity {
+	return sg.velocity.velocity()
 }
 
-func (sg GitHubStargazer) didNotPassThreshold(old, current int) bool {
-	return current < sg.StargazersTarget || old >= current
+// History returns the stargazers counts observed over the velocity window,
+// oldest first.
+func (sg *GitHubStargazer) History() []Sample {
+	return sg.velocity.history()
 }
 
-// fetch the most recent number of stargazers from the GitHub API and store it
-// in the GitHubStargazer. 🤩 If an ETag is stored in the starwatcher, send
-// it in the header to prevent repeated fetches and counting against the rate
-// limit.
+// checkVelocity runs the velocity alert hook if the velocity has risen above
+// the alert rate, and rearms the alert once it falls back below.
+func (sg *GitHubStargazer) checkVelocity() {
+	if sg.velocityAlertRate <= 0 || sg.VelocityAlertHook == nil {
+		return
+	}
+	v := sg.Velocity()
+	sg.mu.Lock()
+	if v.PerHour < sg.velocityAlertRate {
+		sg.velocityAlerted = false
+		sg.mu.Unlock()
+		return
+	}
+	if sg.velocityAlerted {
+		sg.mu.Unlock()
+		return
+	}
+	sg.velocityAlerted = true
+	sg.mu.Unlock()
+	sg.saveState()
+	sg.log.Infow("star velocity above alert rate",
+		"repo", sg.Repository,
+		"stars_per_hour", v.PerHour,
+		"alert_rate", sg.velocityAlertRate)
+	err := sg.VelocityAlertHook(v)
+	if err != nil {
+		sg.log.Infow("error calling velocity alert hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+	sg.hookDone("velocity", err)
+}
+
+// reachedAllTargets reports whether the hook has already fired for every
+// target, which can be the case when state has been restored.
+func (sg *GitHubStargazer) reachedAllTargets() bool {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	for _, target := range sg.targets {
+		if !sg.fired[target] {
+			return false
+		}
+	}
+	return true
+}
+
+// finished reports whether there is nothing left to gaze for, as every
+// target has been reached and no re-armed milestone hooks are waiting to be
+// run again, logging it if so.
+func (sg *GitHubStargazer) finished() bool {
+	if !sg.reachedAllTargets() || len(sg.rearmed) > 0 {
+		return false
+	}
+	sg.log.Infow("all targets reached", "repo", sg.Repository)
+	return true
+}
+
+// resolveRelativeTargets turns relative targets into absolute ones by adding
+// the current stargazers count to each of them. The caller must hold sg.mu.
+func (sg *GitHubStargazer) resolveRelativeTargets(count int) {
+	sg.relative = false
+	sg.resolved = true
+	sg.baseline = count
+	sg.StargazersTarget += count
+	for i := range sg.Milestones {
+		sg.Milestones[i] += count
+	}
+	for i := range sg.targets {
+		sg.targets[i] += count
+	}
+	for i := range sg.thresholds {
+		sg.thresholds[i].count += count
+	}
+	sg.log.Infow("resolved relative target",
+		"repo", sg.Repository,
+		"stargazers_count", count,
+		"target", sg.StargazersTarget)
+}
+
+// fireMilestones runs the milestone hooks for every target that count has
+// reached and that has not already been fired.
+func (sg *GitHubStargazer) fireMilestones(count int) {
+	reached := sg.reachMilestones(count)
+	if len(reached) > 0 {
+		sg.saveState()
+	}
+	for _, m := range reached {
+		sg.metrics.Counter(MetricMilestones, 1, "repo", sg.Repository)
+		sg.emit(ThresholdCrossed{m})
+		for _, rh := range sg.hooks.snapshot() {
+			sg.runHook(rh, m)
+		}
+	}
+}
+
+// reachMilestones marks every target that count has reached and that has not
+// already been fired as fired, returning their milestones.
+func (sg *GitHubStargazer) reachMilestones(count int) []Milestone {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	final := sg.targets[len(sg.targets)-1]
+	var reached []Milestone
+	for _, target := range sg.targets {
+		if count < target || sg.fired[target] {
+			continue
+		}
+		sg.fired[target] = true
+		reached = append(reached, Milestone{
+			Repository:      sg.Repository,
+			Target:          target,
+			StargazersCount: count,
+			Final:           target == final,
+		})
+	}
+	return reached
+}
+
+// fireChange runs the change hook, if there is one.
+func (sg *GitHubStargazer) fireChange(at time.Time, previous, count int) {
+	c := Change{
+		Repository:      sg.Repository,
+		Time:            at,
+		Previous:        previous,
+		StargazersCount: count,
+	}
+	sg.emit(CountChanged{c})
+	if sg.ChangeHook == nil {
+		return
+	}
+	if err := sg.ChangeHook(c); err != nil {
+		sg.log.Infow("error calling change hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+}
+
+// fireProgress runs the progress hook for the highest checkpoint that count
+// has reached and that has not already been fired. Lower checkpoints reached
+// at the same time are skipped so a late start doesn't produce a burst of
+// notifications.
+func (sg *GitHubStargazer) fireProgress(count int) {
+	if sg.ProgressHook == nil || count >= sg.StargazersTarget {
+		return
+	}
+	highest := 0
+	sg.mu.Lock()
+	for _, pct := range sg.checkpoints {
+		if count < sg.checkpointCount(pct) || sg.progressFired[pct] {
+			continue
+		}
+		sg.progressFired[pct] = true
+		highest = pct
+	}
+	sg.mu.Unlock()
+	if highest == 0 {
+		return
+	}
+	sg.saveState()
+	p := Progress{
+		Repository:      sg.Repository,
+		Percent:         highest,
+		Target:          sg.StargazersTarget,
+		StargazersCount: count,
+	}
+	err := sg.ProgressHook(p)
+	if err != nil {
+		sg.log.Infow("error calling progress hook function",
+			"repo", sg.Repository,
+			"percent", highest,
+			"err", err)
+	}
+	sg.hookDone("progress", err)
+}
+
+// checkpointCount returns the stargazers count at which the given percentage
+// of the way from the baseline to the target is reached.
+func (sg *GitHubStargazer) checkpointCount(percent int) int {
+	span := sg.StargazersTarget - sg.baseline
+	return sg.baseline + (span*percent+99)/100
+}
+
+// githubAPIRoot returns the REST API root for a GitHub base URL. GitHub
+// Enterprise Server serves its API under /api/v3, while api.github.com serves
+// it at the root.
+func githubAPIRoot(baseURL string) string {
+	root := strings.TrimRight(baseURL, "/")
+	u, err := url.Parse(root)
+	if err != nil || u.Host == "api.github.com" || strings.HasSuffix(u.Path, "/api/v3") {
+		return root
+	}
+	return root + "/api/v3"
+}
+
+// sortedTargets merges the target and milestones into an ascending list
+// without duplicates.
+func sortedTargets(target int, milestones []int) []int {
+	seen := map[int]bool{target: true}
+	targets := []int{target}
+	for _, m := range milestones {
+		if !seen[m] {
+			seen[m] = true
+			targets = append(targets, m)
+		}
+	}
+	sort.Ints(targets)
+	return targets
+}
+
+// fetch the most recent number of stargazers from the GitHub API. 🤩 The
+// request is conditional, so an unchanged repository doesn't count against the
+// rate limit. Gazers with a Source fetch from it instead, and those with a
+// GraphQL batch fetch with it. The first fetch resolves the repository's ID,
+// by which it is fetched from the REST API from then on, so that the watch
+// carries on if the repository is renamed or transferred. The batch fetches it
+// by its current name instead, which GitHub resolves for renamed
+// repositories.
 func (sg *GitHubStargazer) fetchStargazersCount() (int, error) {
-	if sg.client == nil {
-		sg.client = &http.Client{Timeout: 20 * time.Second}
+	if sg.source != nil {
+		return sg.source.Fetch()
+	}
+	switch sg.count {
+	case Followers:
+		return sg.fetchFollowersCount()
+	case Sponsors:
+		return sg.fetchSponsorsCount()
+	}
+	if sg.batch != nil {
+		return sg.fetchBatched()
 	}
-	endpoint := fmt.Sprintf("%s/repos/%s", sg.apiBaseURL, sg.Repository)
-	req, err := http.NewRequest("GET", endpoint, nil)
-	req.Header.Add("Accept", "application/json")
-	if sg.etag != "" {
-		req.Header.Add("If-None-Match", sg.etag)
+	endpoint := fmt.Sprintf("%s/repos/%s", sg.apiBaseURL, sg.CurrentName())
+	if id := sg.RepositoryID(); id != 0 {
+		endpoint = fmt.Sprintf("%s/repositories/%d", sg.apiBaseURL, id)
 	}
+	body, err := sg.conditionalGet(endpoint, "application/json")
+	if err != nil {
+		return -1, err
+	}
+	count, metadata, err := repoFromJSON(body)
+	if err != nil {
+		return -1, err
+	}
+	sg.mu.Lock()
+	sg.noteMetadata(metadata)
+	sg.mu.Unlock()
+	return count, nil
+}
 
-	resp, err := sg.client.Do(req)
+// fetchBatched fetches the stargazers count and metadata of the repository
+// with the gazer's GraphQL batch, by its current name. If it turns out to have
+// been renamed, the batch fetches it by its new name from then on.
+func (sg *GitHubStargazer) fetchBatched() (int, error) {
+	name := sg.CurrentName()
+	count, metadata, err := sg.batch.repository(name)
 	if err != nil {
-		return -1, errors.Wrapf(err, "error reaching GitHub API: %s", endpoint)
+		return -1, err
 	}
-	if resp.StatusCode == http.StatusNotModified {
-		return sg.StargazersCount(), nil
+	sg.mu.Lock()
+	sg.noteMetadata(metadata)
+	previous, current := sg.batched, sg.currentName()
+	rekey := previous != "" && previous != current
+	if rekey {
+		sg.batched = current
 	}
-	if resp.StatusCode != http.StatusOK {
-		return -1, fmt.Errorf("error during GithHub API call: %v (url: %s)",
-			resp.Status, endpoint)
+	sg.mu.Unlock()
+	if rekey {
+		sg.batch.add(current)
+		sg.batch.remove(previous)
 	}
-	if etag := resp.Header.Get("ETag"); etag != "" && etag != sg.etag {
-		sg.etag = etag
+	return count, nil
+}
+
+// noteMetadata records metadata as the latest of the repository, noting its
+// new name if it has been renamed, and its ID if it hasn't been resolved yet.
+// The caller must hold sg.mu.
+func (sg *GitHubStargazer) noteMetadata(metadata RepoMetadata) {
+	sg.metadata = &metadata
+	sg.noteName(metadata.FullName)
+	if sg.repoID == 0 && metadata.ID != 0 {
+		sg.repoID = metadata.ID
+		sg.log.Debugw("resolved repository ID", "repo", sg.Repository, "id", metadata.ID)
 	}
-	defer resp.Body.Close()
-	return stargazersFromJSON(resp.Body)
 }
 
-func stargazersFromJSON(r io.Reader) (int, error) {
-	var apiResponse struct {
-		StargazersCount int `json:"stargazers_count"`
+// fetchFollowersCount fetches the number of followers of the user or
+// organization that the gazer watches.
+func (sg *GitHubStargazer) fetchFollowersCount() (int, error) {
+	endpoint := fmt.Sprintf("%s/users/%s", sg.apiBaseURL, sg.Repository)
+	body, err := sg.conditionalGet(endpoint, "application/json")
+	if err != nil {
+		return -1, err
 	}
-	d := json.NewDecoder(r)
-	if err := d.Decode(&apiResponse); err != nil {
+	var user struct {
+		Followers int `json:"followers"`
+	}
+	if err := json.Unmarshal(body, &user); err != nil {
 		return -1, errors.Wrap(err, "error decoding GitHub JSON response")
 	}
-	return apiResponse.StargazersCount, nil
+	return user.Followers, nil
+}
+
+// fetchSponsorsCount fetches the number of sponsors of the user or
+// organization that the gazer watches. Sponsors are only available from the
+// GraphQL API, which always requires a token.
+func (sg *GitHubStargazer) fetchSponsorsCount() (int, error) {
+	token, err := sg.authToken()
+	if err != nil {
+		return -1, errors.Wrap(err, "error getting GitHub token")
+	}
+	if token == "" {
+		return -1, fmt.Errorf("cannot count sponsors of %s: GitHub token is empty: %w", sg.Repository, ErrUnauthorized)
+	}
+	login, _ := json.Marshal(sg.Repository)
+	query := fmt.Sprintf("query { repositoryOwner(login: %s) { ... on Sponsorable { sponsors { totalCount } } } }", login)
+	reqBody, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return -1, err
+	}
+	endpoint := graphQLEndpoint(sg.apiBaseURL)
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "bearer "+token)
+	resp, err := sg.do(req)
+	if err != nil {
+		return -1, fmt.Errorf("error reaching GitHub API: %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	if rlErr := rateLimitErrorFromResponse(resp, sg.clock.Now()); rlErr != nil {
+		return -1, rlErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return -1, newAPIError("GitHub GraphQL", resp)
+	}
+	var result struct {
+		Data struct {
+			RepositoryOwner *struct {
+				Sponsors *struct {
+					TotalCount int `json:"totalCount"`
+				} `json:"sponsors"`
+			} `json:"repositoryOwner"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return -1, errors.Wrap(err, "error decoding GitHub GraphQL JSON response")
+	}
+	if len(result.Errors) > 0 {
+		return -1, errors.Errorf("error during GitHub GraphQL API call: %s", result.Errors[0].Message)
+	}
+	owner := result.Data.RepositoryOwner
+	if owner == nil {
+		return -1, fmt.Errorf("user or organization %s: %w", sg.Repository, ErrNotFound)
+	}
+	if owner.Sponsors == nil {
+		return -1, errors.Errorf("%s cannot be sponsored", sg.Repository)
+	}
+	return owner.Sponsors.TotalCount, nil
+}
+
+// StarHistory fetches the time at which each current stargazer starred the
+// repository and returns the resulting count after each star, oldest first.
+// Stars that have since been removed aren't included, and GitHub only allows
+// the first 40,000 stargazers to be listed this way, so for very large
+// repositories the history is incomplete.
+func (sg *GitHubStargazer) StarHistory() ([]Sample, error) {
+	if !sg.watchesRepository() {
+		return nil, fmt.Errorf("cannot fetch star history of %s: %w", sg.Repository, errNotGitHub)
+	}
+	const perPage = 100
+	var samples []Sample
+	for page := 1; ; page++ {
+		endpoint := fmt.Sprintf("%s/repos/%s/stargazers?per_page=%d&page=%d",
+			sg.apiBaseURL, sg.CurrentName(), perPage, page)
+		body, err := sg.get(endpoint, "application/vnd.github.star+json")
+		if err != nil {
+			return samples, err
+		}
+		var stars []struct {
+			StarredAt time.Time `json:"starred_at"`
+		}
+		if err := json.Unmarshal(body, &stars); err != nil {
+			return samples, errors.Wrap(err, "error decoding GitHub JSON response")
+		}
+		for _, star := range stars {
+			samples = append(samples, Sample{Time: star.StarredAt, Count: len(samples) + 1})
+		}
+		if len(stars) < perPage {
+			return samples, nil
+		}
+	}
 }
@@ -0,0 +1,404 @@
+// Package githubtest provides a fake GitHub API for testing code that uses
+// gazers without reaching GitHub. It serves the repository, stargazers and
+// starring endpoints, and the GraphQL stargazer counts, that gazers use, with
+// rate limit headers, and lets tests grow the stargazers of each repository
+// as they like:
+//
+//	srv := githubtest.NewServer()
+//	defer srv.Close()
+//	srv.SetStargazers("matryer/moq", 99)
+//	sg, err := stargazer.NewGitHubStargazer("matryer/moq", 100, time.Second, hook,
+//		stargazer.WithGitHubBaseURL(srv.URL))
+//	...
+//	srv.AddStargazers("matryer/moq", 1)
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+)
+
+// Server is a fake GitHub API. Its URL can be given to
+// stargazer.WithGitHubBaseURL and stargazer.WithGraphQLBaseURL. It is safe
+// for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	repos     map[string]*repository
+	token     string
+	rateLimit stargazer.RateLimit
+	requests  int
+	nextID    int64
+}
+
+// repository is a repository served by the fake API. Its id is given in the
+// order repositories are added, from 1.
+type repository struct {
+	id      int64
+	name    string
+	stars   []time.Time
+	starred bool
+}
+
+// NewServer starts a fake GitHub API with no repositories and no rate
+// limit. It must be closed with Close.
+func NewServer() *Server {
+	s := &Server{repos: make(map[string]*repository)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetStargazers sets the stargazers count of repo, adding the repository if
+// it isn't served yet. Stargazers added to reach count starred it now, and
+// those removed are the latest.
+func (s *Server) SetStargazers(repo string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.repo(repo)
+	for len(r.stars) < count {
+		r.stars = append(r.stars, time.Now())
+	}
+	if count < len(r.stars) {
+		r.stars = r.stars[:max(count, 0)]
+	}
+}
+
+// AddStargazers adds n stargazers to repo, who starred it now.
+func (s *Server) AddStargazers(repo string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.repo(repo)
+	for i := 0; i < n; i++ {
+		r.stars = append(r.stars, time.Now())
+	}
+}
+
+// AddStargazer adds a stargazer to repo who starred it at, for the star
+// history. Stargazers are listed in the order they are added.
+func (s *Server) AddStargazer(repo string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.repo(repo)
+	r.stars = append(r.stars, at)
+}
+
+// Stargazers returns the stargazers count of repo.
+func (s *Server) Stargazers(repo string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.repo(repo).stars)
+}
+
+// Starred reports whether repo has been starred through the API.
+func (s *Server) Starred(repo string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repo(repo).starred
+}
+
+// RequireToken has the API refuse requests that aren't made with token.
+// Starring always requires a token, though any will do unless one is
+// required.
+func (s *Server) RequireToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// SetRateLimit sets the rate limit that the API reports, and enforces once
+// remaining reaches 0. Each request that isn't answered from the client's
+// cache uses one request. When reset passes, remaining starts again from
+// limit for another hour.
+func (s *Server) SetRateLimit(limit, remaining int, reset time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimit = stargazer.RateLimit{Limit: limit, Remaining: remaining, Reset: reset}
+}
+
+// Requests returns how many requests the API has served.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// repo returns the repository named name, adding it if it isn't served yet.
+// The caller must hold s.mu.
+func (s *Server) repo(name string) *repository {
+	key := strings.ToLower(name)
+	r, ok := s.repos[key]
+	if !ok {
+		s.nextID++
+		r = &repository{id: s.nextID, name: name}
+		s.repos[key] = r
+	}
+	return r
+}
+
+// repoByID returns the repository with id, if it is served. The caller must
+// hold s.mu.
+func (s *Server) repoByID(id int64) (*repository, bool) {
+	for _, r := range s.repos {
+		if r.id == id {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+var (
+	repoPath       = regexp.MustCompile(`^/repos/([^/]+/[^/]+)$`)
+	repoIDPath     = regexp.MustCompile(`^/repositories/(\d+)$`)
+	stargazersPath = regexp.MustCompile(`^/repos/([^/]+/[^/]+)/stargazers$`)
+	starredPath    = regexp.MustCompile(`^/user/starred/([^/]+/[^/]+)$`)
+	graphqlAlias   = regexp.MustCompile(`(\w+): repository\(owner: ("[^"]*"), name: ("[^"]*")\)`)
+)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.token != "" && !strings.HasSuffix(r.Header.Get("Authorization"), " "+s.token) {
+		writeJSON(w, http.StatusUnauthorized, message("Bad credentials"))
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+	switch {
+	case r.Method == http.MethodPost && (path == "/graphql" || path == "/api/graphql"):
+		if s.useRateLimit(w) {
+			s.serveGraphQL(w, r)
+		}
+	case r.Method == http.MethodGet && repoPath.MatchString(path):
+		repo, ok := s.repos[strings.ToLower(repoPath.FindStringSubmatch(path)[1])]
+		s.serveRepo(w, r, repo, ok)
+	case r.Method == http.MethodGet && repoIDPath.MatchString(path):
+		id, _ := strconv.ParseInt(repoIDPath.FindStringSubmatch(path)[1], 10, 64)
+		repo, ok := s.repoByID(id)
+		s.serveRepo(w, r, repo, ok)
+	case r.Method == http.MethodGet && stargazersPath.MatchString(path):
+		if s.useRateLimit(w) {
+			s.serveStargazers(w, r, stargazersPath.FindStringSubmatch(path)[1])
+		}
+	case r.Method == http.MethodGet && starredPath.MatchString(path):
+		if s.useRateLimit(w) {
+			s.serveStarred(w, r, starredPath.FindStringSubmatch(path)[1])
+		}
+	case r.Method == http.MethodPut && starredPath.MatchString(path):
+		if s.useRateLimit(w) {
+			s.serveStar(w, r, starredPath.FindStringSubmatch(path)[1])
+		}
+	case r.Method == http.MethodDelete && starredPath.MatchString(path):
+		if s.useRateLimit(w) {
+			s.serveUnstar(w, r, starredPath.FindStringSubmatch(path)[1])
+		}
+	default:
+		writeJSON(w, http.StatusNotFound, message("Not Found"))
+	}
+}
+
+// useRateLimit sets the rate limit headers, using a request, and returns
+// true if the request may go ahead. Otherwise it writes the response GitHub
+// sends when the rate limit is exceeded. The caller must hold s.mu.
+func (s *Server) useRateLimit(w http.ResponseWriter) bool {
+	rl := &s.rateLimit
+	if rl.Limit == 0 {
+		return true
+	}
+	if now := time.Now(); !now.Before(rl.Reset) {
+		rl.Remaining = rl.Limit
+		rl.Reset = now.Add(time.Hour)
+	}
+	if rl.Remaining > 0 {
+		rl.Remaining--
+		s.setRateLimitHeaders(w)
+		return true
+	}
+	s.setRateLimitHeaders(w)
+	writeJSON(w, http.StatusForbidden, message("API rate limit exceeded"))
+	return false
+}
+
+// setRateLimitHeaders sets the rate limit headers, if there is a rate limit.
+// The caller must hold s.mu.
+func (s *Server) setRateLimitHeaders(w http.ResponseWriter) {
+	rl := s.rateLimit
+	if rl.Limit == 0 {
+		return
+	}
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(rl.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(rl.Reset.Unix(), 10))
+}
+
+// serveRepo serves a repository, by name or by ID, or not found if ok is
+// false, with an ETag that changes with its stargazers count. Like GitHub, a
+// conditional request for an unchanged repository doesn't use the rate
+// limit. The caller must hold s.mu.
+func (s *Server) serveRepo(w http.ResponseWriter, r *http.Request, repo *repository, ok bool) {
+	if !ok {
+		if s.useRateLimit(w) {
+			writeJSON(w, http.StatusNotFound, message("Not Found"))
+		}
+		return
+	}
+	etag := fmt.Sprintf(`"%s:%d"`, repo.name, len(repo.stars))
+	if r.Header.Get("If-None-Match") == etag {
+		s.setRateLimitHeaders(w)
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if !s.useRateLimit(w) {
+		return
+	}
+	w.Header().Set("ETag", etag)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":               repo.id,
+		"full_name":        repo.name,
+		"stargazers_count": len(repo.stars),
+	})
+}
+
+// serveStargazers serves a page of the stargazers of a repository, with
+// when they starred it if the star media type is asked for. The caller must
+// hold s.mu.
+func (s *Server) serveStargazers(w http.ResponseWriter, r *http.Request, name string) {
+	repo, ok := s.repos[strings.ToLower(name)]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, message("Not Found"))
+		return
+	}
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage < 1 || perPage > 100 {
+		perPage = 30
+	}
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	withTimes := strings.Contains(r.Header.Get("Accept"), "star+json")
+	stars := []interface{}{}
+	for i := (page - 1) * perPage; i < len(repo.stars) && i < page*perPage; i++ {
+		user := map[string]interface{}{"login": fmt.Sprintf("stargazer%d", i+1)}
+		if withTimes {
+			stars = append(stars, map[string]interface{}{
+				"starred_at": repo.stars[i].UTC().Format(time.RFC3339),
+				"user":       user,
+			})
+			continue
+		}
+		stars = append(stars, user)
+	}
+	writeJSON(w, http.StatusOK, stars)
+}
+
+// serveStar stars a repository, adding a stargazer the first time. The
+// caller must hold s.mu.
+func (s *Server) serveStar(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Header.Get("Authorization") == "" {
+		writeJSON(w, http.StatusUnauthorized, message("Requires authentication"))
+		return
+	}
+	repo, ok := s.repos[strings.ToLower(name)]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, message("Not Found"))
+		return
+	}
+	if !repo.starred {
+		repo.starred = true
+		repo.stars = append(repo.stars, time.Now())
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveStarred answers whether a repository has been starred, with no
+// content if it has and not found if it hasn't. The caller must hold s.mu.
+func (s *Server) serveStarred(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Header.Get("Authorization") == "" {
+		writeJSON(w, http.StatusUnauthorized, message("Requires authentication"))
+		return
+	}
+	if repo, ok := s.repos[strings.ToLower(name)]; ok && repo.starred {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusNotFound, message("Not Found"))
+}
+
+// serveUnstar unstars a repository, removing the stargazer that starring it
+// added. The caller must hold s.mu.
+func (s *Server) serveUnstar(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Header.Get("Authorization") == "" {
+		writeJSON(w, http.StatusUnauthorized, message("Requires authentication"))
+		return
+	}
+	repo, ok := s.repos[strings.ToLower(name)]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, message("Not Found"))
+		return
+	}
+	if repo.starred {
+		repo.starred = false
+		if n := len(repo.stars); n > 0 {
+			repo.stars = repo.stars[:n-1]
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveGraphQL answers the aliased repository queries that
+// stargazer.GraphQLBatch makes, with the stargazerCount, databaseId and
+// nameWithOwner of each. Repositories that aren't served are null,
+// as GitHub reports repositories it can't resolve. The caller must hold
+// s.mu.
+func (s *Server) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query string `json:"query"`
+	}
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, message("Problems parsing JSON"))
+		return
+	}
+	data := make(map[string]interface{})
+	for _, m := range graphqlAlias.FindAllStringSubmatch(req.Query, -1) {
+		var owner, name string
+		json.Unmarshal([]byte(m[2]), &owner)
+		json.Unmarshal([]byte(m[3]), &name)
+		repo, ok := s.repos[strings.ToLower(owner+"/"+name)]
+		if !ok {
+			data[m[1]] = nil
+			continue
+		}
+		data[m[1]] = map[string]interface{}{
+			"stargazerCount": len(repo.stars),
+			"databaseId":     repo.id,
+			"nameWithOwner":  repo.name,
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func message(msg string) map[string]string {
+	return map[string]string{"message": msg}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,112 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GitLabSource is a Source of the number of stars of a project on GitLab.com
+// or a self-hosted GitLab instance.
+type GitLabSource struct {
+	// Project is the path of the project, such as group/subgroup/project.
+	Project string
+
+	baseURL string
+	token   TokenSource
+	client  *http.Client
+	editors []RequestEditor
+}
+
+// NewGitLabSource returns a Source of the number of stars of project on
+// GitLab.com, unless another instance is set with WithGitLabBaseURL.
+func NewGitLabSource(project string, options ...func(*GitLabSource)) (*GitLabSource, error) {
+	if project == "" {
+		return nil, errors.New("project must be specified")
+	}
+	gs := &GitLabSource{
+		Project: strings.Trim(project, "/"),
+		baseURL: "https://gitlab.com",
+		client:  &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, o := range options {
+		o(gs)
+	}
+	if _, err := url.Parse(gs.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid GitLab base URL")
+	}
+	return gs, nil
+}
+
+// WithGitLabBaseURL is an option that can be passed to NewGitLabSource to
+// fetch from a self-hosted GitLab instance, such as
+// https://gitlab.example.com.
+func WithGitLabBaseURL(baseURL string) func(*GitLabSource) {
+	return func(gs *GitLabSource) {
+		gs.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithGitLabHTTPClient is an option that can be passed to NewGitLabSource to
+// make its requests with client instead of a default client with a 20 second
+// timeout.
+func WithGitLabHTTPClient(client *http.Client) func(*GitLabSource) {
+	return func(gs *GitLabSource) {
+		if client != nil {
+			gs.client = client
+		}
+	}
+}
+
+// WithGitLabRequestEditor is an option that can be passed to NewGitLabSource
+// to have editors edit every request it makes, in order, after its User-Agent
+// is set.
+func WithGitLabRequestEditor(editors ...RequestEditor) func(*GitLabSource) {
+	return func(gs *GitLabSource) {
+		gs.editors = append(gs.editors, editors...)
+	}
+}
+
+// WithGitLabToken is an option that can be passed to NewGitLabSource to
+// authenticate with a personal or project access token, which private
+// projects require.
+func WithGitLabToken(token string) func(*GitLabSource) {
+	return WithGitLabTokenSource(StaticTokenSource(token))
+}
+
+// WithGitLabTokenSource is an option that can be passed to NewGitLabSource to
+// supply the access token from source each time it is needed.
+func WithGitLabTokenSource(source TokenSource) func(*GitLabSource) {
+	return func(gs *GitLabSource) {
+		gs.token = source
+	}
+}
+
+// Fetch fetches the number of stars of the project.
+func (gs *GitLabSource) Fetch() (int, error) {
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s", gs.baseURL, url.PathEscape(gs.Project))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return -1, err
+	}
+	if gs.token != nil {
+		token, err := gs.token()
+		if err != nil {
+			return -1, errors.Wrap(err, "error getting GitLab token")
+		}
+		if token != "" {
+			req.Header.Add("PRIVATE-TOKEN", token)
+		}
+	}
+	var project struct {
+		StarCount int `json:"star_count"`
+	}
+	if err := getJSON(gs.client, gs.editors, req, "GitLab", &project); err != nil {
+		return -1, err
+	}
+	return project.StarCount, nil
+}
@@ -0,0 +1,154 @@
+package stargazer
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// GoModuleSource is a Source of the number of published versions of a Go
+// module, from the module proxy, or of the number of packages that import
+// it, from pkg.go.dev.
+type GoModuleSource struct {
+	// Module is the path of the module, such as github.com/pkg/errors.
+	Module string
+
+	// Count is what is counted: Versions unless set with WithGoModuleCount,
+	// or ImportedBy.
+	Count Count
+
+	proxyURL   string
+	pkgsiteURL string
+	client     *http.Client
+	editors    []RequestEditor
+}
+
+// NewGoModuleSource returns a Source of the number of published versions of
+// module, unless another count is set with WithGoModuleCount.
+func NewGoModuleSource(module string, options ...func(*GoModuleSource)) (*GoModuleSource, error) {
+	if module == "" {
+		return nil, errors.New("module must be specified")
+	}
+	gs := &GoModuleSource{
+		Module:     strings.Trim(module, "/"),
+		Count:      Versions,
+		proxyURL:   "https://proxy.golang.org",
+		pkgsiteURL: "https://pkg.go.dev",
+		client:     &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, o := range options {
+		o(gs)
+	}
+	switch gs.Count {
+	case Versions, ImportedBy:
+	default:
+		return nil, errors.Errorf("the Go module proxy cannot count %s", gs.Count)
+	}
+	if _, err := url.Parse(gs.proxyURL); err != nil {
+		return nil, errors.Wrap(err, "invalid Go module proxy URL")
+	}
+	if _, err := url.Parse(gs.pkgsiteURL); err != nil {
+		return nil, errors.Wrap(err, "invalid pkg.go.dev URL")
+	}
+	return gs, nil
+}
+
+// WithGoModuleProxyURL is an option that can be passed to NewGoModuleSource
+// to count versions on another module proxy than https://proxy.golang.org.
+func WithGoModuleProxyURL(proxyURL string) func(*GoModuleSource) {
+	return func(gs *GoModuleSource) {
+		gs.proxyURL = strings.TrimRight(proxyURL, "/")
+	}
+}
+
+// WithPkgsiteURL is an option that can be passed to NewGoModuleSource to
+// count importers on another instance of pkgsite than https://pkg.go.dev.
+func WithPkgsiteURL(pkgsiteURL string) func(*GoModuleSource) {
+	return func(gs *GoModuleSource) {
+		gs.pkgsiteURL = strings.TrimRight(pkgsiteURL, "/")
+	}
+}
+
+// WithGoModuleHTTPClient is an option that can be passed to NewGoModuleSource
+// to make its requests with client instead of a default client with a 20
+// second timeout.
+func WithGoModuleHTTPClient(client *http.Client) func(*GoModuleSource) {
+	return func(gs *GoModuleSource) {
+		if client != nil {
+			gs.client = client
+		}
+	}
+}
+
+// WithGoModuleRequestEditor is an option that can be passed to
+// NewGoModuleSource to have editors edit every request it makes, in order,
+// after its User-Agent is set.
+func WithGoModuleRequestEditor(editors ...RequestEditor) func(*GoModuleSource) {
+	return func(gs *GoModuleSource) {
+		gs.editors = append(gs.editors, editors...)
+	}
+}
+
+// WithGoModuleCount is an option that can be passed to NewGoModuleSource to
+// count ImportedBy instead of Versions.
+func WithGoModuleCount(count Count) func(*GoModuleSource) {
+	return func(gs *GoModuleSource) {
+		gs.Count = count
+	}
+}
+
+// importedByPattern finds the number of importers in a pkg.go.dev page,
+// which has no API for it.
+var importedByPattern = regexp.MustCompile(`Imported by:\s*(?:<[^>]*>\s*)*([\d,]+)`)
+
+// Fetch fetches the count of the module.
+func (gs *GoModuleSource) Fetch() (int, error) {
+	if gs.Count == ImportedBy {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s", gs.pkgsiteURL, gs.Module), nil)
+		if err != nil {
+			return -1, err
+		}
+		page, err := getBody(gs.client, gs.editors, req, "pkg.go.dev")
+		if err != nil {
+			return -1, err
+		}
+		match := importedByPattern.FindSubmatch(page)
+		if match == nil {
+			return -1, errors.Errorf("no imported by count on pkg.go.dev page for %s", gs.Module)
+		}
+		return strconv.Atoi(strings.ReplaceAll(string(match[1]), ",", ""))
+	}
+	endpoint := fmt.Sprintf("%s/%s/@v/list", gs.proxyURL, escapeModulePath(gs.Module))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return -1, err
+	}
+	list, err := getBody(gs.client, gs.editors, req, "Go module proxy")
+	if err != nil {
+		return -1, err
+	}
+	return len(bytes.Fields(list)), nil
+}
+
+// escapeModulePath escapes a module path for the module proxy protocol,
+// which replaces each upper case letter with an exclamation mark followed by
+// the letter in lower case.
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,393 @@
+package stargazer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GraphQLBatch fetches the stargazers counts of many GitHub repositories
+// together, along with their metadata, asking GitHub's GraphQL API for up to
+// BatchSize of them in each request, so that watching a hundred repositories
+// costs about one request per poll instead of a hundred. Gazers given the
+// same batch with WithGraphQLBatch share its results: a gazer's poll fetches
+// the counts of every repository in the batch if they are older than MaxAge,
+// and otherwise reuses them. The GraphQL API requires a token. A GraphQLBatch
+// is safe for concurrent use.
+type GraphQLBatch struct {
+	// BatchSize is how many repositories are asked for in each request.
+	BatchSize int
+
+	// MaxAge is how long fetched counts are reused for.
+	MaxAge time.Duration
+
+	endpoint string
+	token    TokenSource
+	client   *http.Client
+	editors  []RequestEditor
+	metrics  Metrics
+
+	// scheduler, if it is set, has the hourly budget that each request
+	// counts against.
+	scheduler *Scheduler
+
+	// rateLimitMu guards rateLimit, the GraphQL API rate limit as of the
+	// most recent response, apart from mu so that reading it doesn't wait
+	// for a fetch.
+	rateLimitMu sync.Mutex
+	rateLimit   RateLimit
+
+	// mu is held while fetching, so that gazers polling at the same time
+	// wait for one fetch rather than each making their own.
+	mu      sync.Mutex
+	repos   map[string]int
+	results map[string]batchResult
+	errs    map[string]error
+	fetched time.Time
+}
+
+// batchResult is the stargazers count and metadata of a repository, as
+// fetched by a GraphQLBatch.
+type batchResult struct {
+	count    int
+	metadata RepoMetadata
+}
+
+// graphQLRepoFields are the fields asked for of each repository, from which
+// its count and metadata are read as the REST API gives them.
+const graphQLRepoFields = "stargazerCount databaseId nameWithOwner description " +
+	"primaryLanguage { name } repositoryTopics(first: 20) { nodes { topic { name } } } " +
+	"licenseInfo { spdxId name } isArchived forkCount pushedAt " +
+	"issues(states: OPEN) { totalCount } pullRequests(states: OPEN) { totalCount }"
+
+// graphQLRepo is a repository as the GraphQL API describes it.
+type graphQLRepo struct {
+	StargazerCount  int    `json:"stargazerCount"`
+	DatabaseID      int64  `json:"databaseId"`
+	NameWithOwner   string `json:"nameWithOwner"`
+	Description     string `json:"description"`
+	PrimaryLanguage *struct {
+		Name string `json:"name"`
+	} `json:"primaryLanguage"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+	LicenseInfo *struct {
+		SPDXID string `json:"spdxId"`
+		Name   string `json:"name"`
+	} `json:"licenseInfo"`
+	IsArchived bool      `json:"isArchived"`
+	ForkCount  int       `json:"forkCount"`
+	PushedAt   time.Time `json:"pushedAt"`
+	Issues     struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"issues"`
+	PullRequests struct {
+		TotalCount int `json:"totalCount"`
+	} `json:"pullRequests"`
+}
+
+// metadata returns the metadata of r, with its open issues counting open
+// pull requests too, as the REST API counts them.
+func (r *graphQLRepo) metadata() RepoMetadata {
+	m := RepoMetadata{
+		ID:          r.DatabaseID,
+		FullName:    r.NameWithOwner,
+		Description: r.Description,
+		Archived:    r.IsArchived,
+		OpenIssues:  r.Issues.TotalCount + r.PullRequests.TotalCount,
+		Forks:       r.ForkCount,
+		PushedAt:    r.PushedAt,
+	}
+	if r.PrimaryLanguage != nil {
+		m.Language = r.PrimaryLanguage.Name
+	}
+	for _, node := range r.RepositoryTopics.Nodes {
+		m.Topics = append(m.Topics, node.Topic.Name)
+	}
+	if r.LicenseInfo != nil {
+		m.License = licenseName(r.LicenseInfo.SPDXID, r.LicenseInfo.Name)
+	}
+	return m
+}
+
+// NewGraphQLBatch returns a batch that asks for up to 100 repositories per
+// request and reuses counts for 30 seconds, unless the options say otherwise.
+func NewGraphQLBatch(options ...func(*GraphQLBatch)) (*GraphQLBatch, error) {
+	b := &GraphQLBatch{
+		BatchSize: 100,
+		MaxAge:    30 * time.Second,
+		endpoint:  "https://api.github.com/graphql",
+		client:    &http.Client{Timeout: 20 * time.Second},
+		metrics:   nopMetrics{},
+		repos:     make(map[string]int),
+	}
+	for _, o := range options {
+		o(b)
+	}
+	if b.BatchSize < 1 {
+		return nil, errors.New("batch size must be at least 1")
+	}
+	if _, err := url.Parse(b.endpoint); err != nil {
+		return nil, errors.Wrap(err, "invalid GitHub GraphQL endpoint")
+	}
+	return b, nil
+}
+
+// WithGraphQLBatchSize is an option that can be passed to NewGraphQLBatch to
+// set how many repositories are asked for in each request.
+func WithGraphQLBatchSize(size int) func(*GraphQLBatch) {
+	return func(b *GraphQLBatch) {
+		b.BatchSize = size
+	}
+}
+
+// WithGraphQLMaxAge is an option that can be passed to NewGraphQLBatch to set
+// how long fetched counts are reused for.
+func WithGraphQLMaxAge(maxAge time.Duration) func(*GraphQLBatch) {
+	return func(b *GraphQLBatch) {
+		b.MaxAge = maxAge
+	}
+}
+
+// WithGraphQLBaseURL is an option that can be passed to NewGraphQLBatch to
+// talk to a GitHub Enterprise Server instance instead of github.com. Like
+// WithGitHubBaseURL, either the server's root URL or its REST API root may be
+// given.
+func WithGraphQLBaseURL(baseURL string) func(*GraphQLBatch) {
+	return func(b *GraphQLBatch) {
+		b.endpoint = graphQLEndpoint(githubAPIRoot(baseURL))
+	}
+}
+
+// graphQLEndpoint returns the GraphQL endpoint of the GitHub whose REST API
+// root is root: beside the REST API on GitHub Enterprise Server, and under it
+// on github.com.
+func graphQLEndpoint(root string) string {
+	if strings.HasSuffix(root, "/api/v3") {
+		return strings.TrimSuffix(root, "/v3") + "/graphql"
+	}
+	return root + "/graphql"
+}
+
+// WithGraphQLTokenSource is an option that can be passed to NewGraphQLBatch
+// to supply the GitHub token that requests are made with.
+func WithGraphQLTokenSource(source TokenSource) func(*GraphQLBatch) {
+	return func(b *GraphQLBatch) {
+		b.token = source
+	}
+}
+
+// WithGraphQLHTTPClient is an option that can be passed to NewGraphQLBatch to
+// make its requests with client instead of a default client with a 20 second
+// timeout.
+func WithGraphQLHTTPClient(client *http.Client) func(*GraphQLBatch) {
+	return func(b *GraphQLBatch) {
+		if client != nil {
+			b.client = client
+		}
+	}
+}
+
+// WithGraphQLRequestEditor is an option that can be passed to NewGraphQLBatch
+// to have editors edit every request it makes, in order, after its
+// User-Agent is set.
+func WithGraphQLRequestEditor(editors ...RequestEditor) func(*GraphQLBatch) {
+	return func(b *GraphQLBatch) {
+		b.editors = append(b.editors, editors...)
+	}
+}
+
+// WithGraphQLMetrics is an option that can be passed to NewGraphQLBatch to
+// have the batch report the GraphQL API rate limit to metrics.
+func WithGraphQLMetrics(metrics Metrics) func(*GraphQLBatch) {
+	return func(b *GraphQLBatch) {
+		b.metrics = metrics
+	}
+}
+
+// WithGraphQLScheduler is an option that can be passed to NewGraphQLBatch to
+// have each of its requests count against the hourly budget of scheduler,
+// the one its gazers are given with WithScheduler, waiting if it is used up.
+func WithGraphQLScheduler(scheduler *Scheduler) func(*GraphQLBatch) {
+	return func(b *GraphQLBatch) {
+		b.scheduler = scheduler
+	}
+}
+
+// WithGraphQLBatch is an option that can be passed to NewGitHubStargazer to
+// fetch the stargazers count and metadata with batch, together with those of
+// the other gazers using it, instead of with a REST request of its own.
+func WithGraphQLBatch(batch *GraphQLBatch) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.batch = batch
+	}
+}
+
+// add adds repo to the repositories fetched while its gazer gazes.
+// Repositories are counted, so that each add must be matched by a remove.
+func (b *GraphQLBatch) add(repo string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.repos[repo]++
+}
+
+func (b *GraphQLBatch) remove(repo string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.repos[repo]--; b.repos[repo] <= 0 {
+		delete(b.repos, repo)
+	}
+}
+
+// repository returns the stargazers count and metadata of repo, fetching
+// those of every repository in the batch, along with repo, if they are older
+// than MaxAge or repo's haven't been fetched yet.
+func (b *GraphQLBatch) repository(repo string) (int, RepoMetadata, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, fetched := b.results[repo]
+	if _, failed := b.errs[repo]; failed {
+		fetched = true
+	}
+	if !fetched || time.Since(b.fetched) > b.MaxAge {
+		b.fetch(repo)
+	}
+	if err, ok := b.errs[repo]; ok {
+		return -1, RepoMetadata{}, err
+	}
+	if r, ok := b.results[repo]; ok {
+		return r.count, r.metadata, nil
+	}
+	return -1, RepoMetadata{}, errors.Errorf("%s is not in the batch", repo)
+}
+
+// fetch fetches the counts of every repository in the batch and of extra,
+// which may not be in it. The caller must hold b.mu.
+func (b *GraphQLBatch) fetch(extra string) {
+	repos := make([]string, 0, len(b.repos)+1)
+	for repo := range b.repos {
+		repos = append(repos, repo)
+	}
+	if _, ok := b.repos[extra]; !ok {
+		repos = append(repos, extra)
+	}
+	sort.Strings(repos)
+	b.results = make(map[string]batchResult, len(repos))
+	b.errs = make(map[string]error)
+	for start := 0; start < len(repos); start += b.BatchSize {
+		end := start + b.BatchSize
+		if end > len(repos) {
+			end = len(repos)
+		}
+		chunk := repos[start:end]
+		results, err := b.fetchChunk(chunk)
+		for _, repo := range chunk {
+			switch {
+			case err != nil:
+				b.errs[repo] = err
+			case results[repo] == nil:
+				b.errs[repo] = fmt.Errorf("repository %s: %w", repo, ErrNotFound)
+			default:
+				b.results[repo] = *results[repo]
+			}
+		}
+	}
+	b.fetched = time.Now()
+}
+
+// fetchChunk asks for the counts and metadata of repos in one request,
+// aliasing each repository's query by its index. Repositories that GitHub
+// couldn't resolve are nil.
+func (b *GraphQLBatch) fetchChunk(repos []string) (map[string]*batchResult, error) {
+	var query strings.Builder
+	query.WriteString("query {")
+	for i, repo := range repos {
+		r, err := ParseRepo(repo)
+		if err != nil {
+			return nil, err
+		}
+		ownerJSON, _ := json.Marshal(r.Owner)
+		nameJSON, _ := json.Marshal(r.Name)
+		fmt.Fprintf(&query, " r%d: repository(owner: %s, name: %s) { %s }", i, ownerJSON, nameJSON, graphQLRepoFields)
+	}
+	query.WriteString(" }")
+	reqBody, err := json.Marshal(map[string]string{"query": query.String()})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", b.endpoint, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.token != nil {
+		token, err := b.token()
+		if err != nil {
+			return nil, errors.Wrap(err, "error getting GitHub token")
+		}
+		req.Header.Set("Authorization", "bearer "+token)
+	}
+	if b.scheduler != nil {
+		if err := b.scheduler.reserve(); err != nil {
+			return nil, err
+		}
+	}
+	var resp struct {
+		Data   map[string]*graphQLRepo `json:"data"`
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	req.Header.Set("Accept", "application/json")
+	body, header, err := getResponse(b.client, b.editors, req, "GitHub GraphQL")
+	if header != nil {
+		b.updateRateLimit(header)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "error decoding GitHub GraphQL JSON response")
+	}
+	if resp.Data == nil && len(resp.Errors) > 0 {
+		return nil, errors.Errorf("error during GitHub GraphQL API call: %s", resp.Errors[0].Message)
+	}
+	results := make(map[string]*batchResult, len(repos))
+	for i, repo := range repos {
+		if r := resp.Data[fmt.Sprintf("r%d", i)]; r != nil {
+			results[repo] = &batchResult{count: r.StargazerCount, metadata: r.metadata()}
+		}
+	}
+	return results, nil
+}
+
+// RateLimit returns the GitHub GraphQL API rate limit as of the most recent
+// response, which is separate from the REST API rate limit of the gazers.
+func (b *GraphQLBatch) RateLimit() RateLimit {
+	b.rateLimitMu.Lock()
+	defer b.rateLimitMu.Unlock()
+	return b.rateLimit
+}
+
+func (b *GraphQLBatch) updateRateLimit(h http.Header) {
+	if rl, ok := rateLimitFromHeader(h); ok {
+		b.rateLimitMu.Lock()
+		b.rateLimit = rl
+		b.rateLimitMu.Unlock()
+		b.metrics.Gauge(MetricGitHubGraphQLRateLimitRemaining, float64(rl.Remaining))
+	}
+}
@@ -0,0 +1,155 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HackerNewsSource is a MentionSource of Hacker News submissions linking to
+// a repository, found with the Algolia Hacker News search API.
+type HackerNewsSource struct {
+	// Link is the address that submissions link to, without its scheme, such
+	// as github.com/owner/repo. Submissions linking to pages under it count
+	// too.
+	Link string
+
+	baseURL string
+	client  *http.Client
+	editors []RequestEditor
+}
+
+// NewHackerNewsSource returns a source of Hacker News submissions linking to
+// link, such as github.com/owner/repo.
+func NewHackerNewsSource(link string, options ...func(*HackerNewsSource)) (*HackerNewsSource, error) {
+	link = strings.TrimPrefix(strings.TrimPrefix(link, "https://"), "http://")
+	if link == "" {
+		return nil, errors.New("link must be specified")
+	}
+	hs := &HackerNewsSource{
+		Link:    strings.TrimRight(link, "/"),
+		baseURL: "https://hn.algolia.com",
+		client:  &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, o := range options {
+		o(hs)
+	}
+	if _, err := url.Parse(hs.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid Hacker News search base URL")
+	}
+	return hs, nil
+}
+
+// WithHackerNewsBaseURL is an option that can be passed to
+// NewHackerNewsSource to use another base URL for the search API than
+// https://hn.algolia.com.
+func WithHackerNewsBaseURL(baseURL string) func(*HackerNewsSource) {
+	return func(hs *HackerNewsSource) {
+		hs.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHackerNewsHTTPClient is an option that can be passed to
+// NewHackerNewsSource to make its requests with client instead of a default
+// client with a 20 second timeout.
+func WithHackerNewsHTTPClient(client *http.Client) func(*HackerNewsSource) {
+	return func(hs *HackerNewsSource) {
+		if client != nil {
+			hs.client = client
+		}
+	}
+}
+
+// WithHackerNewsRequestEditor is an option that can be passed to
+// NewHackerNewsSource to have editors edit every request it makes, in order,
+// after its User-Agent is set.
+func WithHackerNewsRequestEditor(editors ...RequestEditor) func(*HackerNewsSource) {
+	return func(hs *HackerNewsSource) {
+		hs.editors = append(hs.editors, editors...)
+	}
+}
+
+type hackerNewsHit struct {
+	ObjectID    string `json:"objectID"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Points      int    `json:"points"`
+	NumComments int    `json:"num_comments"`
+	CreatedAtI  int64  `json:"created_at_i"`
+}
+
+// Mentions returns the submissions linking to the link, marking those that
+// are on the front page.
+func (hs *HackerNewsSource) Mentions() ([]Mention, error) {
+	stories, err := hs.search("story")
+	if err != nil {
+		return nil, err
+	}
+	front, err := hs.search("front_page")
+	if err != nil {
+		return nil, err
+	}
+	onFrontPage := make(map[string]bool, len(front))
+	for _, hit := range front {
+		onFrontPage[hit.ObjectID] = true
+	}
+	var mentions []Mention
+	for _, hit := range stories {
+		// The search matches words, so check that the submission really
+		// links to the link.
+		if !linksTo(hit.URL, hs.Link) {
+			continue
+		}
+		mentions = append(mentions, Mention{
+			Site:      "Hacker News",
+			ID:        hit.ObjectID,
+			Title:     hit.Title,
+			Link:      "https://news.ycombinator.com/item?id=" + hit.ObjectID,
+			Points:    hit.Points,
+			Comments:  hit.NumComments,
+			FrontPage: onFrontPage[hit.ObjectID],
+			Time:      time.Unix(hit.CreatedAtI, 0),
+		})
+	}
+	return mentions, nil
+}
+
+// search finds the submissions with tag whose URLs match the link.
+func (hs *HackerNewsSource) search(tag string) ([]hackerNewsHit, error) {
+	q := url.Values{
+		"query":                        {hs.Link},
+		"restrictSearchableAttributes": {"url"},
+		"tags":                         {tag},
+		"hitsPerPage":                  {"100"},
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/search_by_date?%s", hs.baseURL, q.Encode())
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Hits []hackerNewsHit `json:"hits"`
+	}
+	if err := getJSON(hs.client, hs.editors, req, "Hacker News search", &result); err != nil {
+		return nil, err
+	}
+	return result.Hits, nil
+}
+
+// linksTo reports whether the URL u is link, or a page under it.
+func linksTo(u, link string) bool {
+	u = strings.ToLower(u)
+	link = strings.ToLower(link)
+	for _, prefix := range []string{"https://", "http://", "www."} {
+		u = strings.TrimPrefix(u, prefix)
+	}
+	if !strings.HasPrefix(u, link) {
+		return false
+	}
+	rest := u[len(link):]
+	return rest == "" || strings.ContainsAny(rest[:1], "/?#")
+}
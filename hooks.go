@@ -0,0 +1,151 @@
+package stargazer
+
+import (
+	"sync"
+	"time"
+)
+
+// HookID identifies a milestone hook registered with AddHook, so that it can
+// be removed with RemoveHook.
+type HookID int
+
+// hookSet is the set of milestone hooks registered with a gazer, run in the
+// order they were added. It is safe for concurrent use.
+type hookSet struct {
+	mu    sync.Mutex
+	next  HookID
+	hooks []registeredHook
+}
+
+type registeredHook struct {
+	id   HookID
+	hook func(Milestone) error
+}
+
+func (hs *hookSet) add(hook func(Milestone) error) HookID {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	hs.next++
+	hs.hooks = append(hs.hooks, registeredHook{id: hs.next, hook: hook})
+	return hs.next
+}
+
+func (hs *hookSet) remove(id HookID) bool {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	for i, rh := range hs.hooks {
+		if rh.id == id {
+			hs.hooks = append(hs.hooks[:i:i], hs.hooks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// snapshot returns the registered hooks, so that they can be run without
+// holding the lock while hooks add or remove others.
+func (hs *hookSet) snapshot() []registeredHook {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	return append([]registeredHook(nil), hs.hooks...)
+}
+
+// AddHook registers hook to be run once for each target or milestone that is
+// reached, after any hooks already registered. Each hook's error is logged
+// without stopping the others from running. The returned HookID can be
+// passed to RemoveHook.
+func (sg *GitHubStargazer) AddHook(hook func(Milestone) error) HookID {
+	return sg.hooks.add(hook)
+}
+
+// RemoveHook unregisters the hook with id, reporting whether it was
+// registered.
+func (sg *GitHubStargazer) RemoveHook(id HookID) bool {
+	return sg.hooks.remove(id)
+}
+
+// HookRetry is how failing milestone hooks are handled. The zero value runs
+// each hook once and gives up if it fails.
+type HookRetry struct {
+	// Retries is how many more times a failing hook is run before giving up
+	// or re-arming it. Retries hold up polling while they wait. Hooks that
+	// fail with an error IsRetriable rejects aren't retried.
+	Retries int
+
+	// Backoff is how long to wait before the first retry. The wait doubles
+	// for each retry after that.
+	Backoff time.Duration
+
+	// Rearm has a hook that still fails after its retries run again at each
+	// poll until it succeeds, instead of being given up on.
+	Rearm bool
+}
+
+// WithHookRetry is an option that can be passed to NewGitHubStargazer to set
+// how failing milestone hooks are retried.
+func WithHookRetry(policy HookRetry) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.hookRetry = policy
+	}
+}
+
+// rearmedHook is a milestone hook that failed and is to be run again at the
+// next poll.
+type rearmedHook struct {
+	id        HookID
+	milestone Milestone
+}
+
+func (hs *hookSet) get(id HookID) (func(Milestone) error, bool) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	for _, rh := range hs.hooks {
+		if rh.id == id {
+			return rh.hook, true
+		}
+	}
+	return nil, false
+}
+
+// runHook runs a milestone hook, retrying it according to the retry policy
+// unless it fails with an error that isn't worth retrying, such as
+// ErrUnauthorized. If it still fails, it is re-armed if the policy says so.
+func (sg *GitHubStargazer) runHook(rh registeredHook, m Milestone) {
+	backoff := sg.hookRetry.Backoff
+	err := rh.hook(m)
+	for i := 0; IsRetriable(err) && i < sg.hookRetry.Retries; i++ {
+		sg.log.Infow("retrying stargazer target hit hook function",
+			"repo", sg.Repository,
+			"target", m.Target,
+			"hook", rh.id,
+			"backoff", backoff,
+			"err", err)
+		sg.clock.Sleep(backoff)
+		backoff *= 2
+		err = rh.hook(m)
+	}
+	if err != nil {
+		sg.log.Infow("error calling stargazer target hit hook function",
+			"repo", sg.Repository,
+			"target", m.Target,
+			"hook", rh.id,
+			"rearm", sg.hookRetry.Rearm,
+			"err", err)
+		if sg.hookRetry.Rearm {
+			sg.rearmed = append(sg.rearmed, rearmedHook{id: rh.id, milestone: m})
+		}
+	}
+	sg.hookDone("milestone", err)
+}
+
+// runRearmed runs the milestone hooks that were re-armed after failing, other
+// than any that have since been removed.
+func (sg *GitHubStargazer) runRearmed() {
+	rearmed := sg.rearmed
+	sg.rearmed = nil
+	for _, r := range rearmed {
+		if hook, ok := sg.hooks.get(r.id); ok {
+			sg.runHook(registeredHook{id: r.id, hook: hook}, r.milestone)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package stargazer_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/ianfoo/github-stargazer/githubtest"
+	"github.com/ianfoo/github-stargazer/twiliotest"
+)
+
+func TestHookRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   stargazer.HookRetry
+		failures int
+
+		// wantSent is how many messages the hook gets through, and wantCalls
+		// how many times it is called.
+		wantSent  int
+		wantCalls int
+	}{
+		{
+			name:      "succeeds",
+			wantSent:  1,
+			wantCalls: 1,
+		},
+		{
+			name:      "fails once and is given up on",
+			failures:  1,
+			wantSent:  0,
+			wantCalls: 1,
+		},
+		{
+			name:      "succeeds on a retry",
+			policy:    stargazer.HookRetry{Retries: 2, Backoff: time.Millisecond},
+			failures:  2,
+			wantSent:  1,
+			wantCalls: 3,
+		},
+		{
+			name:      "re-armed until it succeeds",
+			policy:    stargazer.HookRetry{Rearm: true},
+			failures:  3,
+			wantSent:  1,
+			wantCalls: 4,
+		},
+		{
+			name:      "retried then re-armed until it succeeds",
+			policy:    stargazer.HookRetry{Retries: 1, Backoff: time.Millisecond, Rearm: true},
+			failures:  3,
+			wantSent:  1,
+			wantCalls: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gh := githubtest.NewServer()
+			defer gh.Close()
+			gh.SetStargazers("matryer/moq", 100)
+
+			tw := twiliotest.NewServer()
+			defer tw.Close()
+			tw.FailNext(tt.failures, http.StatusServiceUnavailable, 20503, "Service unavailable")
+			sender, err := stargazer.NewTwilioSMSSender("AC123", "token", "+15005550006",
+				stargazer.WithTwilioBaseURL(tw.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			calls := 0
+			hook := func(m stargazer.Milestone) error {
+				calls++
+				return sender.Send("+15005550001", "target reached")
+			}
+			sg, err := stargazer.NewGitHubStargazer("matryer/moq", 100, 10*time.Millisecond, hook,
+				stargazer.WithGitHubBaseURL(gh.URL),
+				stargazer.WithHookRetry(tt.policy))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan struct{})
+			go func() {
+				sg.Gaze()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				sg.Stop()
+				t.Fatal("gazer did not stop once the hook was done with")
+			}
+			if got := len(tw.Messages()); got != tt.wantSent {
+				t.Errorf("sent %d messages, want %d", got, tt.wantSent)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("hook called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
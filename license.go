@@ -0,0 +1,67 @@
+package stargazer
+
+import "time"
+
+// LicenseChange describes a repository that has been relicensed, or had its
+// license added or removed.
+type LicenseChange struct {
+	Repository string
+	Time       time.Time
+
+	// License and Previous are the new and old license, as SPDX identifiers
+	// or names like RepoMetadata.License. Either is empty if the repository
+	// has no license.
+	License  string
+	Previous string
+}
+
+// WithLicenseHook is an option that can be passed to NewGitHubStargazer to
+// have hook run when the license of the repository changes, which is checked
+// from the same response as its count, so it costs nothing more. It is only
+// checked if the gazer watches the stars of a GitHub repository, and nothing
+// is reported for the first fetch, which only records what it is.
+func WithLicenseHook(hook func(LicenseChange) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.LicenseHook = hook
+	}
+}
+
+// checkLicense records the license of the repository from its latest
+// metadata, and reports it, running the license hook, if it differs from the
+// one recorded before.
+func (sg *GitHubStargazer) checkLicense() {
+	sg.mu.Lock()
+	if sg.metadata == nil {
+		sg.mu.Unlock()
+		return
+	}
+	license := sg.metadata.License
+	previous := sg.license
+	sg.license = &license
+	sg.mu.Unlock()
+	if previous == nil || *previous == license {
+		return
+	}
+	c := LicenseChange{
+		Repository: sg.Repository,
+		Time:       sg.clock.Now(),
+		License:    license,
+		Previous:   *previous,
+	}
+	sg.log.Warnw("repository license changed",
+		"repo", sg.Repository,
+		"previous", c.Previous,
+		"license", c.License)
+	sg.saveState()
+	sg.emit(LicenseChanged{c})
+	if sg.LicenseHook == nil {
+		return
+	}
+	err := sg.LicenseHook(c)
+	if err != nil {
+		sg.log.Infow("error calling license hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+	sg.hookDone("license", err)
+}
@@ -0,0 +1,131 @@
+package stargazer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LobstersSource is a MentionSource of Lobsters stories linking to a
+// repository, found among the stories on its front page and its newest
+// ones, as Lobsters has no search API.
+type LobstersSource struct {
+	// Link is the address that stories link to, without its scheme, such
+	// as github.com/owner/repo. Stories linking to pages under it count too.
+	Link string
+
+	baseURL string
+	client  *http.Client
+	editors []RequestEditor
+}
+
+// NewLobstersSource returns a source of Lobsters stories linking to link,
+// such as github.com/owner/repo.
+func NewLobstersSource(link string, options ...func(*LobstersSource)) (*LobstersSource, error) {
+	link = strings.TrimPrefix(strings.TrimPrefix(link, "https://"), "http://")
+	if link == "" {
+		return nil, errors.New("link must be specified")
+	}
+	ls := &LobstersSource{
+		Link:    strings.TrimRight(link, "/"),
+		baseURL: "https://lobste.rs",
+		client:  &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, o := range options {
+		o(ls)
+	}
+	if _, err := url.Parse(ls.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid Lobsters base URL")
+	}
+	return ls, nil
+}
+
+// WithLobstersBaseURL is an option that can be passed to NewLobstersSource to
+// use another base URL than https://lobste.rs.
+func WithLobstersBaseURL(baseURL string) func(*LobstersSource) {
+	return func(ls *LobstersSource) {
+		ls.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithLobstersHTTPClient is an option that can be passed to NewLobstersSource
+// to make its requests with client instead of a default client with a 20
+// second timeout.
+func WithLobstersHTTPClient(client *http.Client) func(*LobstersSource) {
+	return func(ls *LobstersSource) {
+		if client != nil {
+			ls.client = client
+		}
+	}
+}
+
+// WithLobstersRequestEditor is an option that can be passed to
+// NewLobstersSource to have editors edit every request it makes, in order,
+// after its User-Agent is set.
+func WithLobstersRequestEditor(editors ...RequestEditor) func(*LobstersSource) {
+	return func(ls *LobstersSource) {
+		ls.editors = append(ls.editors, editors...)
+	}
+}
+
+type lobstersStory struct {
+	ShortID      string    `json:"short_id"`
+	Title        string    `json:"title"`
+	URL          string    `json:"url"`
+	ShortIDURL   string    `json:"short_id_url"`
+	Score        int       `json:"score"`
+	CommentCount int       `json:"comment_count"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Mentions returns the stories on the front page or among the newest that
+// link to the link, marking those that are on the front page.
+func (ls *LobstersSource) Mentions() ([]Mention, error) {
+	hottest, err := ls.stories("hottest")
+	if err != nil {
+		return nil, err
+	}
+	newest, err := ls.stories("newest")
+	if err != nil {
+		return nil, err
+	}
+	onFrontPage := make(map[string]bool, len(hottest))
+	for _, story := range hottest {
+		onFrontPage[story.ShortID] = true
+	}
+	var mentions []Mention
+	seen := make(map[string]bool)
+	for _, story := range append(hottest, newest...) {
+		if seen[story.ShortID] || !linksTo(story.URL, ls.Link) {
+			continue
+		}
+		seen[story.ShortID] = true
+		mentions = append(mentions, Mention{
+			Site:      "Lobsters",
+			ID:        story.ShortID,
+			Title:     story.Title,
+			Link:      story.ShortIDURL,
+			Points:    story.Score,
+			Comments:  story.CommentCount,
+			FrontPage: onFrontPage[story.ShortID],
+			Time:      story.CreatedAt,
+		})
+	}
+	return mentions, nil
+}
+
+// stories fetches the stories of a listing, such as hottest.
+func (ls *LobstersSource) stories(listing string) ([]lobstersStory, error) {
+	req, err := http.NewRequest("GET", ls.baseURL+"/"+listing+".json", nil)
+	if err != nil {
+		return nil, err
+	}
+	var stories []lobstersStory
+	if err := getJSON(ls.client, ls.editors, req, "Lobsters", &stories); err != nil {
+		return nil, err
+	}
+	return stories, nil
+}
@@ -0,0 +1,51 @@
+package stargazer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger is the structured logger used by GitHubStargazer and
+// TwilioSMSSender. Each method logs msg with context given as alternating
+// keys and values. A *zap.SugaredLogger satisfies Logger as it is, and
+// SlogLogger adapts a *slog.Logger.
+type Logger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// nopLogger discards everything logged to it. It is used when no Logger is
+// configured.
+type nopLogger struct{}
+
+func (nopLogger) Debugw(msg string, keysAndValues ...interface{}) {}
+func (nopLogger) Infow(msg string, keysAndValues ...interface{})  {}
+func (nopLogger) Warnw(msg string, keysAndValues ...interface{})  {}
+func (nopLogger) Errorw(msg string, keysAndValues ...interface{}) {}
+
+// SlogLogger returns a Logger that logs to l.
+func SlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (sl slogLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	sl.l.Log(context.Background(), slog.LevelDebug, msg, keysAndValues...)
+}
+
+func (sl slogLogger) Infow(msg string, keysAndValues ...interface{}) {
+	sl.l.Log(context.Background(), slog.LevelInfo, msg, keysAndValues...)
+}
+
+func (sl slogLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	sl.l.Log(context.Background(), slog.LevelWarn, msg, keysAndValues...)
+}
+
+func (sl slogLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	sl.l.Log(context.Background(), slog.LevelError, msg, keysAndValues...)
+}
@@ -0,0 +1,266 @@
+package stargazer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Mention is a post that links to a repository, such as a Hacker News
+// submission.
+type Mention struct {
+	// Site is where the post is, such as Hacker News.
+	Site string
+
+	// ID identifies the post on its site.
+	ID string
+
+	Title string
+
+	// Link is the address of the post and its discussion.
+	Link string
+
+	// Points is the score of the post, however its site scores posts.
+	Points   int
+	Comments int
+
+	// FrontPage is true if the post is on the site's front page.
+	FrontPage bool
+
+	Time time.Time
+}
+
+// MentionSource finds posts that link to a repository.
+type MentionSource interface {
+	// Mentions returns the posts currently linking to the repository.
+	Mentions() ([]Mention, error)
+}
+
+// MentionWatcher polls a MentionSource, running hooks when new posts link to
+// the repository, when they reach the front page, and when their points
+// cross thresholds. The posts found by the first poll are taken as already
+// known, so hooks only run for what happens after the watcher starts.
+type MentionWatcher struct {
+	// Repository is the name of the repository that is mentioned, used in
+	// logs.
+	Repository string
+
+	// Interval is how often the source is polled.
+	Interval time.Duration
+
+	// NewMentionHook gets run for each new post linking to the repository.
+	NewMentionHook func(Mention) error
+
+	// FrontPageHook gets run when a post reaches the front page.
+	FrontPageHook func(Mention) error
+
+	// PointsHook gets run with the threshold a post's points have crossed.
+	PointsHook func(Mention, int) error
+
+	source     MentionSource
+	thresholds []int
+	log        Logger
+	stopCh     chan struct{}
+
+	// seen are the posts found so far, by ID, along with the highest
+	// threshold each has crossed.
+	seen      map[string]int
+	frontPage map[string]bool
+	polled    bool
+}
+
+// NewMentionWatcher returns a watcher that polls source every interval for
+// posts linking to repo.
+func NewMentionWatcher(
+	repo string,
+	source MentionSource,
+	interval time.Duration,
+	options ...func(*MentionWatcher)) (*MentionWatcher, error) {
+
+	if source == nil {
+		return nil, errors.New("mention source must be specified")
+	}
+	if interval <= 0 {
+		return nil, errors.New("mention interval must be positive")
+	}
+	mw := &MentionWatcher{
+		Repository: repo,
+		Interval:   interval,
+		source:     source,
+		log:        nopLogger{},
+		stopCh:     make(chan struct{}, 1),
+		seen:       make(map[string]int),
+		frontPage:  make(map[string]bool),
+	}
+	for _, o := range options {
+		o(mw)
+	}
+	for _, th := range mw.thresholds {
+		if th < 1 {
+			return nil, errors.New("points thresholds must be at least 1")
+		}
+	}
+	sort.Ints(mw.thresholds)
+	return mw, nil
+}
+
+// WithMentionLogger is an option that can be passed to NewMentionWatcher to
+// set its logger.
+func WithMentionLogger(logger Logger) func(*MentionWatcher) {
+	return func(mw *MentionWatcher) {
+		mw.log = logger
+	}
+}
+
+// WithNewMentionHook is an option that can be passed to NewMentionWatcher to
+// set the hook run for each new post.
+func WithNewMentionHook(hook func(Mention) error) func(*MentionWatcher) {
+	return func(mw *MentionWatcher) {
+		mw.NewMentionHook = hook
+	}
+}
+
+// WithFrontPageHook is an option that can be passed to NewMentionWatcher to
+// set the hook run when a post reaches the front page.
+func WithFrontPageHook(hook func(Mention) error) func(*MentionWatcher) {
+	return func(mw *MentionWatcher) {
+		mw.FrontPageHook = hook
+	}
+}
+
+// WithPointsHook is an option that can be passed to NewMentionWatcher to run
+// hook when a post's points cross any of thresholds.
+func WithPointsHook(hook func(Mention, int) error, thresholds ...int) func(*MentionWatcher) {
+	return func(mw *MentionWatcher) {
+		mw.PointsHook = hook
+		mw.thresholds = append(mw.thresholds, thresholds...)
+	}
+}
+
+// Watch polls the source until Stop is called.
+func (mw *MentionWatcher) Watch() {
+	mw.log.Infow("watching for mentions",
+		"repo", mw.Repository,
+		"poll_interval", mw.Interval)
+	mw.poll()
+	t := time.NewTicker(mw.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			mw.poll()
+		case <-mw.stopCh:
+			return
+		}
+	}
+}
+
+// Stop stops the watcher.
+func (mw *MentionWatcher) Stop() {
+	select {
+	case mw.stopCh <- struct{}{}:
+	default:
+	}
+}
+
+// poll fetches the posts and runs the hooks for whatever has changed since
+// the last poll.
+func (mw *MentionWatcher) poll() {
+	mentions, err := mw.source.Mentions()
+	if err != nil {
+		mw.log.Infow("error fetching mentions", "repo", mw.Repository, "err", err)
+		return
+	}
+	first := !mw.polled
+	mw.polled = true
+	for _, m := range mentions {
+		crossed, known := mw.seen[m.ID]
+		reached := 0
+		for _, th := range mw.thresholds {
+			if m.Points >= th {
+				reached = th
+			}
+		}
+		mw.seen[m.ID] = reached
+		wasFrontPage := mw.frontPage[m.ID]
+		mw.frontPage[m.ID] = m.FrontPage
+		if first {
+			continue
+		}
+		if !known {
+			mw.log.Infow("new mention", "repo", mw.Repository, "site", m.Site, "link", m.Link)
+			mw.runHook("new mention", mw.NewMentionHook, m)
+		}
+		if m.FrontPage && !wasFrontPage {
+			mw.log.Infow("mention reached the front page", "repo", mw.Repository, "site", m.Site, "link", m.Link)
+			mw.runHook("front page", mw.FrontPageHook, m)
+		}
+		if reached > crossed && mw.PointsHook != nil {
+			if err := mw.PointsHook(m, reached); err != nil {
+				mw.log.Infow("error calling mention points hook function",
+					"repo", mw.Repository, "link", m.Link, "err", err)
+			}
+		}
+	}
+}
+
+func (mw *MentionWatcher) runHook(name string, hook func(Mention) error, m Mention) {
+	if hook == nil {
+		return
+	}
+	if err := hook(m); err != nil {
+		mw.log.Infow("error calling mention hook function",
+			"repo", mw.Repository, "hook", name, "link", m.Link, "err", err)
+	}
+}
+
+// SpikeSource finds the post that most likely set off a spike in a
+// repository's count: of the posts from sources made since since, the one
+// with the most points, the newest winning ties. It returns false if there
+// is none. The sources are asked at the same time, so it takes as long as
+// the slowest of them. Sources that fail are skipped, and the error is only
+// returned if every one of them fails.
+func SpikeSource(since time.Time, sources ...MentionSource) (Mention, bool, error) {
+	type result struct {
+		mentions []Mention
+		err      error
+	}
+	results := make([]result, len(sources))
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source MentionSource) {
+			defer wg.Done()
+			mentions, err := source.Mentions()
+			results[i] = result{mentions, err}
+		}(i, source)
+	}
+	wg.Wait()
+	var (
+		likely Mention
+		found  bool
+		errs   []error
+	)
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		for _, m := range r.mentions {
+			if m.Time.Before(since) {
+				continue
+			}
+			if !found || m.Points > likely.Points ||
+				(m.Points == likely.Points && m.Time.After(likely.Time)) {
+				likely, found = m, true
+			}
+		}
+	}
+	if len(sources) > 0 && len(errs) == len(sources) {
+		return Mention{}, false, fmt.Errorf("error fetching mentions from every source: %w", errs[0])
+	}
+	return likely, found, nil
+}
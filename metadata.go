@@ -0,0 +1,103 @@
+package stargazer
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RepoMetadata describes a GitHub repository, as of the most recent time its
+// stargazers count was fetched.
+type RepoMetadata struct {
+	// ID is the repository's numeric ID, which stays the same when it is
+	// renamed or transferred.
+	ID int64 `json:"id"`
+
+	// FullName is the repository's name in owner/repo format, which is
+	// its new name if it has been renamed or transferred.
+	FullName string `json:"full_name"`
+
+	Description string   `json:"description,omitempty"`
+	Language    string   `json:"language,omitempty"`
+	Topics      []string `json:"topics,omitempty"`
+
+	// License is the SPDX identifier of the repository's license, or its
+	// name if GitHub doesn't recognize it as a standard license.
+	License string `json:"license,omitempty"`
+
+	Archived   bool      `json:"archived"`
+	OpenIssues int       `json:"open_issues"`
+	Forks      int       `json:"forks"`
+	PushedAt   time.Time `json:"pushed_at"`
+}
+
+// repoFromJSON decodes the stargazers count and metadata of a repository
+// from its GitHub API representation.
+func repoFromJSON(body []byte) (int, RepoMetadata, error) {
+	var repo struct {
+		ID              int64     `json:"id"`
+		FullName        string    `json:"full_name"`
+		StargazersCount int       `json:"stargazers_count"`
+		Description     string    `json:"description"`
+		Language        string    `json:"language"`
+		Topics          []string  `json:"topics"`
+		Archived        bool      `json:"archived"`
+		OpenIssuesCount int       `json:"open_issues_count"`
+		ForksCount      int       `json:"forks_count"`
+		PushedAt        time.Time `json:"pushed_at"`
+		License         *struct {
+			SPDXID string `json:"spdx_id"`
+			Name   string `json:"name"`
+		} `json:"license"`
+	}
+	if err := json.Unmarshal(body, &repo); err != nil {
+		return -1, RepoMetadata{}, errors.Wrap(err, "error decoding GitHub JSON response")
+	}
+	metadata := RepoMetadata{
+		ID:          repo.ID,
+		FullName:    repo.FullName,
+		Description: repo.Description,
+		Language:    repo.Language,
+		Topics:      repo.Topics,
+		Archived:    repo.Archived,
+		OpenIssues:  repo.OpenIssuesCount,
+		Forks:       repo.ForksCount,
+		PushedAt:    repo.PushedAt,
+	}
+	if repo.License != nil {
+		metadata.License = licenseName(repo.License.SPDXID, repo.License.Name)
+	}
+	return repo.StargazersCount, metadata, nil
+}
+
+// licenseName returns the SPDX identifier of a license, or its name if GitHub
+// doesn't recognize it as a standard license.
+func licenseName(spdxID, name string) string {
+	if spdxID == "" || spdxID == "NOASSERTION" {
+		return name
+	}
+	return spdxID
+}
+
+// Metadata returns the description, language, license and other details of
+// the repository from the most recent time its stargazers count was fetched.
+// It is only available for gazers that watch the stars of a GitHub
+// repository, not those with a Source or counting something else.
+func (sg *GitHubStargazer) Metadata() (RepoMetadata, bool) {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	if sg.metadata == nil {
+		return RepoMetadata{}, false
+	}
+	return *sg.metadata, true
+}
+
+// RepositoryID returns the numeric ID of the repository, or 0 if it hasn't
+// been resolved yet. It is resolved by the first fetch of a gazer that watches
+// the stars of a GitHub repository, or restored with WithState.
+func (sg *GitHubStargazer) RepositoryID() int64 {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.repoID
+}
@@ -0,0 +1,117 @@
+package stargazer
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics receives measurements from GitHubStargazer and TwilioSMSSender so
+// that they can be reported to whatever metrics system the caller uses. Tags
+// are given as alternating keys and values, such as "repo", "owner/repo".
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// Counter adds delta to the named counter.
+	Counter(name string, delta int64, tags ...string)
+
+	// Gauge sets the named gauge to value.
+	Gauge(name string, value float64, tags ...string)
+
+	// Timer records a duration for the named timer.
+	Timer(name string, d time.Duration, tags ...string)
+}
+
+// Names of the metrics reported to Metrics.
+const (
+	// MetricGitHubRequests counts GitHub API requests, tagged with repo and
+	// the response status code, or "error" if no response was received.
+	MetricGitHubRequests = "github.requests"
+
+	// MetricGitHubRequestDuration times GitHub API requests, tagged with
+	// repo.
+	MetricGitHubRequestDuration = "github.request_duration"
+
+	// MetricGitHubRateLimitRemaining is the number of requests left in the
+	// GitHub rate limit, tagged with repo.
+	MetricGitHubRateLimitRemaining = "github.rate_limit_remaining"
+
+	// MetricGitHubGraphQLRateLimitRemaining is the number of requests left
+	// in the GitHub GraphQL API rate limit, as reported to a GraphQLBatch.
+	MetricGitHubGraphQLRateLimitRemaining = "github.graphql_rate_limit_remaining"
+
+	// MetricThrottled is 1 while a gazer polls less often than scheduled,
+	// to conserve the rate limit or because GitHub asked it to back off,
+	// and 0 otherwise, tagged with repo.
+	MetricThrottled = "gazer.throttled"
+
+	// MetricSchedulerBudgetUsed is the number of GitHub API requests made
+	// in the last hour against the hourly budget of a Scheduler, and
+	// MetricSchedulerBudgetRemaining the number the budget still allows.
+	MetricSchedulerBudgetUsed      = "scheduler.budget_used"
+	MetricSchedulerBudgetRemaining = "scheduler.budget_remaining"
+
+	// MetricSchedulerSkippedPolls counts the polls a Scheduler skipped
+	// because they fell due while an earlier poll was waiting to run,
+	// tagged with repo.
+	MetricSchedulerSkippedPolls = "scheduler.skipped_polls"
+
+	// MetricPollFailures counts failed stargazer count fetches, tagged with
+	// repo.
+	MetricPollFailures = "gazer.poll_failures"
+
+	// MetricCircuitOpen is 1 while a gazer's circuit breaker is open or
+	// half-open and 0 once it has closed again, tagged with repo.
+	MetricCircuitOpen = "gazer.circuit_open"
+
+	// MetricStargazers is the most recent stargazers count, tagged with repo.
+	MetricStargazers = "gazer.stargazers"
+
+	// MetricMilestones counts the targets and milestones reached, tagged with
+	// repo.
+	MetricMilestones = "gazer.milestones"
+
+	// MetricTwilioRequests counts Twilio API requests, tagged with the
+	// response status code, or "error" if no response was received.
+	MetricTwilioRequests = "twilio.requests"
+
+	// MetricTwilioRequestDuration times Twilio API requests.
+	MetricTwilioRequestDuration = "twilio.request_duration"
+
+	// MetricSMS counts SMS messages, tagged with result "sent" or "failed".
+	MetricSMS = "twilio.messages"
+)
+
+// nopMetrics discards all measurements. It is used when no Metrics are
+// configured.
+type nopMetrics struct{}
+
+func (nopMetrics) Counter(name string, delta int64, tags ...string)   {}
+func (nopMetrics) Gauge(name string, value float64, tags ...string)   {}
+func (nopMetrics) Timer(name string, d time.Duration, tags ...string) {}
+
+// WithGitHubMetrics is an option that can be passed to NewGitHubStargazer to
+// have the gazer report measurements of its requests and progress to metrics.
+func WithGitHubMetrics(metrics Metrics) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.metrics = metrics
+	}
+}
+
+// WithTwilioMetrics is an option that can be passed to NewTwilioSMSSender to
+// have the sender report measurements of its requests to metrics.
+func WithTwilioMetrics(metrics Metrics) func(*TwilioSMSSender) {
+	return func(ts *TwilioSMSSender) {
+		ts.metrics = metrics
+	}
+}
+
+// observeRequest reports a request, which took elapsed, that returned resp,
+// which is nil if the request failed.
+func observeRequest(metrics Metrics, counter, timer string, elapsed time.Duration, resp *http.Response, tags ...string) {
+	status := "error"
+	if resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	metrics.Timer(timer, elapsed, tags...)
+	metrics.Counter(counter, 1, append(tags, "status", status)...)
+}
@@ -0,0 +1,149 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// npmStatsStart is the first day for which the npm registry has download
+// counts, and npmMaxRange the longest range it will count at once.
+var (
+	npmStatsStart = time.Date(2015, time.January, 10, 0, 0, 0, 0, time.UTC)
+	npmMaxRange   = 540 * 24 * time.Hour
+)
+
+// NpmSource is a Source of the number of downloads of a package from the
+// npm registry. Unlike the other sources, it is not safe for concurrent use.
+type NpmSource struct {
+	// Package is the name of the package, such as left-pad or @scope/pkg.
+	Package string
+
+	// Count is what is counted: WeeklyDownloads unless set with
+	// WithNpmCount, or Downloads.
+	Count Count
+
+	baseURL string
+	client  *http.Client
+	editors []RequestEditor
+
+	// doneDownloads are the downloads before doneUntil, which are added up
+	// once and remembered, as they can't change.
+	doneDownloads int
+	doneUntil     time.Time
+}
+
+// NewNpmSource returns a Source of the number of downloads of pkg in the
+// last week, unless another count is set with WithNpmCount.
+func NewNpmSource(pkg string, options ...func(*NpmSource)) (*NpmSource, error) {
+	if pkg == "" {
+		return nil, errors.New("package must be specified")
+	}
+	ns := &NpmSource{
+		Package:   pkg,
+		Count:     WeeklyDownloads,
+		baseURL:   "https://api.npmjs.org",
+		client:    &http.Client{Timeout: 20 * time.Second},
+		doneUntil: npmStatsStart,
+	}
+	for _, o := range options {
+		o(ns)
+	}
+	switch ns.Count {
+	case WeeklyDownloads, Downloads:
+	default:
+		return nil, errors.Errorf("npm cannot count %s", ns.Count)
+	}
+	if _, err := url.Parse(ns.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid npm API base URL")
+	}
+	return ns, nil
+}
+
+// WithNpmBaseURL is an option that can be passed to NewNpmSource to use
+// another base URL for the npm download counts API than
+// https://api.npmjs.org.
+func WithNpmBaseURL(baseURL string) func(*NpmSource) {
+	return func(ns *NpmSource) {
+		ns.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithNpmHTTPClient is an option that can be passed to NewNpmSource to make
+// its requests with client instead of a default client with a 20 second
+// timeout.
+func WithNpmHTTPClient(client *http.Client) func(*NpmSource) {
+	return func(ns *NpmSource) {
+		if client != nil {
+			ns.client = client
+		}
+	}
+}
+
+// WithNpmRequestEditor is an option that can be passed to NewNpmSource to
+// have editors edit every request it makes, in order, after its User-Agent is
+// set.
+func WithNpmRequestEditor(editors ...RequestEditor) func(*NpmSource) {
+	return func(ns *NpmSource) {
+		ns.editors = append(ns.editors, editors...)
+	}
+}
+
+// WithNpmCount is an option that can be passed to NewNpmSource to count all
+// Downloads instead of WeeklyDownloads.
+func WithNpmCount(count Count) func(*NpmSource) {
+	return func(ns *NpmSource) {
+		ns.Count = count
+	}
+}
+
+// Fetch fetches the number of downloads of the package. The registry only
+// counts up to 18 months of downloads at a time, so counting all of them
+// takes a request for each 18 months the first time.
+func (ns *NpmSource) Fetch() (int, error) {
+	if ns.Count == WeeklyDownloads {
+		return ns.downloads("last-week")
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for ns.doneUntil.Add(npmMaxRange).Before(today) {
+		until := ns.doneUntil.Add(npmMaxRange)
+		n, err := ns.downloads(npmRange(ns.doneUntil, until.Add(-24*time.Hour)))
+		if err != nil {
+			return -1, err
+		}
+		ns.doneDownloads += n
+		ns.doneUntil = until
+	}
+	n, err := ns.downloads(npmRange(ns.doneUntil, today))
+	if err != nil {
+		return -1, err
+	}
+	return ns.doneDownloads + n, nil
+}
+
+// downloads fetches the number of downloads of the package in period, which
+// is a named period like last-week or a range of dates.
+func (ns *NpmSource) downloads(period string) (int, error) {
+	endpoint := fmt.Sprintf("%s/downloads/point/%s/%s", ns.baseURL, period, ns.Package)
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return -1, err
+	}
+	var point struct {
+		Downloads int `json:"downloads"`
+	}
+	if err := getJSON(ns.client, ns.editors, req, "npm", &point); err != nil {
+		return -1, err
+	}
+	return point.Downloads, nil
+}
+
+// npmRange formats the range of days from start to end, inclusive, for the
+// npm download counts API.
+func npmRange(start, end time.Time) string {
+	return start.Format("2006-01-02") + ":" + end.Format("2006-01-02")
+}
@@ -0,0 +1,225 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GitHubOrg lists the repositories of a GitHub organization, so that every
+// one of them can be watched without naming each.
+type GitHubOrg struct {
+	// Org is the login of the organization, such as "golang".
+	Org string
+
+	apiBaseURL string
+	token      TokenSource
+	client     *http.Client
+	editors    []RequestEditor
+}
+
+// ListedRepository is a repository listed by GitHubOrg or GitHubUser.
+type ListedRepository struct {
+	// FullName is the name of the repository in owner/repo format.
+	FullName string `json:"full_name"`
+
+	Archived        bool `json:"archived"`
+	Fork            bool `json:"fork"`
+	Private         bool `json:"private"`
+	StargazersCount int  `json:"stargazers_count"`
+}
+
+// NewGitHubOrg returns a lister of the repositories of org on github.com,
+// unless another instance is set with WithOrgBaseURL.
+func NewGitHubOrg(org string, options ...func(*GitHubOrg)) (*GitHubOrg, error) {
+	org = strings.Trim(org, "/")
+	if org == "" {
+		return nil, errors.New("organization must be specified")
+	}
+	if err := validOwner(org); err != nil {
+		return nil, errors.Wrapf(err, "invalid organization %q", org)
+	}
+	o := &GitHubOrg{
+		Org:        org,
+		apiBaseURL: "https://api.github.com",
+		client:     &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, option := range options {
+		option(o)
+	}
+	if _, err := url.Parse(o.apiBaseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid GitHub API base URL")
+	}
+	return o, nil
+}
+
+// WithOrgBaseURL is an option that can be passed to NewGitHubOrg to talk to a
+// GitHub Enterprise Server instance instead of github.com. Like
+// WithGitHubBaseURL, either the server's root URL or its REST API root may be
+// given.
+func WithOrgBaseURL(baseURL string) func(*GitHubOrg) {
+	return func(o *GitHubOrg) {
+		o.apiBaseURL = githubAPIRoot(baseURL)
+	}
+}
+
+// WithOrgTokenSource is an option that can be passed to NewGitHubOrg to
+// supply the GitHub token that requests are made with, which listing private
+// repositories requires.
+func WithOrgTokenSource(source TokenSource) func(*GitHubOrg) {
+	return func(o *GitHubOrg) {
+		o.token = source
+	}
+}
+
+// WithOrgHTTPClient is an option that can be passed to NewGitHubOrg to make
+// its requests with client instead of a default client with a 20 second
+// timeout.
+func WithOrgHTTPClient(client *http.Client) func(*GitHubOrg) {
+	return func(o *GitHubOrg) {
+		if client != nil {
+			o.client = client
+		}
+	}
+}
+
+// WithOrgRequestEditor is an option that can be passed to NewGitHubOrg to have
+// editors edit every request it makes, in order, after its User-Agent is set.
+func WithOrgRequestEditor(editors ...RequestEditor) func(*GitHubOrg) {
+	return func(o *GitHubOrg) {
+		o.editors = append(o.editors, editors...)
+	}
+}
+
+// Repositories lists every repository of the organization that the token can
+// see, a page of 100 at a time, in the order GitHub lists them.
+func (o *GitHubOrg) Repositories() ([]ListedRepository, error) {
+	endpoint := fmt.Sprintf("%s/orgs/%s/repos?", o.apiBaseURL, url.PathEscape(o.Org))
+	repos, err := listRepositories(o.client, o.token, o.editors, endpoint)
+	if err != nil {
+		return repos, fmt.Errorf("error listing repositories of %s: %w", o.Org, err)
+	}
+	return repos, nil
+}
+
+// listRepositories lists the repositories at endpoint, which is a URL ending
+// in its query string, or a question mark if it has none, a page of 100 at a
+// time.
+func listRepositories(client *http.Client, source TokenSource, editors []RequestEditor, endpoint string) ([]ListedRepository, error) {
+	const perPage = 100
+	var repos []ListedRepository
+	for page := 1; ; page++ {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%sper_page=%d&page=%d", endpoint, perPage, page), nil)
+		if err != nil {
+			return nil, err
+		}
+		if source != nil {
+			token, err := source()
+			if err != nil {
+				return nil, errors.Wrap(err, "error getting GitHub token")
+			}
+			if token != "" {
+				req.Header.Add("Authorization", "token "+token)
+			}
+		}
+		var listed []ListedRepository
+		if err := getJSON(client, editors, req, "GitHub", &listed); err != nil {
+			return nil, err
+		}
+		repos = append(repos, listed...)
+		if len(listed) < perPage {
+			return repos, nil
+		}
+	}
+}
+
+// GitHubUser lists the public repositories that a GitHub user owns, so that
+// every one of them can be watched without naming each.
+type GitHubUser struct {
+	// User is the login of the user, such as "octocat".
+	User string
+
+	apiBaseURL string
+	token      TokenSource
+	client     *http.Client
+	editors    []RequestEditor
+}
+
+// NewGitHubUser returns a lister of the repositories of user on github.com,
+// unless another instance is set with WithUserBaseURL.
+func NewGitHubUser(user string, options ...func(*GitHubUser)) (*GitHubUser, error) {
+	user = strings.Trim(user, "/")
+	if user == "" {
+		return nil, errors.New("user must be specified")
+	}
+	if err := validOwner(user); err != nil {
+		return nil, errors.Wrapf(err, "invalid user %q", user)
+	}
+	u := &GitHubUser{
+		User:       user,
+		apiBaseURL: "https://api.github.com",
+		client:     &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, option := range options {
+		option(u)
+	}
+	if _, err := url.Parse(u.apiBaseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid GitHub API base URL")
+	}
+	return u, nil
+}
+
+// WithUserBaseURL is an option that can be passed to NewGitHubUser to talk to
+// a GitHub Enterprise Server instance instead of github.com. Like
+// WithGitHubBaseURL, either the server's root URL or its REST API root may be
+// given.
+func WithUserBaseURL(baseURL string) func(*GitHubUser) {
+	return func(u *GitHubUser) {
+		u.apiBaseURL = githubAPIRoot(baseURL)
+	}
+}
+
+// WithUserTokenSource is an option that can be passed to NewGitHubUser to
+// supply the GitHub token that requests are made with, which raises the rate
+// limit they are made under.
+func WithUserTokenSource(source TokenSource) func(*GitHubUser) {
+	return func(u *GitHubUser) {
+		u.token = source
+	}
+}
+
+// WithUserHTTPClient is an option that can be passed to NewGitHubUser to make
+// its requests with client instead of a default client with a 20 second
+// timeout.
+func WithUserHTTPClient(client *http.Client) func(*GitHubUser) {
+	return func(u *GitHubUser) {
+		if client != nil {
+			u.client = client
+		}
+	}
+}
+
+// WithUserRequestEditor is an option that can be passed to NewGitHubUser to
+// have editors edit every request it makes, in order, after its User-Agent is
+// set.
+func WithUserRequestEditor(editors ...RequestEditor) func(*GitHubUser) {
+	return func(u *GitHubUser) {
+		u.editors = append(u.editors, editors...)
+	}
+}
+
+// Repositories lists every public repository that the user owns, a page of
+// 100 at a time, in the order GitHub lists them. Repositories the user only
+// contributes to aren't listed.
+func (u *GitHubUser) Repositories() ([]ListedRepository, error) {
+	endpoint := fmt.Sprintf("%s/users/%s/repos?type=owner&", u.apiBaseURL, url.PathEscape(u.User))
+	repos, err := listRepositories(u.client, u.token, u.editors, endpoint)
+	if err != nil {
+		return repos, fmt.Errorf("error listing repositories of %s: %w", u.User, err)
+	}
+	return repos, nil
+}
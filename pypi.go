@@ -0,0 +1,115 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PyPISource is a Source of the number of recent downloads of a package from
+// PyPI, as counted by pypistats.org. PyPI itself doesn't count downloads,
+// and pypistats.org doesn't keep them long enough to count them all.
+type PyPISource struct {
+	// Package is the name of the package.
+	Package string
+
+	// Count is what is counted: WeeklyDownloads unless set with
+	// WithPyPICount, or MonthlyDownloads.
+	Count Count
+
+	baseURL string
+	client  *http.Client
+	editors []RequestEditor
+}
+
+// NewPyPISource returns a Source of the number of downloads of pkg in the
+// last week, unless another count is set with WithPyPICount.
+func NewPyPISource(pkg string, options ...func(*PyPISource)) (*PyPISource, error) {
+	if pkg == "" {
+		return nil, errors.New("package must be specified")
+	}
+	ps := &PyPISource{
+		Package: pkg,
+		Count:   WeeklyDownloads,
+		baseURL: "https://pypistats.org",
+		client:  &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, o := range options {
+		o(ps)
+	}
+	switch ps.Count {
+	case WeeklyDownloads, MonthlyDownloads:
+	default:
+		return nil, errors.Errorf("PyPI cannot count %s", ps.Count)
+	}
+	if _, err := url.Parse(ps.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid pypistats base URL")
+	}
+	return ps, nil
+}
+
+// WithPyPIBaseURL is an option that can be passed to NewPyPISource to use
+// another base URL for the pypistats API than https://pypistats.org.
+func WithPyPIBaseURL(baseURL string) func(*PyPISource) {
+	return func(ps *PyPISource) {
+		ps.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithPyPIHTTPClient is an option that can be passed to NewPyPISource to make
+// its requests with client instead of a default client with a 20 second
+// timeout.
+func WithPyPIHTTPClient(client *http.Client) func(*PyPISource) {
+	return func(ps *PyPISource) {
+		if client != nil {
+			ps.client = client
+		}
+	}
+}
+
+// WithPyPIRequestEditor is an option that can be passed to NewPyPISource to
+// have editors edit every request it makes, in order, after its User-Agent is
+// set.
+func WithPyPIRequestEditor(editors ...RequestEditor) func(*PyPISource) {
+	return func(ps *PyPISource) {
+		ps.editors = append(ps.editors, editors...)
+	}
+}
+
+// WithPyPICount is an option that can be passed to NewPyPISource to count
+// MonthlyDownloads instead of WeeklyDownloads.
+func WithPyPICount(count Count) func(*PyPISource) {
+	return func(ps *PyPISource) {
+		ps.Count = count
+	}
+}
+
+// Fetch fetches the number of recent downloads of the package. pypistats.org
+// only updates its counts daily.
+func (ps *PyPISource) Fetch() (int, error) {
+	// Package names are case insensitive, and pypistats.org only knows
+	// them in lower case.
+	endpoint := fmt.Sprintf("%s/api/packages/%s/recent",
+		ps.baseURL, url.PathEscape(strings.ToLower(ps.Package)))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return -1, err
+	}
+	var recent struct {
+		Data struct {
+			LastWeek  int `json:"last_week"`
+			LastMonth int `json:"last_month"`
+		} `json:"data"`
+	}
+	if err := getJSON(ps.client, ps.editors, req, "pypistats", &recent); err != nil {
+		return -1, err
+	}
+	if ps.Count == MonthlyDownloads {
+		return recent.Data.LastMonth, nil
+	}
+	return recent.Data.LastWeek, nil
+}
@@ -0,0 +1,158 @@
+package stargazer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit is the state of the GitHub API rate limit as of the most recent
+// response.
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// rateLimitFromHeader reads the rate limit headers GitHub sends with every API
+// response. The second return value is false if they are missing or
+// malformed.
+func rateLimitFromHeader(h http.Header) (RateLimit, bool) {
+	limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return RateLimit{}, false
+	}
+	return RateLimit{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}, true
+}
+
+// pollInterval returns how long to wait before the next poll so the remaining
+// requests last until the rate limit resets. It is never shorter than
+// interval, and is exactly interval once the reset time has passed.
+func (rl RateLimit) pollInterval(interval time.Duration, now time.Time) time.Duration {
+	untilReset := rl.Reset.Sub(now)
+	if rl.Limit == 0 || untilReset <= 0 {
+		return interval
+	}
+	if rl.Remaining <= 0 {
+		return untilReset
+	}
+	if spread := untilReset / time.Duration(rl.Remaining); spread > interval {
+		return spread
+	}
+	return interval
+}
+
+// RateLimitError is returned when GitHub refuses a request because a primary
+// or secondary rate limit has been exceeded. RetryAfter is how long GitHub
+// asks to wait before trying again, and Reset when that will be. It matches
+// ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	StatusCode int
+	RetryAfter time.Duration
+	Reset      time.Time
+	Secondary  bool
+	Message    string
+}
+
+// Is reports whether target is ErrRateLimited.
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "rate limit"
+	if e.Secondary {
+		kind = "secondary rate limit"
+	}
+	return fmt.Sprintf("GitHub %s exceeded (status %d), retry after %v: %s",
+		kind, e.StatusCode, e.RetryAfter, e.Message)
+}
+
+// asRateLimitError returns the *RateLimitError that err is or wraps, if
+// there is one.
+func asRateLimitError(err error) (*RateLimitError, bool) {
+	var rlErr *RateLimitError
+	ok := errors.As(err, &rlErr)
+	return rlErr, ok
+}
+
+// secondaryRateLimitWait is how long to wait after hitting a secondary rate
+// limit when GitHub doesn't say, per GitHub's documentation.
+const secondaryRateLimitWait = time.Minute
+
+// rateLimitErrorFromResponse returns a *RateLimitError if resp indicates that
+// a rate limit was exceeded, or nil otherwise. When the status code is 403
+// or 429 the response body is read and then restored, so that it can be read
+// again to build an APIError if no rate limit was exceeded.
+func rateLimitErrorFromResponse(resp *http.Response, now time.Time) *RateLimitError {
+	rlErr := rateLimitError(resp, now)
+	if rlErr != nil {
+		rlErr.Reset = now.Add(rlErr.RetryAfter)
+	}
+	return rlErr
+}
+
+func rateLimitError(resp *http.Response, now time.Time) *RateLimitError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+	message := peekMessage(resp)
+	rlErr := &RateLimitError{StatusCode: resp.StatusCode, Message: message}
+	rlErr.Secondary = strings.Contains(strings.ToLower(message), "secondary rate limit")
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		rlErr.RetryAfter = time.Duration(secs) * time.Second
+		return rlErr
+	}
+	if rl, ok := rateLimitFromHeader(resp.Header); ok && rl.Remaining == 0 {
+		rlErr.RetryAfter = rl.Reset.Sub(now)
+		return rlErr
+	}
+	if rlErr.Secondary || resp.StatusCode == http.StatusTooManyRequests {
+		rlErr.RetryAfter = secondaryRateLimitWait
+		return rlErr
+	}
+	return nil
+}
+
+// peekMessage returns the message of the JSON error body of resp, or the body
+// itself if it isn't JSON, and restores the body so that it can be read
+// again.
+func peekMessage(resp *http.Response) string {
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body = peekedBody{bytes.NewReader(b), resp.Body}
+	if err != nil {
+		return fmt.Sprintf("error reading response body: %v", err)
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return strings.TrimSpace(string(b))
+	}
+	return body.Message
+}
+
+// peekedBody is a response body that has been read, to be read again from
+// its bytes, while closing it closes the original.
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,170 @@
+package stargazer_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/ianfoo/github-stargazer/githubtest"
+)
+
+func TestRateLimitError(t *testing.T) {
+	clock := newFakeClock()
+	now := clock.Now()
+	tests := []struct {
+		name    string
+		status  int
+		header  map[string]string
+		body    string
+		want    *stargazer.RateLimitError
+		wantMsg string // of the *APIError returned if the rate limit wasn't exceeded
+	}{
+		{
+			name:    "forbidden",
+			status:  http.StatusForbidden,
+			body:    `{"message": "Must have push access to repository"}`,
+			wantMsg: "Must have push access to repository",
+		},
+		{
+			name:   "primary rate limit",
+			status: http.StatusForbidden,
+			header: map[string]string{
+				"X-RateLimit-Limit":     "60",
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(now.Add(90*time.Second).Unix(), 10),
+			},
+			body: `{"message": "API rate limit exceeded"}`,
+			want: &stargazer.RateLimitError{
+				StatusCode: http.StatusForbidden,
+				RetryAfter: 90 * time.Second,
+				Message:    "API rate limit exceeded",
+			},
+		},
+		{
+			name:   "secondary rate limit",
+			status: http.StatusForbidden,
+			body:   `{"message": "You have exceeded a secondary rate limit."}`,
+			want: &stargazer.RateLimitError{
+				StatusCode: http.StatusForbidden,
+				RetryAfter: time.Minute,
+				Secondary:  true,
+				Message:    "You have exceeded a secondary rate limit.",
+			},
+		},
+		{
+			name:   "secondary rate limit with Retry-After",
+			status: http.StatusForbidden,
+			header: map[string]string{"Retry-After": "30"},
+			body:   `{"message": "You have exceeded a secondary rate limit."}`,
+			want: &stargazer.RateLimitError{
+				StatusCode: http.StatusForbidden,
+				RetryAfter: 30 * time.Second,
+				Secondary:  true,
+				Message:    "You have exceeded a secondary rate limit.",
+			},
+		},
+		{
+			name:   "too many requests without a body",
+			status: http.StatusTooManyRequests,
+			want: &stargazer.RateLimitError{
+				StatusCode: http.StatusTooManyRequests,
+				RetryAfter: time.Minute,
+			},
+		},
+		{
+			name:   "body that isn't JSON",
+			status: http.StatusTooManyRequests,
+			header: map[string]string{"Retry-After": "5"},
+			body:   "<html>Slow down</html>\n",
+			want: &stargazer.RateLimitError{
+				StatusCode: http.StatusTooManyRequests,
+				RetryAfter: 5 * time.Second,
+				Message:    "<html>Slow down</html>",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+			sg, err := stargazer.NewGitHubStargazer("matryer/moq", 100, time.Minute, nil,
+				stargazer.WithGitHubBaseURL(srv.URL),
+				stargazer.WithClock(clock))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = sg.Prime()
+			if tt.want == nil {
+				var apiErr *stargazer.APIError
+				if !errors.As(err, &apiErr) {
+					t.Fatalf("got error %v, want an *APIError", err)
+				}
+				if apiErr.Message != tt.wantMsg {
+					t.Errorf("got message %q, want %q", apiErr.Message, tt.wantMsg)
+				}
+				if errors.Is(err, stargazer.ErrRateLimited) {
+					t.Errorf("error %v matches ErrRateLimited", err)
+				}
+				return
+			}
+			var rlErr *stargazer.RateLimitError
+			if !errors.As(err, &rlErr) {
+				t.Fatalf("got error %v, want a *RateLimitError", err)
+			}
+			want := *tt.want
+			want.Reset = now.Add(want.RetryAfter)
+			if *rlErr != want {
+				t.Errorf("got %+v, want %+v", *rlErr, want)
+			}
+			if !errors.Is(err, stargazer.ErrRateLimited) {
+				t.Errorf("error %v doesn't match ErrRateLimited", err)
+			}
+		})
+	}
+}
+
+func TestRateLimitErrorFromGitHub(t *testing.T) {
+	srv := githubtest.NewServer()
+	defer srv.Close()
+	srv.SetStargazers("matryer/moq", 99)
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	srv.SetRateLimit(60, 1, reset)
+	sg, err := stargazer.NewGitHubStargazer("matryer/moq", 100, time.Minute, nil,
+		stargazer.WithGitHubBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sg.Prime(); err != nil {
+		t.Fatalf("first poll failed: %v", err)
+	}
+	srv.AddStargazers("matryer/moq", 1)
+	err = sg.Prime()
+	var rlErr *stargazer.RateLimitError
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("got error %v, want a *RateLimitError", err)
+	}
+	if rlErr.Secondary || rlErr.StatusCode != http.StatusForbidden {
+		t.Errorf("got %+v, want a primary rate limit error with status 403", *rlErr)
+	}
+	if rlErr.Message != "API rate limit exceeded" {
+		t.Errorf("got message %q, want %q", rlErr.Message, "API rate limit exceeded")
+	}
+	if rlErr.RetryAfter <= 0 || rlErr.RetryAfter > time.Hour {
+		t.Errorf("got RetryAfter %v, want until the reset at %v", rlErr.RetryAfter, reset)
+	}
+	if rl := sg.RateLimit(); rl.Remaining != 0 || !rl.Reset.Equal(reset) {
+		t.Errorf("got rate limit %+v, want none remaining until %v", rl, reset)
+	}
+}
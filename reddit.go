@@ -0,0 +1,150 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RedditSource is a MentionSource of Reddit posts linking to a repository,
+// found with Reddit's search.
+type RedditSource struct {
+	// Link is the address that posts link to, without its scheme, such as
+	// github.com/owner/repo. Posts linking to pages under it count too.
+	Link string
+
+	// Subreddits are those searched, without their r/ prefix. All of Reddit
+	// is searched if there are none.
+	Subreddits []string
+
+	baseURL string
+	client  *http.Client
+	editors []RequestEditor
+}
+
+// NewRedditSource returns a source of Reddit posts linking to link, such as
+// github.com/owner/repo.
+func NewRedditSource(link string, options ...func(*RedditSource)) (*RedditSource, error) {
+	link = strings.TrimPrefix(strings.TrimPrefix(link, "https://"), "http://")
+	if link == "" {
+		return nil, errors.New("link must be specified")
+	}
+	rs := &RedditSource{
+		Link:    strings.TrimRight(link, "/"),
+		baseURL: "https://www.reddit.com",
+		client:  &http.Client{Timeout: 20 * time.Second},
+	}
+	for _, o := range options {
+		o(rs)
+	}
+	for i, sub := range rs.Subreddits {
+		sub = strings.TrimPrefix(strings.TrimPrefix(sub, "/"), "r/")
+		if sub == "" || strings.ContainsAny(sub, "/+ ") {
+			return nil, errors.Errorf("invalid subreddit %q", rs.Subreddits[i])
+		}
+		rs.Subreddits[i] = sub
+	}
+	if _, err := url.Parse(rs.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid Reddit base URL")
+	}
+	return rs, nil
+}
+
+// WithRedditBaseURL is an option that can be passed to NewRedditSource to
+// use another base URL than https://www.reddit.com.
+func WithRedditBaseURL(baseURL string) func(*RedditSource) {
+	return func(rs *RedditSource) {
+		rs.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithRedditHTTPClient is an option that can be passed to NewRedditSource to
+// make its requests with client instead of a default client with a 20 second
+// timeout.
+func WithRedditHTTPClient(client *http.Client) func(*RedditSource) {
+	return func(rs *RedditSource) {
+		if client != nil {
+			rs.client = client
+		}
+	}
+}
+
+// WithRedditRequestEditor is an option that can be passed to NewRedditSource
+// to have editors edit every request it makes, in order, after its User-Agent
+// is set.
+func WithRedditRequestEditor(editors ...RequestEditor) func(*RedditSource) {
+	return func(rs *RedditSource) {
+		rs.editors = append(rs.editors, editors...)
+	}
+}
+
+// WithSubreddits is an option that can be passed to NewRedditSource to
+// search only the given subreddits, such as golang or r/programming.
+func WithSubreddits(subreddits ...string) func(*RedditSource) {
+	return func(rs *RedditSource) {
+		rs.Subreddits = append(rs.Subreddits, subreddits...)
+	}
+}
+
+type redditPost struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	Permalink   string  `json:"permalink"`
+	Subreddit   string  `json:"subreddit"`
+	Score       int     `json:"score"`
+	NumComments int     `json:"num_comments"`
+	CreatedUTC  float64 `json:"created_utc"`
+}
+
+// Mentions returns the newest posts linking to the link in the source's
+// subreddits. The site of each is the subreddit it was posted to, such as
+// r/golang.
+func (rs *RedditSource) Mentions() ([]Mention, error) {
+	path := "/search.json"
+	q := url.Values{
+		"q":     {"url:" + rs.Link},
+		"sort":  {"new"},
+		"limit": {"100"},
+	}
+	if len(rs.Subreddits) > 0 {
+		path = "/r/" + strings.Join(rs.Subreddits, "+") + "/search.json"
+		q.Set("restrict_sr", "1")
+	}
+	endpoint := fmt.Sprintf("%s%s?%s", rs.baseURL, path, q.Encode())
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	var listing struct {
+		Data struct {
+			Children []struct {
+				Data redditPost `json:"data"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	if err := getJSON(rs.client, rs.editors, req, "Reddit search", &listing); err != nil {
+		return nil, err
+	}
+	var mentions []Mention
+	for _, child := range listing.Data.Children {
+		post := child.Data
+		if !linksTo(post.URL, rs.Link) {
+			continue
+		}
+		mentions = append(mentions, Mention{
+			Site:     "r/" + post.Subreddit,
+			ID:       post.ID,
+			Title:    post.Title,
+			Link:     "https://www.reddit.com" + post.Permalink,
+			Points:   post.Score,
+			Comments: post.NumComments,
+			Time:     time.Unix(int64(post.CreatedUTC), 0),
+		})
+	}
+	return mentions, nil
+}
@@ -0,0 +1,113 @@
+package stargazer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Referrer is a site that has sent visitors to a repository, with how many
+// views it sent, and from how many unique visitors, over the last 14 days.
+type Referrer struct {
+	Referrer string `json:"referrer"`
+	Count    int    `json:"count"`
+	Uniques  int    `json:"uniques"`
+}
+
+// WithReferrers is an option that can be passed to NewGitHubStargazer to have
+// the top referrers of the repository, from its traffic data, fetched after
+// a successful poll whenever they were last fetched at least every ago, to
+// help connect jumps in the count to where they came from. Traffic data is
+// only available to those who can push to the repository, so this needs a
+// GitHub token that can, and is only done for gazers that watch the stars of
+// a GitHub repository.
+func WithReferrers(every time.Duration) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.referrersEvery = every
+	}
+}
+
+// Referrers returns the top referrers of the repository, most views first,
+// and when they were fetched. It returns false if they haven't been fetched,
+// which they only are with WithReferrers.
+func (sg *GitHubStargazer) Referrers() ([]Referrer, time.Time, bool) {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	if sg.referrersAt.IsZero() {
+		return nil, time.Time{}, false
+	}
+	return append([]Referrer{}, sg.referrers...), sg.referrersAt, true
+}
+
+// checkReferrers fetches the top referrers of the repository if they are
+// due. They aren't tried again until they are next due if fetching them
+// fails.
+func (sg *GitHubStargazer) checkReferrers() {
+	if sg.referrersEvery <= 0 || !sg.watchesRepository() {
+		return
+	}
+	now := sg.clock.Now()
+	sg.mu.Lock()
+	due := !now.Before(sg.referrersNext)
+	if due {
+		sg.referrersNext = now.Add(sg.referrersEvery)
+	}
+	sg.mu.Unlock()
+	if !due {
+		return
+	}
+	referrers, err := sg.fetchReferrers()
+	if err != nil {
+		sg.log.Warnw("unable to fetch referrers", "repo", sg.Repository, "err", err)
+		return
+	}
+	sg.log.Debugw("fetched referrers", "repo", sg.Repository, "referrers", len(referrers))
+	sg.mu.Lock()
+	sg.referrers = referrers
+	sg.referrersAt = now
+	sg.mu.Unlock()
+}
+
+// fetchReferrers fetches the top referrers of the repository from its
+// traffic data, which needs a token that can push to it.
+func (sg *GitHubStargazer) fetchReferrers() ([]Referrer, error) {
+	token, err := sg.authToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting GitHub token")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("cannot fetch referrers of %s: GitHub token is empty: %w", sg.Repository, ErrUnauthorized)
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/traffic/popular/referrers", sg.apiBaseURL, sg.CurrentName())
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/vnd.github+json")
+	req.Header.Add("Authorization", fmt.Sprintf("token %s", token))
+	resp, err := sg.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reaching GitHub API: %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+	sg.updateRateLimit(resp)
+	if rlErr := rateLimitErrorFromResponse(resp, sg.clock.Now()); rlErr != nil {
+		return nil, rlErr
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("GitHub", resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading GitHub API response: %s: %w", endpoint, err)
+	}
+	var referrers []Referrer
+	if err := json.Unmarshal(body, &referrers); err != nil {
+		return nil, errors.Wrap(err, "error decoding GitHub JSON response")
+	}
+	return referrers, nil
+}
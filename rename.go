@@ -0,0 +1,93 @@
+package stargazer
+
+import (
+	"strings"
+	"time"
+)
+
+// Rename describes a repository that has been renamed or transferred, which
+// GitHub reveals by redirecting requests for its old name, or by reporting it
+// under its new name when it is fetched by ID.
+type Rename struct {
+	// Repository is the name the gazer watches the repository by, and
+	// Previous the name it had before this rename, which differs from
+	// Repository if it has been renamed more than once.
+	Repository string
+	Previous   string
+
+	// NewName is the repository's new name in owner/repo format.
+	NewName string
+	Time    time.Time
+}
+
+// WithRenameHook is an option that can be passed to NewGitHubStargazer to have
+// hook run when the repository turns out to have been renamed or transferred.
+// The gazer carries on under the new name either way, which CurrentName
+// returns.
+func WithRenameHook(hook func(Rename) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.RenameHook = hook
+	}
+}
+
+// CurrentName returns the name of the repository in owner/repo format, which
+// is Repository unless it has been renamed or transferred since.
+func (sg *GitHubStargazer) CurrentName() string {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.currentName()
+}
+
+// currentName returns the current name of the repository. The caller must
+// hold sg.mu.
+func (sg *GitHubStargazer) currentName() string {
+	if sg.renamedTo != "" {
+		return sg.renamedTo
+	}
+	return sg.Repository
+}
+
+// noteName records fullName, the name GitHub reported for the repository, as
+// its new name if it differs from the current one other than by case. The
+// rename is reported by the next call to fireRename. The caller must hold
+// sg.mu.
+func (sg *GitHubStargazer) noteName(fullName string) {
+	previous := sg.currentName()
+	if fullName == "" || strings.EqualFold(fullName, previous) {
+		return
+	}
+	sg.renamedTo = fullName
+	sg.rename = &Rename{
+		Repository: sg.Repository,
+		Previous:   previous,
+		NewName:    fullName,
+		Time:       sg.clock.Now(),
+	}
+}
+
+// fireRename logs and reports the rename noted since it was last called, if
+// there was one, and runs the rename hook for it.
+func (sg *GitHubStargazer) fireRename() {
+	sg.mu.Lock()
+	r := sg.rename
+	sg.rename = nil
+	sg.mu.Unlock()
+	if r == nil {
+		return
+	}
+	sg.log.Warnw("repository was renamed or transferred",
+		"repo", sg.Repository,
+		"previous", r.Previous,
+		"new_name", r.NewName)
+	sg.emit(Renamed{*r})
+	if sg.RenameHook == nil {
+		return
+	}
+	err := sg.RenameHook(*r)
+	if err != nil {
+		sg.log.Infow("error calling rename hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+	sg.hookDone("rename", err)
+}
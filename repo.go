@@ -0,0 +1,106 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Repo is a GitHub repository, named by its owner and name.
+type Repo struct {
+	// Owner is the login of the user or organization that owns the
+	// repository, such as "ianfoo".
+	Owner string
+
+	// Name is the name of the repository, such as "github-stargazer".
+	Name string
+}
+
+// ParseRepo parses a repository given in owner/name format, or as its URL,
+// such as https://github.com/ianfoo/github-stargazer. The scheme may be left
+// off the URL, and a trailing .git, as in a clone URL, is dropped. Any host
+// is accepted, so that repositories on GitHub Enterprise Server can be
+// parsed too. The error for malformed input matches ErrInvalidRepo with
+// errors.Is.
+func ParseRepo(s string) (Repo, error) {
+	path := s
+	if strings.Contains(s, "://") || strings.HasPrefix(s, "github.com/") {
+		raw := s
+		if !strings.Contains(raw, "://") {
+			raw = "https://" + raw
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Repo{}, fmt.Errorf("%w %q: not an http or https URL", ErrInvalidRepo, s)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			return Repo{}, fmt.Errorf("%w %q: URL must not have a query or fragment", ErrInvalidRepo, s)
+		}
+		path = strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	}
+	owner, name, ok := strings.Cut(path, "/")
+	if !ok || strings.Contains(name, "/") {
+		return Repo{}, fmt.Errorf("%w %q: must be owner/name", ErrInvalidRepo, s)
+	}
+	if err := validOwner(owner); err != nil {
+		return Repo{}, fmt.Errorf("%w %q: %s", ErrInvalidRepo, s, err)
+	}
+	if err := validRepoName(name); err != nil {
+		return Repo{}, fmt.Errorf("%w %q: %s", ErrInvalidRepo, s, err)
+	}
+	return Repo{Owner: owner, Name: name}, nil
+}
+
+// String returns the repository in owner/name format.
+func (r Repo) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+// validOwner checks that owner could be the login of a GitHub user or
+// organization: up to 39 letters, digits and hyphens, not starting with a
+// hyphen. Underscores are allowed too, as managed users' logins end with an
+// underscore and the enterprise's short code.
+func validOwner(owner string) error {
+	if owner == "" {
+		return errors.New("owner is empty")
+	}
+	if len(owner) > 39 {
+		return errors.Errorf("owner %q is longer than 39 characters", owner)
+	}
+	if owner[0] == '-' {
+		return errors.Errorf("owner %q starts with a hyphen", owner)
+	}
+	for _, c := range owner {
+		if !isAlphanumeric(c) && c != '-' && c != '_' {
+			return errors.Errorf("owner %q contains %q, but may only contain letters, digits, hyphens and underscores", owner, c)
+		}
+	}
+	return nil
+}
+
+// validRepoName checks that name could be the name of a GitHub repository:
+// up to 100 letters, digits, hyphens, underscores and periods, other than
+// "." and "..".
+func validRepoName(name string) error {
+	if name == "" {
+		return errors.New("name is empty")
+	}
+	if len(name) > 100 {
+		return errors.Errorf("name %q is longer than 100 characters", name)
+	}
+	if name == "." || name == ".." {
+		return errors.Errorf("name %q is reserved", name)
+	}
+	for _, c := range name {
+		if !isAlphanumeric(c) && c != '-' && c != '_' && c != '.' {
+			return errors.Errorf("name %q contains %q, but may only contain letters, digits, hyphens, underscores and periods", name, c)
+		}
+	}
+	return nil
+}
+
+func isAlphanumeric(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
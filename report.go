@@ -0,0 +1,67 @@
+package stargazer
+
+import "time"
+
+// Report is the current state of a gazer's repository, sent on a schedule
+// whether or not anything has happened.
+type Report struct {
+	Repository      string
+	Time            time.Time
+	StargazersCount int
+	Target          int
+
+	// Next is the lowest target or milestone that hasn't been reached,
+	// or 0 if every one has.
+	Next int
+
+	Velocity Velocity
+}
+
+// WithReport is an option that can be passed to NewGitHubStargazer to have
+// hook run with a report of the stargazers count at the times schedule
+// gives, such as every morning with a cron schedule, while the gazer is
+// gazing. Reports aren't made before the count has been fetched, and are
+// made while the gazer is paused.
+func WithReport(schedule Schedule, hook func(Report) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.reportSchedule = schedule
+		sg.ReportHook = hook
+	}
+}
+
+// nextReport returns a timer that fires when the next report is due.
+func (sg *GitHubStargazer) nextReport() Timer {
+	now := sg.clock.Now()
+	return sg.clock.NewTimer(sg.reportSchedule.Next(now).Sub(now))
+}
+
+// fireReport runs the report hook with a report of the current count.
+func (sg *GitHubStargazer) fireReport() {
+	sg.mu.Lock()
+	if sg.lastSuccess.IsZero() {
+		sg.mu.Unlock()
+		sg.log.Infow("skipping report until the count has been fetched", "repo", sg.Repository)
+		return
+	}
+	r := Report{
+		Repository:      sg.Repository,
+		Time:            sg.clock.Now(),
+		StargazersCount: sg.stargazersCount,
+		Target:          sg.StargazersTarget,
+	}
+	for _, t := range sg.targets {
+		if !sg.fired[t] {
+			r.Next = t
+			break
+		}
+	}
+	sg.mu.Unlock()
+	r.Velocity = sg.Velocity()
+	err := sg.ReportHook(r)
+	if err != nil {
+		sg.log.Infow("error calling report hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+	sg.hookDone("report", err)
+}
@@ -0,0 +1,36 @@
+package stargazer
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// UserAgent identifies the requests made to GitHub, Twilio and the other
+// APIs, as some of them, such as GitHub's and crates.io's, refuse requests
+// without a User-Agent. A RequestEditor can replace it.
+const UserAgent = "github-stargazer (https://github.com/ianfoo/github-stargazer)"
+
+// RequestEditor edits a request before it is sent, for example to add
+// headers, log it or sign it. If it returns an error, the request isn't sent.
+type RequestEditor func(*http.Request) error
+
+// SetHeader returns a RequestEditor that sets the header key to value, such
+// as a User-Agent of the caller's own.
+func SetHeader(key, value string) RequestEditor {
+	return func(req *http.Request) error {
+		req.Header.Set(key, value)
+		return nil
+	}
+}
+
+// editRequest sets the User-Agent of req and then runs editors on it in
+// order.
+func editRequest(req *http.Request, editors []RequestEditor) error {
+	req.Header.Set("User-Agent", UserAgent)
+	for _, edit := range editors {
+		if err := edit(req); err != nil {
+			return fmt.Errorf("error editing request: %w", err)
+		}
+	}
+	return nil
+}
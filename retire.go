@@ -0,0 +1,92 @@
+package stargazer
+
+import (
+	"errors"
+	"time"
+)
+
+// Retirement describes a repository that has been archived or deleted, so
+// that there is nothing more to watch. The gazer parks itself by pausing
+// when it finds one, rather than polling it and failing forever.
+type Retirement struct {
+	Repository string
+	Time       time.Time
+
+	// Archived is true if the repository was archived, and false if it was
+	// deleted, or can't be seen with the gazer's token any more, which Err
+	// says.
+	Archived bool
+	Err      error
+}
+
+// WithRetireHook is an option that can be passed to NewGitHubStargazer to
+// have hook run once when the repository is found to have been archived or
+// deleted, after which the gazer pauses. Resuming it polls the repository
+// again, and if it is still archived or deleted, parks it again without
+// running hook.
+func WithRetireHook(hook func(Retirement) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.RetireHook = hook
+	}
+}
+
+// Retired reports whether the gazer has parked itself because the repository
+// was archived or deleted.
+func (sg *GitHubStargazer) Retired() bool {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.retired
+}
+
+// checkRetired parks the gazer if the repository turns out to have been
+// archived, or if err, from fetching the count, says it doesn't exist. The
+// retire hook runs the first time it is parked for either, and the gazer is
+// unmarked once a fetch succeeds for a repository that isn't archived.
+func (sg *GitHubStargazer) checkRetired(err error) {
+	sg.mu.Lock()
+	archived := err == nil && sg.metadata != nil && sg.metadata.Archived
+	if err == nil && !archived {
+		was := sg.retired
+		sg.retired = false
+		sg.mu.Unlock()
+		if was {
+			sg.log.Infow("repository is no longer archived or deleted", "repo", sg.Repository)
+			sg.saveState()
+		}
+		return
+	}
+	if !archived && !errors.Is(err, ErrNotFound) {
+		sg.mu.Unlock()
+		return
+	}
+	alerted := sg.retired
+	sg.retired = true
+	sg.mu.Unlock()
+	if alerted {
+		sg.log.Infow("repository is still archived or deleted; parking watch again", "repo", sg.Repository)
+		sg.Pause()
+		return
+	}
+	r := Retirement{
+		Repository: sg.Repository,
+		Time:       sg.clock.Now(),
+		Archived:   archived,
+		Err:        err,
+	}
+	sg.log.Warnw("repository was archived or deleted; parking watch",
+		"repo", sg.Repository,
+		"archived", archived)
+	sg.saveState()
+	sg.emit(Retired{r})
+	sg.Pause()
+	if sg.RetireHook == nil {
+		return
+	}
+	hookErr := sg.RetireHook(r)
+	if hookErr != nil {
+		sg.log.Infow("error calling retire hook function",
+			"repo", sg.Repository,
+			"err", hookErr)
+	}
+	sg.hookDone("retire", hookErr)
+}
@@ -0,0 +1,123 @@
+package stargazer
+
+import "time"
+
+// RivalKind is what happened between a repository and its rival.
+type RivalKind string
+
+// Kinds of rivalry.
+const (
+	// Overtook is when the repository passes its rival.
+	Overtook RivalKind = "overtook"
+
+	// Overtaken is when the rival passes the repository.
+	Overtaken RivalKind = "overtaken"
+
+	// GapNarrowed is when the counts of the repository and its rival come
+	// within the gap given to WithRival of each other.
+	GapNarrowed RivalKind = "gap"
+)
+
+// Rivalry describes a repository overtaking its rival, being overtaken by
+// it, or coming close to it.
+type Rivalry struct {
+	Repository string
+	Rival      string
+	Kind       RivalKind
+	Time       time.Time
+
+	StargazersCount int
+	RivalCount      int
+
+	// Gap is how far apart the counts are, whichever is ahead.
+	Gap int
+}
+
+// WithRival is an option that can be passed to NewGitHubStargazer to compare
+// the stargazers count with the count of the repository named rival, fetched
+// from source at each poll, and have hook run when either overtakes the
+// other. Which is ahead is only noted at the first comparison, and a tie
+// leaves it unchanged. If gap is positive, hook also runs when the counts
+// come within gap of each other, and then not again until they are at least
+// gap apart. A GitHubStargazer can be the source of a GitHub rival.
+func WithRival(rival string, source Source, gap int, hook func(Rivalry) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.rivalName = rival
+		sg.rival = source
+		sg.rivalGap = gap
+		sg.RivalHook = hook
+	}
+}
+
+// checkRival compares count with the rival's count, if the gazer has a
+// rival, and runs the rival hook for what has changed between them.
+func (sg *GitHubStargazer) checkRival(count int) {
+	if sg.rival == nil {
+		return
+	}
+	rivalCount, err := sg.rival.Fetch()
+	if err != nil {
+		sg.log.Warnw("error fetching rival count",
+			"repo", sg.Repository,
+			"rival", sg.rivalName,
+			"err", err)
+		return
+	}
+	gap := count - rivalCount
+	if gap < 0 {
+		gap = -gap
+	}
+	r := Rivalry{
+		Repository:      sg.Repository,
+		Rival:           sg.rivalName,
+		Time:            sg.clock.Now(),
+		StargazersCount: count,
+		RivalCount:      rivalCount,
+		Gap:             gap,
+	}
+	sg.mu.Lock()
+	switch {
+	case sg.rivalAhead == nil:
+	case *sg.rivalAhead && count > rivalCount:
+		r.Kind = Overtook
+	case !*sg.rivalAhead && rivalCount > count:
+		r.Kind = Overtaken
+	}
+	if sg.rivalAhead == nil || r.Kind != "" {
+		ahead := rivalCount > count
+		sg.rivalAhead = &ahead
+	}
+	near := sg.rivalGap > 0 && gap < sg.rivalGap
+	if !near {
+		sg.rivalGapAlerted = false
+	}
+	if r.Kind == "" && near && !sg.rivalGapAlerted {
+		r.Kind = GapNarrowed
+	}
+	// An overtake brings the counts close, so it stands in for the gap
+	// narrowing.
+	if near && r.Kind != "" {
+		sg.rivalGapAlerted = true
+	}
+	sg.mu.Unlock()
+	if r.Kind == "" {
+		return
+	}
+	sg.saveState()
+	sg.log.Infow("rivalry changed",
+		"repo", sg.Repository,
+		"rival", sg.rivalName,
+		"kind", r.Kind,
+		"stargazers_count", count,
+		"rival_count", rivalCount)
+	if sg.RivalHook == nil {
+		return
+	}
+	err = sg.RivalHook(r)
+	if err != nil {
+		sg.log.Infow("error calling rival hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+	sg.hookDone("rival", err)
+}
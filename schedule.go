@@ -0,0 +1,157 @@
+package stargazer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a gazer polls. The same Schedule may be shared by any
+// number of gazers.
+type Schedule interface {
+	// Next returns the first time after after at which to poll.
+	Next(after time.Time) time.Time
+}
+
+// Every returns a Schedule that polls every interval.
+func Every(interval time.Duration) Schedule {
+	return everySchedule(interval)
+}
+
+type everySchedule time.Duration
+
+func (es everySchedule) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(es))
+}
+
+// WithSchedule is an option that can be passed to NewGitHubStargazer to poll
+// according to schedule instead of every Interval.
+func WithSchedule(schedule Schedule) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.schedule = schedule
+	}
+}
+
+// cronSchedule is a Schedule parsed from a cron expression. Each field is the
+// set of values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow map[int]bool
+
+	// domAny and dowAny are true when the day of month or day of week field
+	// is *. If neither is, a day matching either field matches.
+	domAny, dowAny bool
+}
+
+// cronSearchLimit is how far ahead Next looks for a matching time before
+// giving up on a schedule that can never match, such as February 30th.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// ParseCron parses a standard five-field cron expression (minute, hour, day
+// of month, month and day of week) into a Schedule, in the location of the
+// times passed to it. Fields may be *, numbers, ranges such as 9-17, steps
+// such as */15, and comma-separated lists of those. Sunday is 0 or 7.
+func ParseCron(expr string) (Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+	var (
+		cs  cronSchedule
+		err error
+	)
+	if cs.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if cs.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if cs.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if cs.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if cs.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	if cs.dow[7] {
+		cs.dow[0] = true
+	}
+	cs.domAny, cs.dowAny = fields[2] == "*", fields[4] == "*"
+	return cs, nil
+}
+
+// parseCronField parses one field of a cron expression, whose values must be
+// between min and max.
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in cron field %q", field)
+			}
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			var err error
+			bounds := strings.SplitN(rng, "-", 2)
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, fmt.Errorf("invalid cron field %q", field)
+			}
+			hi = lo
+			if len(bounds) == 2 {
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, fmt.Errorf("invalid cron field %q", field)
+				}
+			} else if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("cron field %q out of range %d-%d", field, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+func (cs cronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	end := after.Add(cronSearchLimit)
+	for t := after.Truncate(time.Minute).Add(time.Minute); t.Before(end); {
+		y, mo, d := t.Date()
+		switch {
+		case !cs.month[int(mo)]:
+			t = time.Date(y, mo+1, 1, 0, 0, 0, 0, loc)
+		case !cs.matchesDay(t):
+			t = time.Date(y, mo, d+1, 0, 0, 0, 0, loc)
+		case !cs.hour[t.Hour()]:
+			t = time.Date(y, mo, d, t.Hour()+1, 0, 0, 0, loc)
+		case !cs.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return end
+}
+
+func (cs cronSchedule) matchesDay(t time.Time) bool {
+	dom, dow := cs.dom[t.Day()], cs.dow[int(t.Weekday())]
+	switch {
+	case cs.domAny && cs.dowAny:
+		return true
+	case cs.domAny:
+		return dow
+	case cs.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
@@ -0,0 +1,412 @@
+package stargazer
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Scheduler paces the polls of many gazers, so that watching many
+// repositories doesn't mean a ticker per repository firing requests whenever
+// it likes. Gazers given the same Scheduler with WithScheduler poll when it
+// says so: in order of when their polls are due, no more than Workers at a
+// time, and spaced out to stay within an hourly request budget. Polls still
+// run on the gazers' own goroutines. A Scheduler is safe for concurrent use.
+//
+// The budget holds for every GitHub API request the gazers make, not just
+// their polls: a request that would exceed it, such as one made by a hook,
+// waits until the oldest request of the last hour has aged out of it. Polls
+// that fall due while they wait are skipped, the gazer polling once when it
+// is allowed to rather than catching up.
+type Scheduler struct {
+	// Workers is how many polls may run at once.
+	Workers int
+
+	// HourlyBudget is how many GitHub API requests may be made per hour
+	// across every gazer, or 0 for no limit. Polls are spaced evenly to stay
+	// within it.
+	HourlyBudget int
+
+	mu    sync.Mutex
+	queue scheduleQueue
+	free  int
+	last  time.Time
+	stats map[*scheduled]*ScheduleStats
+
+	// requests are the times of the requests made in the last hour, oldest
+	// first, which count against the hourly budget.
+	requests []time.Time
+	metrics  Metrics
+	clock    Clock
+
+	wake   chan struct{}
+	stopCh chan struct{}
+	start  sync.Once
+}
+
+// ScheduleStats describes how a gazer's polls have been scheduled.
+type ScheduleStats struct {
+	Repository string    `json:"repository"`
+	Polls      int       `json:"polls"`
+	LastPoll   time.Time `json:"last_poll"`
+	NextPoll   time.Time `json:"next_poll"`
+
+	// Delay is how long after it was due the last poll was allowed to run,
+	// and MaxDelay the longest any poll has waited.
+	Delay    time.Duration `json:"delay_ns"`
+	MaxDelay time.Duration `json:"max_delay_ns"`
+
+	// Skipped is how many polls have been skipped because they fell due
+	// while an earlier one was still waiting to run.
+	Skipped int `json:"skipped"`
+}
+
+// scheduled is a gazer's place in the schedule.
+type scheduled struct {
+	repo     string
+	at       time.Time
+	interval time.Duration
+	index    int
+
+	// due receives when the gazer may poll. running is true from then until
+	// the gazer is done polling, while it holds one of the workers.
+	due     chan struct{}
+	running bool
+}
+
+// NewScheduler returns a scheduler running up to 4 polls at once with no
+// hourly budget, unless the options say otherwise.
+func NewScheduler(options ...func(*Scheduler)) (*Scheduler, error) {
+	s := &Scheduler{
+		Workers: 4,
+		stats:   make(map[*scheduled]*ScheduleStats),
+		metrics: nopMetrics{},
+		clock:   realClock{},
+		wake:    make(chan struct{}, 1),
+		stopCh:  make(chan struct{}),
+	}
+	for _, o := range options {
+		o(s)
+	}
+	if s.Workers < 1 {
+		return nil, errors.New("scheduler workers must be at least 1")
+	}
+	if s.HourlyBudget < 0 {
+		return nil, errors.New("hourly budget must not be negative")
+	}
+	s.free = s.Workers
+	return s, nil
+}
+
+// WithWorkers is an option that can be passed to NewScheduler to set how
+// many polls may run at once.
+func WithWorkers(workers int) func(*Scheduler) {
+	return func(s *Scheduler) {
+		s.Workers = workers
+	}
+}
+
+// WithHourlyBudget is an option that can be passed to NewScheduler to limit
+// how many GitHub API requests are made per hour across every gazer.
+func WithHourlyBudget(requests int) func(*Scheduler) {
+	return func(s *Scheduler) {
+		s.HourlyBudget = requests
+	}
+}
+
+// WithSchedulerMetrics is an option that can be passed to NewScheduler to
+// have the scheduler report how much of the hourly budget has been used, and
+// the polls it skipped, to metrics.
+func WithSchedulerMetrics(metrics Metrics) func(*Scheduler) {
+	return func(s *Scheduler) {
+		s.metrics = metrics
+	}
+}
+
+// WithSchedulerClock is an option that can be passed to NewScheduler to have
+// the scheduler tell the time, and wait for polls and the hourly budget, with
+// clock rather than the time package.
+func WithSchedulerClock(clock Clock) func(*Scheduler) {
+	return func(s *Scheduler) {
+		if clock != nil {
+			s.clock = clock
+		}
+	}
+}
+
+// WithScheduler is an option that can be passed to NewGitHubStargazer to have
+// the gazer poll when scheduler says so, rather than on its own ticker.
+func WithScheduler(scheduler *Scheduler) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.scheduler = scheduler
+	}
+}
+
+// Stop stops the scheduler. Gazers using it make no more polls until they
+// stop too.
+func (s *Scheduler) Stop() {
+	s.start.Do(func() {})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.stopCh:
+	default:
+		close(s.stopCh)
+	}
+}
+
+// Stats returns how the polls of each gazer using the scheduler have been
+// scheduled, ordered by repository.
+func (s *Scheduler) Stats() []ScheduleStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]ScheduleStats, 0, len(s.stats))
+	for _, st := range s.stats {
+		stats = append(stats, *st)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Repository < stats[j].Repository
+	})
+	return stats
+}
+
+// statsFor returns the scheduling stats of e.
+func (s *Scheduler) statsFor(e *scheduled) (ScheduleStats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.stats[e]
+	if !ok {
+		return ScheduleStats{}, false
+	}
+	return *st, true
+}
+
+// ScheduleStats returns how the gazer's polls have been scheduled. It returns
+// false if the gazer has no scheduler or isn't gazing.
+func (sg *GitHubStargazer) ScheduleStats() (ScheduleStats, bool) {
+	sg.mu.Lock()
+	e := sg.scheduled
+	sg.mu.Unlock()
+	if sg.scheduler == nil || e == nil {
+		return ScheduleStats{}, false
+	}
+	return sg.scheduler.statsFor(e)
+}
+
+// spacing is the least time between polls that keeps within the budget.
+func (s *Scheduler) spacing() time.Duration {
+	if s.HourlyBudget == 0 {
+		return 0
+	}
+	return time.Hour / time.Duration(s.HourlyBudget)
+}
+
+// add schedules a poll for repo at at, starting the scheduler if it hasn't
+// been started.
+func (s *Scheduler) add(repo string, at time.Time) *scheduled {
+	s.start.Do(func() { go s.run() })
+	e := &scheduled{repo: repo, at: at, due: make(chan struct{}, 1)}
+	s.mu.Lock()
+	heap.Push(&s.queue, e)
+	s.stats[e] = &ScheduleStats{Repository: repo, NextPoll: at}
+	s.mu.Unlock()
+	s.poke()
+	return e
+}
+
+// done frees the worker held by e, which polls again after interval.
+func (s *Scheduler) done(e *scheduled, interval time.Duration) {
+	s.mu.Lock()
+	if e.running {
+		e.running = false
+		s.free++
+	}
+	e.at = s.clock.Now().Add(interval)
+	e.interval = interval
+	heap.Push(&s.queue, e)
+	s.stats[e].NextPoll = e.at
+	s.mu.Unlock()
+	s.poke()
+}
+
+// remove takes e off the schedule, freeing its worker if it holds one.
+func (s *Scheduler) remove(e *scheduled) {
+	s.mu.Lock()
+	if e.running {
+		e.running = false
+		s.free++
+	}
+	if e.index >= 0 {
+		heap.Remove(&s.queue, e.index)
+	}
+	delete(s.stats, e)
+	s.mu.Unlock()
+	s.poke()
+}
+
+func (s *Scheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run lets gazers poll as their polls come due and workers are free, until
+// the scheduler is stopped.
+func (s *Scheduler) run() {
+	for {
+		s.mu.Lock()
+		wait, ok := s.dispatch(s.clock.Now())
+		s.mu.Unlock()
+		var (
+			timer Timer
+			next  <-chan time.Time
+		)
+		if ok {
+			timer = s.clock.NewTimer(wait)
+			next = timer.C()
+		}
+		select {
+		case <-next:
+		case <-s.wake:
+		case <-s.stopCh:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// dispatch lets every gazer poll whose poll is due, while workers are free
+// and the budget allows. It returns how long until the next poll can be
+// dispatched, or false if that depends on a worker being freed or a poll
+// being added. The caller must hold s.mu.
+func (s *Scheduler) dispatch(now time.Time) (time.Duration, bool) {
+	for s.free > 0 && s.queue.Len() > 0 {
+		e := s.queue[0]
+		ready := e.at
+		if spaced := s.last.Add(s.spacing()); spaced.After(ready) {
+			ready = spaced
+		}
+		if s.HourlyBudget > 0 {
+			s.prune(now)
+			if len(s.requests) >= s.HourlyBudget {
+				if freed := s.requests[0].Add(time.Hour); freed.After(ready) {
+					ready = freed
+				}
+			}
+		}
+		if ready.After(now) {
+			return ready.Sub(now), true
+		}
+		heap.Pop(&s.queue)
+		s.free--
+		s.last = now
+		e.running = true
+		st := s.stats[e]
+		st.Polls++
+		st.LastPoll = now
+		st.Delay = now.Sub(e.at)
+		if st.Delay > st.MaxDelay {
+			st.MaxDelay = st.Delay
+		}
+		if e.interval > 0 && st.Delay >= e.interval {
+			skipped := int(st.Delay / e.interval)
+			st.Skipped += skipped
+			s.metrics.Counter(MetricSchedulerSkippedPolls, int64(skipped), "repo", e.repo)
+		}
+		e.due <- struct{}{}
+	}
+	return 0, false
+}
+
+// reserve counts a request against the hourly budget, first waiting until
+// the budget allows it if it is used up. It returns an error if the
+// scheduler is stopped while it waits.
+func (s *Scheduler) reserve() error {
+	for {
+		s.mu.Lock()
+		wait := s.spend(s.clock.Now())
+		s.mu.Unlock()
+		if wait == 0 {
+			return nil
+		}
+		timer := s.clock.NewTimer(wait)
+		select {
+		case <-timer.C():
+		case <-s.stopCh:
+			timer.Stop()
+			return errors.New("scheduler stopped while waiting for the hourly budget")
+		}
+	}
+}
+
+// spend counts a request made at now against the hourly budget and returns
+// 0, or, if the budget is used up, returns how long until it allows another
+// request. The caller must hold s.mu.
+func (s *Scheduler) spend(now time.Time) time.Duration {
+	if s.HourlyBudget == 0 {
+		return 0
+	}
+	s.prune(now)
+	if len(s.requests) >= s.HourlyBudget {
+		return s.requests[0].Add(time.Hour).Sub(now)
+	}
+	s.requests = append(s.requests, now)
+	s.reportBudget()
+	return 0
+}
+
+// prune forgets the requests made more than an hour before now, which no
+// longer count against the budget. The caller must hold s.mu.
+func (s *Scheduler) prune(now time.Time) {
+	hourAgo := now.Add(-time.Hour)
+	i := sort.Search(len(s.requests), func(i int) bool {
+		return s.requests[i].After(hourAgo)
+	})
+	if i > 0 {
+		s.requests = append(s.requests[:0], s.requests[i:]...)
+		s.reportBudget()
+	}
+}
+
+// reportBudget reports how much of the hourly budget has been used to the
+// scheduler's metrics. The caller must hold s.mu.
+func (s *Scheduler) reportBudget() {
+	s.metrics.Gauge(MetricSchedulerBudgetUsed, float64(len(s.requests)))
+	s.metrics.Gauge(MetricSchedulerBudgetRemaining, float64(s.HourlyBudget-len(s.requests)))
+}
+
+// scheduleQueue is a heap of scheduled polls, earliest first.
+type scheduleQueue []*scheduled
+
+func (q scheduleQueue) Len() int           { return len(q) }
+func (q scheduleQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x interface{}) {
+	e := x.(*scheduled)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *scheduleQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	e.index = -1
+	*q = old[:len(old)-1]
+	return e
+}
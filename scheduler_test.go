@@ -0,0 +1,209 @@
+package stargazer
+
+import (
+	"container/heap"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSchedulerDispatch(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		workers int
+		budget  int
+
+		// sinceLast is how long ago a poll was last dispatched, or 0 if none
+		// has been, and due when each poll on the schedule is due.
+		sinceLast time.Duration
+		due       []time.Duration
+
+		// requests are when the requests counting against the budget were
+		// made, oldest first.
+		requests []time.Duration
+
+		// wantPolls are the polls dispatched, by their index in due, and
+		// wantWait and wantOK what dispatch returns.
+		wantPolls []int
+		wantWait  time.Duration
+		wantOK    bool
+	}{
+		{
+			name:    "nothing scheduled",
+			workers: 4,
+		},
+		{
+			name:      "due polls",
+			workers:   4,
+			due:       []time.Duration{-time.Second, -3 * time.Second, 0},
+			wantPolls: []int{0, 1, 2},
+		},
+		{
+			name:      "poll not yet due",
+			workers:   4,
+			due:       []time.Duration{-time.Second, 5 * time.Second},
+			wantPolls: []int{0},
+			wantWait:  5 * time.Second,
+			wantOK:    true,
+		},
+		{
+			name:      "no free workers",
+			workers:   2,
+			due:       []time.Duration{-time.Second, -3 * time.Second, -2 * time.Second},
+			wantPolls: []int{1, 2},
+		},
+		{
+			name:      "budget spaces polls due together",
+			workers:   4,
+			budget:    60,
+			due:       []time.Duration{-2 * time.Second, -time.Second},
+			wantPolls: []int{0},
+			wantWait:  time.Minute,
+			wantOK:    true,
+		},
+		{
+			name:      "budget spaces polls after the last one",
+			workers:   4,
+			budget:    60,
+			sinceLast: 45 * time.Second,
+			due:       []time.Duration{-time.Second},
+			wantWait:  15 * time.Second,
+			wantOK:    true,
+		},
+		{
+			name:      "budget allows a poll once spaced",
+			workers:   4,
+			budget:    60,
+			sinceLast: time.Minute,
+			due:       []time.Duration{-time.Second},
+			wantPolls: []int{0},
+		},
+		{
+			name:     "budget used up",
+			workers:  4,
+			budget:   2,
+			due:      []time.Duration{-time.Second},
+			requests: []time.Duration{-50 * time.Minute, -40 * time.Minute},
+			wantWait: 10 * time.Minute,
+			wantOK:   true,
+		},
+		{
+			name:      "requests older than an hour don't count",
+			workers:   4,
+			budget:    2,
+			due:       []time.Duration{-time.Second},
+			requests:  []time.Duration{-70 * time.Minute, -40 * time.Minute},
+			wantPolls: []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScheduler(WithWorkers(tt.workers), WithHourlyBudget(tt.budget))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.sinceLast > 0 {
+				s.last = now.Add(-tt.sinceLast)
+			}
+			for _, r := range tt.requests {
+				s.requests = append(s.requests, now.Add(r))
+			}
+			polls := make([]*scheduled, len(tt.due))
+			for i, due := range tt.due {
+				polls[i] = &scheduled{repo: "owner/repo", at: now.Add(due), due: make(chan struct{}, 1)}
+				heap.Push(&s.queue, polls[i])
+				s.stats[polls[i]] = &ScheduleStats{Repository: "owner/repo", NextPoll: polls[i].at}
+			}
+
+			wait, ok := s.dispatch(now)
+			if wait != tt.wantWait || ok != tt.wantOK {
+				t.Errorf("dispatch returned %v, %v, want %v, %v", wait, ok, tt.wantWait, tt.wantOK)
+			}
+			var dispatched []int
+			for i, e := range polls {
+				select {
+				case <-e.due:
+					dispatched = append(dispatched, i)
+					if !e.running {
+						t.Errorf("poll %d was dispatched but isn't running", i)
+					}
+					if st := s.stats[e]; st.Polls != 1 || st.Delay != -tt.due[i] {
+						t.Errorf("poll %d has %d polls delayed %v, want 1 delayed %v", i, st.Polls, st.Delay, -tt.due[i])
+					}
+				default:
+				}
+			}
+			if !slices.Equal(dispatched, tt.wantPolls) {
+				t.Errorf("dispatched polls %v, want %v", dispatched, tt.wantPolls)
+			}
+			if want := tt.workers - len(tt.wantPolls); s.free != want {
+				t.Errorf("%d workers free, want %d", s.free, want)
+			}
+		})
+	}
+}
+
+func TestSchedulerSpend(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		budget   int
+		requests []time.Duration
+
+		// wantWait is what spend returns, and wantRequests how many requests
+		// count against the budget after it.
+		wantWait     time.Duration
+		wantRequests int
+	}{
+		{
+			name: "no budget",
+		},
+		{
+			name:         "within budget",
+			budget:       3,
+			requests:     []time.Duration{-30 * time.Minute, -10 * time.Minute},
+			wantRequests: 3,
+		},
+		{
+			name:         "budget used up",
+			budget:       2,
+			requests:     []time.Duration{-50 * time.Minute, -10 * time.Minute},
+			wantWait:     10 * time.Minute,
+			wantRequests: 2,
+		},
+		{
+			name:         "requests older than an hour pruned",
+			budget:       2,
+			requests:     []time.Duration{-90 * time.Minute, -61 * time.Minute, -10 * time.Minute},
+			wantRequests: 2,
+		},
+		{
+			name:         "request an hour old pruned",
+			budget:       1,
+			requests:     []time.Duration{-time.Hour},
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScheduler(WithHourlyBudget(tt.budget))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range tt.requests {
+				s.requests = append(s.requests, now.Add(r))
+			}
+
+			if wait := s.spend(now); wait != tt.wantWait {
+				t.Errorf("spend returned %v, want %v", wait, tt.wantWait)
+			}
+			if len(s.requests) != tt.wantRequests {
+				t.Errorf("%d requests count against the budget, want %d", len(s.requests), tt.wantRequests)
+			}
+			if tt.wantWait == 0 && tt.budget > 0 && !s.requests[len(s.requests)-1].Equal(now) {
+				t.Errorf("request made now wasn't counted: %v", s.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package stargazer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Source fetches the count that a gazer watches. By default a gazer watches
+// the stargazers of a GitHub repository, but it can be given a Source with
+// WithSource to watch a count from somewhere else, such as the stars of a
+// GitLab project.
+type Source interface {
+	// Fetch returns the current count.
+	Fetch() (int, error)
+}
+
+// Count is what a Source counts. Not every Source can count everything.
+type Count string
+
+// Counts that sources can fetch.
+const (
+	Stars    Count = "stars"
+	Forks    Count = "forks"
+	Releases Count = "releases"
+	Watchers Count = "watchers"
+
+	// Downloads counts every download there has ever been,
+	// WeeklyDownloads and MonthlyDownloads those in the last week or month,
+	// and RecentDownloads those in the last 90 days.
+	Downloads        Count = "downloads"
+	WeeklyDownloads  Count = "weekly-downloads"
+	MonthlyDownloads Count = "monthly-downloads"
+	RecentDownloads  Count = "recent-downloads"
+
+	// ReverseDependencies counts the packages that depend on a package,
+	// and ImportedBy the packages that import a Go module's packages.
+	ReverseDependencies Count = "reverse-dependencies"
+	ImportedBy          Count = "imported-by"
+
+	// Versions counts the versions of a module that have been published.
+	Versions Count = "versions"
+
+	// Followers counts the followers of a GitHub user or organization,
+	// rather than anything about a repository.
+	Followers Count = "followers"
+
+	// Sponsors counts the GitHub Sponsors of a user or organization.
+	Sponsors Count = "sponsors"
+)
+
+// WithSource is an option that can be passed to NewGitHubStargazer to watch
+// the count fetched from source instead of the repository's GitHub
+// stargazers. Repository is then only used to name the watch. Star and
+// StarHistory are only supported for GitHub repositories.
+func WithSource(source Source) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.source = source
+	}
+}
+
+// errNotGitHub is returned by GitHub-only methods of gazers watching another
+// source.
+var errNotGitHub = errors.New("only supported for GitHub repositories")
+
+// getJSON makes req with client and decodes the JSON response into v. The
+// api names the API being called in error messages.
+func getJSON(client *http.Client, editors []RequestEditor, req *http.Request, api string, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	body, err := getBody(client, editors, req, api)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.Wrapf(err, "error decoding %s JSON response", api)
+	}
+	return nil
+}
+
+// getBody makes req with client and returns the body of the response. The
+// api names the API being called in error messages. The request is sent with
+// UserAgent, and then edited by editors, in order.
+func getBody(client *http.Client, editors []RequestEditor, req *http.Request, api string) ([]byte, error) {
+	body, _, err := getResponse(client, editors, req, api)
+	return body, err
+}
+
+// getResponse is like getBody, but also returns the header of the response,
+// even if its status is an error. The header is nil if there was no
+// response.
+func getResponse(client *http.Client, editors []RequestEditor, req *http.Request, api string) ([]byte, http.Header, error) {
+	if err := editRequest(req, editors); err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reaching %s API: %s: %w", api, req.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.Header, newAPIError(api, resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.Header, fmt.Errorf("error reading %s API response: %s: %w", api, req.URL, err)
+	}
+	return body, resp.Header, nil
+}
@@ -0,0 +1,171 @@
+// Package sqlitestore keeps the stargazers counts observed by gazers, and any
+// state their owners want to persist, in a SQLite database, so that they
+// survive restarts and history can be queried. It uses a pure-Go SQLite
+// driver, so no cgo is needed.
+package sqlitestore
+
+import (
+	"database/sql"
+	"time"
+
+	stargazer "github.com/ianfoo/github-stargazer"
+	"github.com/pkg/errors"
+
+	// Register the "sqlite" database/sql driver.
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS samples (
+	repo  TEXT    NOT NULL,
+	time  INTEGER NOT NULL,
+	count INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS samples_repo_time ON samples (repo, time);
+CREATE TABLE IF NOT EXISTS states (
+	key   TEXT PRIMARY KEY,
+	value BLOB NOT NULL
+);
+`
+
+// Store is a SQLite-backed history of stargazers counts and store of state.
+// It is safe for concurrent use.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the SQLite database at path, creating it if necessary.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening history database")
+	}
+	// SQLite allows only one writer at a time.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "error creating history schema")
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Record adds a sample to the history of repo.
+func (s *Store) Record(repo string, sample stargazer.Sample) error {
+	_, err := s.db.Exec(`INSERT INTO samples (repo, time, count) VALUES (?, ?, ?)`,
+		repo, sample.Time.UnixNano(), sample.Count)
+	return errors.Wrap(err, "error recording sample")
+}
+
+// RecordAll adds samples to the history of repo in a single transaction.
+func (s *Store) RecordAll(repo string, samples []stargazer.Sample) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "error recording samples")
+	}
+	for _, sample := range samples {
+		_, err := tx.Exec(`INSERT INTO samples (repo, time, count) VALUES (?, ?, ?)`,
+			repo, sample.Time.UnixNano(), sample.Count)
+		if err != nil {
+			tx.Rollback()
+			return errors.Wrap(err, "error recording samples")
+		}
+	}
+	return errors.Wrap(tx.Commit(), "error recording samples")
+}
+
+// History returns the samples recorded for repo between from and to, oldest
+// first.
+func (s *Store) History(repo string, from, to time.Time) ([]stargazer.Sample, error) {
+	rows, err := s.db.Query(`
+		SELECT time, count FROM samples
+		WHERE repo = ? AND time >= ? AND time <= ?
+		ORDER BY time`,
+		repo, from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, errors.Wrap(err, "error querying history")
+	}
+	defer rows.Close()
+	var samples []stargazer.Sample
+	for rows.Next() {
+		var (
+			nanos int64
+			count int
+		)
+		if err := rows.Scan(&nanos, &count); err != nil {
+			return nil, errors.Wrap(err, "error reading history")
+		}
+		samples = append(samples, stargazer.Sample{Time: time.Unix(0, nanos), Count: count})
+	}
+	return samples, errors.Wrap(rows.Err(), "error reading history")
+}
+
+// Compact reduces the samples recorded before rollupBefore to the last one in
+// each period of the given resolution, and deletes those recorded before
+// dropBefore. A zero time skips that step.
+func (s *Store) Compact(rollupBefore, dropBefore time.Time, resolution time.Duration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "error compacting history")
+	}
+	defer tx.Rollback()
+	if !dropBefore.IsZero() {
+		if _, err := tx.Exec(`DELETE FROM samples WHERE time < ?`, dropBefore.UnixNano()); err != nil {
+			return errors.Wrap(err, "error compacting history")
+		}
+	}
+	if !rollupBefore.IsZero() {
+		_, err := tx.Exec(`
+			DELETE FROM samples WHERE rowid IN (
+				SELECT rowid FROM (
+					SELECT rowid, ROW_NUMBER() OVER (
+						PARTITION BY repo, time / ? ORDER BY time DESC, rowid DESC
+					) AS n
+					FROM samples WHERE time < ?
+				) WHERE n > 1
+			)`,
+			int64(resolution), rollupBefore.UnixNano())
+		if err != nil {
+			return errors.Wrap(err, "error compacting history")
+		}
+	}
+	return errors.Wrap(tx.Commit(), "error compacting history")
+}
+
+// PutState stores value under key, replacing any value already stored there.
+func (s *Store) PutState(key string, value []byte) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO states (key, value) VALUES (?, ?)`,
+		key, value)
+	return errors.Wrap(err, "error storing state")
+}
+
+// States returns every stored state by key.
+func (s *Store) States() (map[string][]byte, error) {
+	rows, err := s.db.Query(`SELECT key, value FROM states`)
+	if err != nil {
+		return nil, errors.Wrap(err, "error querying states")
+	}
+	defer rows.Close()
+	states := make(map[string][]byte)
+	for rows.Next() {
+		var (
+			key   string
+			value []byte
+		)
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, errors.Wrap(err, "error reading state")
+		}
+		states[key] = value
+	}
+	return states, errors.Wrap(rows.Err(), "error reading states")
+}
+
+// DeleteState removes the state stored under key.
+func (s *Store) DeleteState(key string) error {
+	_, err := s.db.Exec(`DELETE FROM states WHERE key = ?`, key)
+	return errors.Wrap(err, "error deleting state")
+}
@@ -0,0 +1,4 @@
+// Package stargazermock provides mocks of the stargazer package's
+// GitHubClient and SMSSender, generated by moq, for testing code that uses
+// them without reaching GitHub or Twilio.
+package stargazermock
@@ -0,0 +1,7 @@
+// Package stargazerpb provides the Protocol Buffers messages and gRPC client
+// and server of the Stargazer service, which github-stargazer serves with
+// -grpc-addr, generated by protoc-gen-go and protoc-gen-go-grpc from
+// stargazer.proto.
+package stargazerpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative stargazer.proto
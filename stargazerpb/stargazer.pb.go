@@ -0,0 +1,1318 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: stargazer.proto
+
+package stargazerpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// WatchSpec describes a repository to watch, like the JSON objects of the
+// /watches HTTP API.
+type WatchSpec struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repo          string                 `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	Provider      string                 `protobuf:"bytes,2,opt,name=provider,proto3" json:"provider,omitempty"`
+	BaseUrl       string                 `protobuf:"bytes,3,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`
+	Count         string                 `protobuf:"bytes,4,opt,name=count,proto3" json:"count,omitempty"`
+	Target        string                 `protobuf:"bytes,5,opt,name=target,proto3" json:"target,omitempty"`
+	Milestones    []int64                `protobuf:"varint,6,rep,packed,name=milestones,proto3" json:"milestones,omitempty"`
+	Progress      []int64                `protobuf:"varint,7,rep,packed,name=progress,proto3" json:"progress,omitempty"`
+	Interval      string                 `protobuf:"bytes,8,opt,name=interval,proto3" json:"interval,omitempty"`
+	IntervalAt    map[int64]string       `protobuf:"bytes,9,rep,name=interval_at,json=intervalAt,proto3" json:"interval_at,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Schedule      string                 `protobuf:"bytes,10,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	Report        string                 `protobuf:"bytes,11,opt,name=report,proto3" json:"report,omitempty"`
+	Deadline      string                 `protobuf:"bytes,12,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	VelocityAlert float64                `protobuf:"fixed64,13,opt,name=velocity_alert,json=velocityAlert,proto3" json:"velocity_alert,omitempty"`
+	Phone         string                 `protobuf:"bytes,14,opt,name=phone,proto3" json:"phone,omitempty"`
+	Lang          string                 `protobuf:"bytes,15,opt,name=lang,proto3" json:"lang,omitempty"`
+	HackerNews    *MentionSpec           `protobuf:"bytes,16,opt,name=hacker_news,json=hackerNews,proto3" json:"hacker_news,omitempty"`
+	Reddit        *MentionSpec           `protobuf:"bytes,17,opt,name=reddit,proto3" json:"reddit,omitempty"`
+	Channels      []string               `protobuf:"bytes,18,rep,name=channels,proto3" json:"channels,omitempty"`
+	Templates     map[string]string      `protobuf:"bytes,19,rep,name=templates,proto3" json:"templates,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Rival         string                 `protobuf:"bytes,20,opt,name=rival,proto3" json:"rival,omitempty"`
+	RivalGap      int64                  `protobuf:"varint,21,opt,name=rival_gap,json=rivalGap,proto3" json:"rival_gap,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchSpec) Reset() {
+	*x = WatchSpec{}
+	mi := &file_stargazer_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchSpec) ProtoMessage() {}
+
+func (x *WatchSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchSpec.ProtoReflect.Descriptor instead.
+func (*WatchSpec) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WatchSpec) GetRepo() string {
+	if x != nil {
+		return x.Repo
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetCount() string {
+	if x != nil {
+		return x.Count
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetMilestones() []int64 {
+	if x != nil {
+		return x.Milestones
+	}
+	return nil
+}
+
+func (x *WatchSpec) GetProgress() []int64 {
+	if x != nil {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *WatchSpec) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetIntervalAt() map[int64]string {
+	if x != nil {
+		return x.IntervalAt
+	}
+	return nil
+}
+
+func (x *WatchSpec) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetReport() string {
+	if x != nil {
+		return x.Report
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetDeadline() string {
+	if x != nil {
+		return x.Deadline
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetVelocityAlert() float64 {
+	if x != nil {
+		return x.VelocityAlert
+	}
+	return 0
+}
+
+func (x *WatchSpec) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetHackerNews() *MentionSpec {
+	if x != nil {
+		return x.HackerNews
+	}
+	return nil
+}
+
+func (x *WatchSpec) GetReddit() *MentionSpec {
+	if x != nil {
+		return x.Reddit
+	}
+	return nil
+}
+
+func (x *WatchSpec) GetChannels() []string {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+func (x *WatchSpec) GetTemplates() map[string]string {
+	if x != nil {
+		return x.Templates
+	}
+	return nil
+}
+
+func (x *WatchSpec) GetRival() string {
+	if x != nil {
+		return x.Rival
+	}
+	return ""
+}
+
+func (x *WatchSpec) GetRivalGap() int64 {
+	if x != nil {
+		return x.RivalGap
+	}
+	return 0
+}
+
+// MentionSpec is how posts about the repository on a site are watched.
+type MentionSpec struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Points        []int64                `protobuf:"varint,1,rep,packed,name=points,proto3" json:"points,omitempty"`
+	Subreddits    []string               `protobuf:"bytes,2,rep,name=subreddits,proto3" json:"subreddits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MentionSpec) Reset() {
+	*x = MentionSpec{}
+	mi := &file_stargazer_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MentionSpec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MentionSpec) ProtoMessage() {}
+
+func (x *MentionSpec) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MentionSpec.ProtoReflect.Descriptor instead.
+func (*MentionSpec) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MentionSpec) GetPoints() []int64 {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+func (x *MentionSpec) GetSubreddits() []string {
+	if x != nil {
+		return x.Subreddits
+	}
+	return nil
+}
+
+// WatchStatus is the state of a watch, like the objects of /status.
+type WatchStatus struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Repository          string                 `protobuf:"bytes,1,opt,name=repository,proto3" json:"repository,omitempty"`
+	StargazersCount     int64                  `protobuf:"varint,2,opt,name=stargazers_count,json=stargazersCount,proto3" json:"stargazers_count,omitempty"`
+	StargazersTarget    int64                  `protobuf:"varint,3,opt,name=stargazers_target,json=stargazersTarget,proto3" json:"stargazers_target,omitempty"`
+	Milestones          []int64                `protobuf:"varint,4,rep,packed,name=milestones,proto3" json:"milestones,omitempty"`
+	Velocity            *Velocity              `protobuf:"bytes,5,opt,name=velocity,proto3" json:"velocity,omitempty"`
+	Paused              bool                   `protobuf:"varint,6,opt,name=paused,proto3" json:"paused,omitempty"`
+	Ready               bool                   `protobuf:"varint,7,opt,name=ready,proto3" json:"ready,omitempty"`
+	ConsecutiveFailures int64                  `protobuf:"varint,8,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *WatchStatus) Reset() {
+	*x = WatchStatus{}
+	mi := &file_stargazer_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchStatus) ProtoMessage() {}
+
+func (x *WatchStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchStatus.ProtoReflect.Descriptor instead.
+func (*WatchStatus) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WatchStatus) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *WatchStatus) GetStargazersCount() int64 {
+	if x != nil {
+		return x.StargazersCount
+	}
+	return 0
+}
+
+func (x *WatchStatus) GetStargazersTarget() int64 {
+	if x != nil {
+		return x.StargazersTarget
+	}
+	return 0
+}
+
+func (x *WatchStatus) GetMilestones() []int64 {
+	if x != nil {
+		return x.Milestones
+	}
+	return nil
+}
+
+func (x *WatchStatus) GetVelocity() *Velocity {
+	if x != nil {
+		return x.Velocity
+	}
+	return nil
+}
+
+func (x *WatchStatus) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *WatchStatus) GetReady() bool {
+	if x != nil {
+		return x.Ready
+	}
+	return false
+}
+
+func (x *WatchStatus) GetConsecutiveFailures() int64 {
+	if x != nil {
+		return x.ConsecutiveFailures
+	}
+	return 0
+}
+
+// Velocity is how fast the count has been growing over the last day.
+type Velocity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StarsPerHour  float64                `protobuf:"fixed64,1,opt,name=stars_per_hour,json=starsPerHour,proto3" json:"stars_per_hour,omitempty"`
+	StarsPerDay   float64                `protobuf:"fixed64,2,opt,name=stars_per_day,json=starsPerDay,proto3" json:"stars_per_day,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Velocity) Reset() {
+	*x = Velocity{}
+	mi := &file_stargazer_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Velocity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Velocity) ProtoMessage() {}
+
+func (x *Velocity) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Velocity.ProtoReflect.Descriptor instead.
+func (*Velocity) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Velocity) GetStarsPerHour() float64 {
+	if x != nil {
+		return x.StarsPerHour
+	}
+	return 0
+}
+
+func (x *Velocity) GetStarsPerDay() float64 {
+	if x != nil {
+		return x.StarsPerDay
+	}
+	return 0
+}
+
+// Event is an event of a watch, or the result of a notification, like the
+// lines of the -event-log file. Fields that don't apply to the event are
+// left unset.
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Repository    string                 `protobuf:"bytes,3,opt,name=repository,proto3" json:"repository,omitempty"`
+	Count         int64                  `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	Previous      int64                  `protobuf:"varint,5,opt,name=previous,proto3" json:"previous,omitempty"`
+	Target        int64                  `protobuf:"varint,6,opt,name=target,proto3" json:"target,omitempty"`
+	Final         bool                   `protobuf:"varint,7,opt,name=final,proto3" json:"final,omitempty"`
+	Op            string                 `protobuf:"bytes,8,opt,name=op,proto3" json:"op,omitempty"`
+	Failures      int64                  `protobuf:"varint,9,opt,name=failures,proto3" json:"failures,omitempty"`
+	Channel       string                 `protobuf:"bytes,10,opt,name=channel,proto3" json:"channel,omitempty"`
+	To            string                 `protobuf:"bytes,11,opt,name=to,proto3" json:"to,omitempty"`
+	Message       string                 `protobuf:"bytes,12,opt,name=message,proto3" json:"message,omitempty"`
+	Result        string                 `protobuf:"bytes,13,opt,name=result,proto3" json:"result,omitempty"`
+	Error         string                 `protobuf:"bytes,14,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_stargazer_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+func (x *Event) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Event) GetPrevious() int64 {
+	if x != nil {
+		return x.Previous
+	}
+	return 0
+}
+
+func (x *Event) GetTarget() int64 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+func (x *Event) GetFinal() bool {
+	if x != nil {
+		return x.Final
+	}
+	return false
+}
+
+func (x *Event) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *Event) GetFailures() int64 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *Event) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *Event) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Event) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Event) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ListWatchesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWatchesRequest) Reset() {
+	*x = ListWatchesRequest{}
+	mi := &file_stargazer_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWatchesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWatchesRequest) ProtoMessage() {}
+
+func (x *ListWatchesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWatchesRequest.ProtoReflect.Descriptor instead.
+func (*ListWatchesRequest) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{5}
+}
+
+type ListWatchesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Watches       []*WatchSpec           `protobuf:"bytes,1,rep,name=watches,proto3" json:"watches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWatchesResponse) Reset() {
+	*x = ListWatchesResponse{}
+	mi := &file_stargazer_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWatchesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWatchesResponse) ProtoMessage() {}
+
+func (x *ListWatchesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWatchesResponse.ProtoReflect.Descriptor instead.
+func (*ListWatchesResponse) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListWatchesResponse) GetWatches() []*WatchSpec {
+	if x != nil {
+		return x.Watches
+	}
+	return nil
+}
+
+type GetWatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repo          string                 `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWatchRequest) Reset() {
+	*x = GetWatchRequest{}
+	mi := &file_stargazer_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWatchRequest) ProtoMessage() {}
+
+func (x *GetWatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWatchRequest.ProtoReflect.Descriptor instead.
+func (*GetWatchRequest) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetWatchRequest) GetRepo() string {
+	if x != nil {
+		return x.Repo
+	}
+	return ""
+}
+
+type CreateWatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Watch         *WatchSpec             `protobuf:"bytes,1,opt,name=watch,proto3" json:"watch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateWatchRequest) Reset() {
+	*x = CreateWatchRequest{}
+	mi := &file_stargazer_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateWatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateWatchRequest) ProtoMessage() {}
+
+func (x *CreateWatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateWatchRequest.ProtoReflect.Descriptor instead.
+func (*CreateWatchRequest) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateWatchRequest) GetWatch() *WatchSpec {
+	if x != nil {
+		return x.Watch
+	}
+	return nil
+}
+
+type UpdateWatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// repo is the repository of the watch to replace, which defaults to the
+	// repo of watch.
+	Repo          string     `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	Watch         *WatchSpec `protobuf:"bytes,2,opt,name=watch,proto3" json:"watch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateWatchRequest) Reset() {
+	*x = UpdateWatchRequest{}
+	mi := &file_stargazer_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateWatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateWatchRequest) ProtoMessage() {}
+
+func (x *UpdateWatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateWatchRequest.ProtoReflect.Descriptor instead.
+func (*UpdateWatchRequest) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateWatchRequest) GetRepo() string {
+	if x != nil {
+		return x.Repo
+	}
+	return ""
+}
+
+func (x *UpdateWatchRequest) GetWatch() *WatchSpec {
+	if x != nil {
+		return x.Watch
+	}
+	return nil
+}
+
+type DeleteWatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repo          string                 `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWatchRequest) Reset() {
+	*x = DeleteWatchRequest{}
+	mi := &file_stargazer_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWatchRequest) ProtoMessage() {}
+
+func (x *DeleteWatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWatchRequest.ProtoReflect.Descriptor instead.
+func (*DeleteWatchRequest) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteWatchRequest) GetRepo() string {
+	if x != nil {
+		return x.Repo
+	}
+	return ""
+}
+
+type DeleteWatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteWatchResponse) Reset() {
+	*x = DeleteWatchResponse{}
+	mi := &file_stargazer_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteWatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteWatchResponse) ProtoMessage() {}
+
+func (x *DeleteWatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteWatchResponse.ProtoReflect.Descriptor instead.
+func (*DeleteWatchResponse) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{11}
+}
+
+type PauseWatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repo          string                 `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseWatchRequest) Reset() {
+	*x = PauseWatchRequest{}
+	mi := &file_stargazer_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseWatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseWatchRequest) ProtoMessage() {}
+
+func (x *PauseWatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseWatchRequest.ProtoReflect.Descriptor instead.
+func (*PauseWatchRequest) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PauseWatchRequest) GetRepo() string {
+	if x != nil {
+		return x.Repo
+	}
+	return ""
+}
+
+type ResumeWatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repo          string                 `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeWatchRequest) Reset() {
+	*x = ResumeWatchRequest{}
+	mi := &file_stargazer_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeWatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeWatchRequest) ProtoMessage() {}
+
+func (x *ResumeWatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeWatchRequest.ProtoReflect.Descriptor instead.
+func (*ResumeWatchRequest) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ResumeWatchRequest) GetRepo() string {
+	if x != nil {
+		return x.Repo
+	}
+	return ""
+}
+
+type GetStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// repo narrows the status to that of one repository.
+	Repo          string `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_stargazer_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetStatusRequest) GetRepo() string {
+	if x != nil {
+		return x.Repo
+	}
+	return ""
+}
+
+type GetStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Watches       []*WatchStatus         `protobuf:"bytes,1,rep,name=watches,proto3" json:"watches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusResponse) Reset() {
+	*x = GetStatusResponse{}
+	mi := &file_stargazer_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusResponse) ProtoMessage() {}
+
+func (x *GetStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetStatusResponse) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetStatusResponse) GetWatches() []*WatchStatus {
+	if x != nil {
+		return x.Watches
+	}
+	return nil
+}
+
+type StreamEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// repo narrows the events to those of one repository.
+	Repo          string `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamEventsRequest) Reset() {
+	*x = StreamEventsRequest{}
+	mi := &file_stargazer_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamEventsRequest) ProtoMessage() {}
+
+func (x *StreamEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_stargazer_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamEventsRequest) Descriptor() ([]byte, []int) {
+	return file_stargazer_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *StreamEventsRequest) GetRepo() string {
+	if x != nil {
+		return x.Repo
+	}
+	return ""
+}
+
+var File_stargazer_proto protoreflect.FileDescriptor
+
+const file_stargazer_proto_rawDesc = "" +
+	"\n" +
+	"\x0fstargazer.proto\x12\fstargazer.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc8\x06\n" +
+	"\tWatchSpec\x12\x12\n" +
+	"\x04repo\x18\x01 \x01(\tR\x04repo\x12\x1a\n" +
+	"\bprovider\x18\x02 \x01(\tR\bprovider\x12\x19\n" +
+	"\bbase_url\x18\x03 \x01(\tR\abaseUrl\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\tR\x05count\x12\x16\n" +
+	"\x06target\x18\x05 \x01(\tR\x06target\x12\x1e\n" +
+	"\n" +
+	"milestones\x18\x06 \x03(\x03R\n" +
+	"milestones\x12\x1a\n" +
+	"\bprogress\x18\a \x03(\x03R\bprogress\x12\x1a\n" +
+	"\binterval\x18\b \x01(\tR\binterval\x12H\n" +
+	"\vinterval_at\x18\t \x03(\v2'.stargazer.v1.WatchSpec.IntervalAtEntryR\n" +
+	"intervalAt\x12\x1a\n" +
+	"\bschedule\x18\n" +
+	" \x01(\tR\bschedule\x12\x16\n" +
+	"\x06report\x18\v \x01(\tR\x06report\x12\x1a\n" +
+	"\bdeadline\x18\f \x01(\tR\bdeadline\x12%\n" +
+	"\x0evelocity_alert\x18\r \x01(\x01R\rvelocityAlert\x12\x14\n" +
+	"\x05phone\x18\x0e \x01(\tR\x05phone\x12\x12\n" +
+	"\x04lang\x18\x0f \x01(\tR\x04lang\x12:\n" +
+	"\vhacker_news\x18\x10 \x01(\v2\x19.stargazer.v1.MentionSpecR\n" +
+	"hackerNews\x121\n" +
+	"\x06reddit\x18\x11 \x01(\v2\x19.stargazer.v1.MentionSpecR\x06reddit\x12\x1a\n" +
+	"\bchannels\x18\x12 \x03(\tR\bchannels\x12D\n" +
+	"\ttemplates\x18\x13 \x03(\v2&.stargazer.v1.WatchSpec.TemplatesEntryR\ttemplates\x12\x14\n" +
+	"\x05rival\x18\x14 \x01(\tR\x05rival\x12\x1b\n" +
+	"\trival_gap\x18\x15 \x01(\x03R\brivalGap\x1a=\n" +
+	"\x0fIntervalAtEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x03R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a<\n" +
+	"\x0eTemplatesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"E\n" +
+	"\vMentionSpec\x12\x16\n" +
+	"\x06points\x18\x01 \x03(\x03R\x06points\x12\x1e\n" +
+	"\n" +
+	"subreddits\x18\x02 \x03(\tR\n" +
+	"subreddits\"\xba\x02\n" +
+	"\vWatchStatus\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x01 \x01(\tR\n" +
+	"repository\x12)\n" +
+	"\x10stargazers_count\x18\x02 \x01(\x03R\x0fstargazersCount\x12+\n" +
+	"\x11stargazers_target\x18\x03 \x01(\x03R\x10stargazersTarget\x12\x1e\n" +
+	"\n" +
+	"milestones\x18\x04 \x03(\x03R\n" +
+	"milestones\x122\n" +
+	"\bvelocity\x18\x05 \x01(\v2\x16.stargazer.v1.VelocityR\bvelocity\x12\x16\n" +
+	"\x06paused\x18\x06 \x01(\bR\x06paused\x12\x14\n" +
+	"\x05ready\x18\a \x01(\bR\x05ready\x121\n" +
+	"\x14consecutive_failures\x18\b \x01(\x03R\x13consecutiveFailures\"T\n" +
+	"\bVelocity\x12$\n" +
+	"\x0estars_per_hour\x18\x01 \x01(\x01R\fstarsPerHour\x12\"\n" +
+	"\rstars_per_day\x18\x02 \x01(\x01R\vstarsPerDay\"\xe9\x02\n" +
+	"\x05Event\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1e\n" +
+	"\n" +
+	"repository\x18\x03 \x01(\tR\n" +
+	"repository\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x03R\x05count\x12\x1a\n" +
+	"\bprevious\x18\x05 \x01(\x03R\bprevious\x12\x16\n" +
+	"\x06target\x18\x06 \x01(\x03R\x06target\x12\x14\n" +
+	"\x05final\x18\a \x01(\bR\x05final\x12\x0e\n" +
+	"\x02op\x18\b \x01(\tR\x02op\x12\x1a\n" +
+	"\bfailures\x18\t \x01(\x03R\bfailures\x12\x18\n" +
+	"\achannel\x18\n" +
+	" \x01(\tR\achannel\x12\x0e\n" +
+	"\x02to\x18\v \x01(\tR\x02to\x12\x18\n" +
+	"\amessage\x18\f \x01(\tR\amessage\x12\x16\n" +
+	"\x06result\x18\r \x01(\tR\x06result\x12\x14\n" +
+	"\x05error\x18\x0e \x01(\tR\x05error\"\x14\n" +
+	"\x12ListWatchesRequest\"H\n" +
+	"\x13ListWatchesResponse\x121\n" +
+	"\awatches\x18\x01 \x03(\v2\x17.stargazer.v1.WatchSpecR\awatches\"%\n" +
+	"\x0fGetWatchRequest\x12\x12\n" +
+	"\x04repo\x18\x01 \x01(\tR\x04repo\"C\n" +
+	"\x12CreateWatchRequest\x12-\n" +
+	"\x05watch\x18\x01 \x01(\v2\x17.stargazer.v1.WatchSpecR\x05watch\"W\n" +
+	"\x12UpdateWatchRequest\x12\x12\n" +
+	"\x04repo\x18\x01 \x01(\tR\x04repo\x12-\n" +
+	"\x05watch\x18\x02 \x01(\v2\x17.stargazer.v1.WatchSpecR\x05watch\"(\n" +
+	"\x12DeleteWatchRequest\x12\x12\n" +
+	"\x04repo\x18\x01 \x01(\tR\x04repo\"\x15\n" +
+	"\x13DeleteWatchResponse\"'\n" +
+	"\x11PauseWatchRequest\x12\x12\n" +
+	"\x04repo\x18\x01 \x01(\tR\x04repo\"(\n" +
+	"\x12ResumeWatchRequest\x12\x12\n" +
+	"\x04repo\x18\x01 \x01(\tR\x04repo\"&\n" +
+	"\x10GetStatusRequest\x12\x12\n" +
+	"\x04repo\x18\x01 \x01(\tR\x04repo\"H\n" +
+	"\x11GetStatusResponse\x123\n" +
+	"\awatches\x18\x01 \x03(\v2\x19.stargazer.v1.WatchStatusR\awatches\")\n" +
+	"\x13StreamEventsRequest\x12\x12\n" +
+	"\x04repo\x18\x01 \x01(\tR\x04repo2\xb9\x05\n" +
+	"\tStargazer\x12R\n" +
+	"\vListWatches\x12 .stargazer.v1.ListWatchesRequest\x1a!.stargazer.v1.ListWatchesResponse\x12B\n" +
+	"\bGetWatch\x12\x1d.stargazer.v1.GetWatchRequest\x1a\x17.stargazer.v1.WatchSpec\x12H\n" +
+	"\vCreateWatch\x12 .stargazer.v1.CreateWatchRequest\x1a\x17.stargazer.v1.WatchSpec\x12H\n" +
+	"\vUpdateWatch\x12 .stargazer.v1.UpdateWatchRequest\x1a\x17.stargazer.v1.WatchSpec\x12R\n" +
+	"\vDeleteWatch\x12 .stargazer.v1.DeleteWatchRequest\x1a!.stargazer.v1.DeleteWatchResponse\x12H\n" +
+	"\n" +
+	"PauseWatch\x12\x1f.stargazer.v1.PauseWatchRequest\x1a\x19.stargazer.v1.WatchStatus\x12J\n" +
+	"\vResumeWatch\x12 .stargazer.v1.ResumeWatchRequest\x1a\x19.stargazer.v1.WatchStatus\x12L\n" +
+	"\tGetStatus\x12\x1e.stargazer.v1.GetStatusRequest\x1a\x1f.stargazer.v1.GetStatusResponse\x12H\n" +
+	"\fStreamEvents\x12!.stargazer.v1.StreamEventsRequest\x1a\x13.stargazer.v1.Event0\x01B0Z.github.com/ianfoo/github-stargazer/stargazerpbb\x06proto3"
+
+var (
+	file_stargazer_proto_rawDescOnce sync.Once
+	file_stargazer_proto_rawDescData []byte
+)
+
+func file_stargazer_proto_rawDescGZIP() []byte {
+	file_stargazer_proto_rawDescOnce.Do(func() {
+		file_stargazer_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_stargazer_proto_rawDesc), len(file_stargazer_proto_rawDesc)))
+	})
+	return file_stargazer_proto_rawDescData
+}
+
+var file_stargazer_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_stargazer_proto_goTypes = []any{
+	(*WatchSpec)(nil),             // 0: stargazer.v1.WatchSpec
+	(*MentionSpec)(nil),           // 1: stargazer.v1.MentionSpec
+	(*WatchStatus)(nil),           // 2: stargazer.v1.WatchStatus
+	(*Velocity)(nil),              // 3: stargazer.v1.Velocity
+	(*Event)(nil),                 // 4: stargazer.v1.Event
+	(*ListWatchesRequest)(nil),    // 5: stargazer.v1.ListWatchesRequest
+	(*ListWatchesResponse)(nil),   // 6: stargazer.v1.ListWatchesResponse
+	(*GetWatchRequest)(nil),       // 7: stargazer.v1.GetWatchRequest
+	(*CreateWatchRequest)(nil),    // 8: stargazer.v1.CreateWatchRequest
+	(*UpdateWatchRequest)(nil),    // 9: stargazer.v1.UpdateWatchRequest
+	(*DeleteWatchRequest)(nil),    // 10: stargazer.v1.DeleteWatchRequest
+	(*DeleteWatchResponse)(nil),   // 11: stargazer.v1.DeleteWatchResponse
+	(*PauseWatchRequest)(nil),     // 12: stargazer.v1.PauseWatchRequest
+	(*ResumeWatchRequest)(nil),    // 13: stargazer.v1.ResumeWatchRequest
+	(*GetStatusRequest)(nil),      // 14: stargazer.v1.GetStatusRequest
+	(*GetStatusResponse)(nil),     // 15: stargazer.v1.GetStatusResponse
+	(*StreamEventsRequest)(nil),   // 16: stargazer.v1.StreamEventsRequest
+	nil,                           // 17: stargazer.v1.WatchSpec.IntervalAtEntry
+	nil,                           // 18: stargazer.v1.WatchSpec.TemplatesEntry
+	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
+}
+var file_stargazer_proto_depIdxs = []int32{
+	17, // 0: stargazer.v1.WatchSpec.interval_at:type_name -> stargazer.v1.WatchSpec.IntervalAtEntry
+	1,  // 1: stargazer.v1.WatchSpec.hacker_news:type_name -> stargazer.v1.MentionSpec
+	1,  // 2: stargazer.v1.WatchSpec.reddit:type_name -> stargazer.v1.MentionSpec
+	18, // 3: stargazer.v1.WatchSpec.templates:type_name -> stargazer.v1.WatchSpec.TemplatesEntry
+	3,  // 4: stargazer.v1.WatchStatus.velocity:type_name -> stargazer.v1.Velocity
+	19, // 5: stargazer.v1.Event.time:type_name -> google.protobuf.Timestamp
+	0,  // 6: stargazer.v1.ListWatchesResponse.watches:type_name -> stargazer.v1.WatchSpec
+	0,  // 7: stargazer.v1.CreateWatchRequest.watch:type_name -> stargazer.v1.WatchSpec
+	0,  // 8: stargazer.v1.UpdateWatchRequest.watch:type_name -> stargazer.v1.WatchSpec
+	2,  // 9: stargazer.v1.GetStatusResponse.watches:type_name -> stargazer.v1.WatchStatus
+	5,  // 10: stargazer.v1.Stargazer.ListWatches:input_type -> stargazer.v1.ListWatchesRequest
+	7,  // 11: stargazer.v1.Stargazer.GetWatch:input_type -> stargazer.v1.GetWatchRequest
+	8,  // 12: stargazer.v1.Stargazer.CreateWatch:input_type -> stargazer.v1.CreateWatchRequest
+	9,  // 13: stargazer.v1.Stargazer.UpdateWatch:input_type -> stargazer.v1.UpdateWatchRequest
+	10, // 14: stargazer.v1.Stargazer.DeleteWatch:input_type -> stargazer.v1.DeleteWatchRequest
+	12, // 15: stargazer.v1.Stargazer.PauseWatch:input_type -> stargazer.v1.PauseWatchRequest
+	13, // 16: stargazer.v1.Stargazer.ResumeWatch:input_type -> stargazer.v1.ResumeWatchRequest
+	14, // 17: stargazer.v1.Stargazer.GetStatus:input_type -> stargazer.v1.GetStatusRequest
+	16, // 18: stargazer.v1.Stargazer.StreamEvents:input_type -> stargazer.v1.StreamEventsRequest
+	6,  // 19: stargazer.v1.Stargazer.ListWatches:output_type -> stargazer.v1.ListWatchesResponse
+	0,  // 20: stargazer.v1.Stargazer.GetWatch:output_type -> stargazer.v1.WatchSpec
+	0,  // 21: stargazer.v1.Stargazer.CreateWatch:output_type -> stargazer.v1.WatchSpec
+	0,  // 22: stargazer.v1.Stargazer.UpdateWatch:output_type -> stargazer.v1.WatchSpec
+	11, // 23: stargazer.v1.Stargazer.DeleteWatch:output_type -> stargazer.v1.DeleteWatchResponse
+	2,  // 24: stargazer.v1.Stargazer.PauseWatch:output_type -> stargazer.v1.WatchStatus
+	2,  // 25: stargazer.v1.Stargazer.ResumeWatch:output_type -> stargazer.v1.WatchStatus
+	15, // 26: stargazer.v1.Stargazer.GetStatus:output_type -> stargazer.v1.GetStatusResponse
+	4,  // 27: stargazer.v1.Stargazer.StreamEvents:output_type -> stargazer.v1.Event
+	19, // [19:28] is the sub-list for method output_type
+	10, // [10:19] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_stargazer_proto_init() }
+func file_stargazer_proto_init() {
+	if File_stargazer_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_stargazer_proto_rawDesc), len(file_stargazer_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_stargazer_proto_goTypes,
+		DependencyIndexes: file_stargazer_proto_depIdxs,
+		MessageInfos:      file_stargazer_proto_msgTypes,
+	}.Build()
+	File_stargazer_proto = out.File
+	file_stargazer_proto_goTypes = nil
+	file_stargazer_proto_depIdxs = nil
+}
@@ -0,0 +1,463 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: stargazer.proto
+
+package stargazerpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Stargazer_ListWatches_FullMethodName  = "/stargazer.v1.Stargazer/ListWatches"
+	Stargazer_GetWatch_FullMethodName     = "/stargazer.v1.Stargazer/GetWatch"
+	Stargazer_CreateWatch_FullMethodName  = "/stargazer.v1.Stargazer/CreateWatch"
+	Stargazer_UpdateWatch_FullMethodName  = "/stargazer.v1.Stargazer/UpdateWatch"
+	Stargazer_DeleteWatch_FullMethodName  = "/stargazer.v1.Stargazer/DeleteWatch"
+	Stargazer_PauseWatch_FullMethodName   = "/stargazer.v1.Stargazer/PauseWatch"
+	Stargazer_ResumeWatch_FullMethodName  = "/stargazer.v1.Stargazer/ResumeWatch"
+	Stargazer_GetStatus_FullMethodName    = "/stargazer.v1.Stargazer/GetStatus"
+	Stargazer_StreamEvents_FullMethodName = "/stargazer.v1.Stargazer/StreamEvents"
+)
+
+// StargazerClient is the client API for Stargazer service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Stargazer manages the watches of a running github-stargazer, reports their
+// status and streams their events. It is served alongside the HTTP status
+// server when -grpc-addr is set, and every call must carry the control token
+// as "authorization: Bearer <token>" metadata.
+type StargazerClient interface {
+	// ListWatches lists the specs of every watch.
+	ListWatches(ctx context.Context, in *ListWatchesRequest, opts ...grpc.CallOption) (*ListWatchesResponse, error)
+	// GetWatch returns the spec of the watch of a repository.
+	GetWatch(ctx context.Context, in *GetWatchRequest, opts ...grpc.CallOption) (*WatchSpec, error)
+	// CreateWatch starts a watch, failing with ALREADY_EXISTS if the
+	// repository is already watched.
+	CreateWatch(ctx context.Context, in *CreateWatchRequest, opts ...grpc.CallOption) (*WatchSpec, error)
+	// UpdateWatch replaces the spec of the watch of a repository.
+	UpdateWatch(ctx context.Context, in *UpdateWatchRequest, opts ...grpc.CallOption) (*WatchSpec, error)
+	// DeleteWatch stops and removes the watch of a repository.
+	DeleteWatch(ctx context.Context, in *DeleteWatchRequest, opts ...grpc.CallOption) (*DeleteWatchResponse, error)
+	// PauseWatch stops polling the repository until ResumeWatch is called.
+	PauseWatch(ctx context.Context, in *PauseWatchRequest, opts ...grpc.CallOption) (*WatchStatus, error)
+	// ResumeWatch resumes polling a paused repository.
+	ResumeWatch(ctx context.Context, in *ResumeWatchRequest, opts ...grpc.CallOption) (*WatchStatus, error)
+	// GetStatus returns the status of every watch, or of one repository.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	// StreamEvents streams the events of every watch, or of one repository,
+	// and the results of their notifications, as they happen, until the
+	// client cancels the call.
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type stargazerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStargazerClient(cc grpc.ClientConnInterface) StargazerClient {
+	return &stargazerClient{cc}
+}
+
+func (c *stargazerClient) ListWatches(ctx context.Context, in *ListWatchesRequest, opts ...grpc.CallOption) (*ListWatchesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWatchesResponse)
+	err := c.cc.Invoke(ctx, Stargazer_ListWatches_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stargazerClient) GetWatch(ctx context.Context, in *GetWatchRequest, opts ...grpc.CallOption) (*WatchSpec, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WatchSpec)
+	err := c.cc.Invoke(ctx, Stargazer_GetWatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stargazerClient) CreateWatch(ctx context.Context, in *CreateWatchRequest, opts ...grpc.CallOption) (*WatchSpec, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WatchSpec)
+	err := c.cc.Invoke(ctx, Stargazer_CreateWatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stargazerClient) UpdateWatch(ctx context.Context, in *UpdateWatchRequest, opts ...grpc.CallOption) (*WatchSpec, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WatchSpec)
+	err := c.cc.Invoke(ctx, Stargazer_UpdateWatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stargazerClient) DeleteWatch(ctx context.Context, in *DeleteWatchRequest, opts ...grpc.CallOption) (*DeleteWatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteWatchResponse)
+	err := c.cc.Invoke(ctx, Stargazer_DeleteWatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stargazerClient) PauseWatch(ctx context.Context, in *PauseWatchRequest, opts ...grpc.CallOption) (*WatchStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WatchStatus)
+	err := c.cc.Invoke(ctx, Stargazer_PauseWatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stargazerClient) ResumeWatch(ctx context.Context, in *ResumeWatchRequest, opts ...grpc.CallOption) (*WatchStatus, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WatchStatus)
+	err := c.cc.Invoke(ctx, Stargazer_ResumeWatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stargazerClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatusResponse)
+	err := c.cc.Invoke(ctx, Stargazer_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stargazerClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Stargazer_ServiceDesc.Streams[0], Stargazer_StreamEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamEventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Stargazer_StreamEventsClient = grpc.ServerStreamingClient[Event]
+
+// StargazerServer is the server API for Stargazer service.
+// All implementations must embed UnimplementedStargazerServer
+// for forward compatibility.
+//
+// Stargazer manages the watches of a running github-stargazer, reports their
+// status and streams their events. It is served alongside the HTTP status
+// server when -grpc-addr is set, and every call must carry the control token
+// as "authorization: Bearer <token>" metadata.
+type StargazerServer interface {
+	// ListWatches lists the specs of every watch.
+	ListWatches(context.Context, *ListWatchesRequest) (*ListWatchesResponse, error)
+	// GetWatch returns the spec of the watch of a repository.
+	GetWatch(context.Context, *GetWatchRequest) (*WatchSpec, error)
+	// CreateWatch starts a watch, failing with ALREADY_EXISTS if the
+	// repository is already watched.
+	CreateWatch(context.Context, *CreateWatchRequest) (*WatchSpec, error)
+	// UpdateWatch replaces the spec of the watch of a repository.
+	UpdateWatch(context.Context, *UpdateWatchRequest) (*WatchSpec, error)
+	// DeleteWatch stops and removes the watch of a repository.
+	DeleteWatch(context.Context, *DeleteWatchRequest) (*DeleteWatchResponse, error)
+	// PauseWatch stops polling the repository until ResumeWatch is called.
+	PauseWatch(context.Context, *PauseWatchRequest) (*WatchStatus, error)
+	// ResumeWatch resumes polling a paused repository.
+	ResumeWatch(context.Context, *ResumeWatchRequest) (*WatchStatus, error)
+	// GetStatus returns the status of every watch, or of one repository.
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	// StreamEvents streams the events of every watch, or of one repository,
+	// and the results of their notifications, as they happen, until the
+	// client cancels the call.
+	StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedStargazerServer()
+}
+
+// UnimplementedStargazerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStargazerServer struct{}
+
+func (UnimplementedStargazerServer) ListWatches(context.Context, *ListWatchesRequest) (*ListWatchesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListWatches not implemented")
+}
+func (UnimplementedStargazerServer) GetWatch(context.Context, *GetWatchRequest) (*WatchSpec, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetWatch not implemented")
+}
+func (UnimplementedStargazerServer) CreateWatch(context.Context, *CreateWatchRequest) (*WatchSpec, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateWatch not implemented")
+}
+func (UnimplementedStargazerServer) UpdateWatch(context.Context, *UpdateWatchRequest) (*WatchSpec, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdateWatch not implemented")
+}
+func (UnimplementedStargazerServer) DeleteWatch(context.Context, *DeleteWatchRequest) (*DeleteWatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DeleteWatch not implemented")
+}
+func (UnimplementedStargazerServer) PauseWatch(context.Context, *PauseWatchRequest) (*WatchStatus, error) {
+	return nil, status.Error(codes.Unimplemented, "method PauseWatch not implemented")
+}
+func (UnimplementedStargazerServer) ResumeWatch(context.Context, *ResumeWatchRequest) (*WatchStatus, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResumeWatch not implemented")
+}
+func (UnimplementedStargazerServer) GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedStargazerServer) StreamEvents(*StreamEventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedStargazerServer) mustEmbedUnimplementedStargazerServer() {}
+func (UnimplementedStargazerServer) testEmbeddedByValue()                   {}
+
+// UnsafeStargazerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StargazerServer will
+// result in compilation errors.
+type UnsafeStargazerServer interface {
+	mustEmbedUnimplementedStargazerServer()
+}
+
+func RegisterStargazerServer(s grpc.ServiceRegistrar, srv StargazerServer) {
+	// If the following call panics, it indicates UnimplementedStargazerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Stargazer_ServiceDesc, srv)
+}
+
+func _Stargazer_ListWatches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWatchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StargazerServer).ListWatches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stargazer_ListWatches_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StargazerServer).ListWatches(ctx, req.(*ListWatchesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stargazer_GetWatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StargazerServer).GetWatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stargazer_GetWatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StargazerServer).GetWatch(ctx, req.(*GetWatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stargazer_CreateWatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StargazerServer).CreateWatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stargazer_CreateWatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StargazerServer).CreateWatch(ctx, req.(*CreateWatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stargazer_UpdateWatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateWatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StargazerServer).UpdateWatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stargazer_UpdateWatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StargazerServer).UpdateWatch(ctx, req.(*UpdateWatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stargazer_DeleteWatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StargazerServer).DeleteWatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stargazer_DeleteWatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StargazerServer).DeleteWatch(ctx, req.(*DeleteWatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stargazer_PauseWatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseWatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StargazerServer).PauseWatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stargazer_PauseWatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StargazerServer).PauseWatch(ctx, req.(*PauseWatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stargazer_ResumeWatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeWatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StargazerServer).ResumeWatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stargazer_ResumeWatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StargazerServer).ResumeWatch(ctx, req.(*ResumeWatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stargazer_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StargazerServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Stargazer_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StargazerServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Stargazer_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StargazerServer).StreamEvents(m, &grpc.GenericServerStream[StreamEventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Stargazer_StreamEventsServer = grpc.ServerStreamingServer[Event]
+
+// Stargazer_ServiceDesc is the grpc.ServiceDesc for Stargazer service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Stargazer_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "stargazer.v1.Stargazer",
+	HandlerType: (*StargazerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListWatches",
+			Handler:    _Stargazer_ListWatches_Handler,
+		},
+		{
+			MethodName: "GetWatch",
+			Handler:    _Stargazer_GetWatch_Handler,
+		},
+		{
+			MethodName: "CreateWatch",
+			Handler:    _Stargazer_CreateWatch_Handler,
+		},
+		{
+			MethodName: "UpdateWatch",
+			Handler:    _Stargazer_UpdateWatch_Handler,
+		},
+		{
+			MethodName: "DeleteWatch",
+			Handler:    _Stargazer_DeleteWatch_Handler,
+		},
+		{
+			MethodName: "PauseWatch",
+			Handler:    _Stargazer_PauseWatch_Handler,
+		},
+		{
+			MethodName: "ResumeWatch",
+			Handler:    _Stargazer_ResumeWatch_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Stargazer_GetStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Stargazer_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "stargazer.proto",
+}
@@ -0,0 +1,171 @@
+package stargazer
+
+import (
+	"sort"
+	"time"
+)
+
+// State is the part of a gazer's state worth keeping across restarts, so that
+// a restarted gazer doesn't fire hooks again for targets it has already
+// reached. Get it with State, and restore it with WithState.
+type State struct {
+	StargazersCount int `json:"stargazers_count"`
+
+	// RepositoryID is the numeric ID of the repository, once it has been
+	// resolved, so that a restored gazer fetches the repository by ID
+	// even if it was renamed while the gazer wasn't running.
+	RepositoryID int64 `json:"repository_id,omitempty"`
+
+	// RenamedTo is the name the repository was renamed or transferred to,
+	// if it was, so that a restored gazer doesn't report the rename again.
+	RenamedTo string `json:"renamed_to,omitempty"`
+
+	// Retired is whether the repository was found to have been archived or
+	// deleted, so that a restored gazer that finds it so again doesn't
+	// report it again.
+	Retired bool `json:"retired,omitempty"`
+
+	// License is the license of the repository as of the latest fetch, if
+	// it has been fetched, so that a restored gazer reports it being changed
+	// while the gazer wasn't running.
+	License *string `json:"license,omitempty"`
+
+	// ContentHashes are the hashes of the parts of the repository watched
+	// with WithContentWatch, so that a restored gazer reports changes made
+	// while it wasn't running.
+	ContentHashes map[string]string `json:"content_hashes,omitempty"`
+
+	// MissedDeadline is the deadline that passed before every target was
+	// reached, if one did, so that a gazer restored with the same deadline
+	// doesn't report it again.
+	MissedDeadline *time.Time `json:"missed_deadline,omitempty"`
+
+	// RelativeBaseline is the count relative targets were resolved against,
+	// or nil if they haven't been resolved (or the targets aren't relative).
+	RelativeBaseline *int `json:"relative_baseline,omitempty"`
+
+	FiredMilestones []int                     `json:"fired_milestones,omitempty"`
+	FiredProgress   []int                     `json:"fired_progress,omitempty"`
+	VelocityAlerted bool                      `json:"velocity_alerted,omitempty"`
+	RivalAhead      *bool                     `json:"rival_ahead,omitempty"`
+	RivalGapAlerted bool                      `json:"rival_gap_alerted,omitempty"`
+	Responses       map[string]CachedResponse `json:"responses,omitempty"`
+	History         []Sample                  `json:"history,omitempty"`
+}
+
+// WithState is an option that can be passed to NewGitHubStargazer to restore
+// state saved from an earlier gazer for the same repository.
+func WithState(st State) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.restored = &st
+	}
+}
+
+// WithStateHook is an option that can be passed to NewGitHubStargazer to have
+// hook run with the gazer's state whenever it marks a target, milestone,
+// progress checkpoint, velocity alert, rivalry or deadline as fired, the
+// repository as archived or deleted, or a change to its license or content as
+// seen, before the hooks for it run. Saving the state there, rather than
+// after the hooks have sent their notifications, means that a gazer restored
+// from it after a crash or restart doesn't send them again, at the cost of
+// not sending any that were cut short.
+func WithStateHook(hook func(State) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.StateHook = hook
+	}
+}
+
+// saveState runs the state hook, if there is one.
+func (sg *GitHubStargazer) saveState() {
+	if sg.StateHook == nil {
+		return
+	}
+	if err := sg.StateHook(sg.State()); err != nil {
+		sg.log.Warnw("error calling state hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+}
+
+// State returns the gazer's current state, for saving.
+func (sg *GitHubStargazer) State() State {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	st := State{
+		StargazersCount: sg.stargazersCount,
+		RepositoryID:    sg.repoID,
+		RenamedTo:       sg.renamedTo,
+		Retired:         sg.retired,
+		License:         sg.license,
+		ContentHashes:   copyStrings(sg.contentHashes),
+		FiredMilestones: sortedKeys(sg.fired),
+		FiredProgress:   sortedKeys(sg.progressFired),
+		VelocityAlerted: sg.velocityAlerted,
+		RivalAhead:      sg.rivalAhead,
+		RivalGapAlerted: sg.rivalGapAlerted,
+		Responses:       sg.cache.snapshot(),
+		History:         sg.velocity.history(),
+	}
+	if !sg.missedDeadline.IsZero() {
+		missed := sg.missedDeadline
+		st.MissedDeadline = &missed
+	}
+	if sg.resolved {
+		baseline := sg.baseline
+		st.RelativeBaseline = &baseline
+	}
+	return st
+}
+
+// restore applies saved state to a newly constructed gazer.
+func (sg *GitHubStargazer) restore(st State) {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	sg.stargazersCount = st.StargazersCount
+	sg.repoID = st.RepositoryID
+	sg.renamedTo = st.RenamedTo
+	sg.retired = st.Retired
+	sg.license = st.License
+	sg.contentHashes = copyStrings(st.ContentHashes)
+	if sg.relative && st.RelativeBaseline != nil {
+		sg.resolveRelativeTargets(*st.RelativeBaseline)
+	}
+	for _, m := range st.FiredMilestones {
+		sg.fired[m] = true
+	}
+	for _, pct := range st.FiredProgress {
+		sg.progressFired[pct] = true
+	}
+	sg.velocityAlerted = st.VelocityAlerted
+	sg.rivalAhead = st.RivalAhead
+	sg.rivalGapAlerted = st.RivalGapAlerted
+	if st.MissedDeadline != nil {
+		sg.missedDeadline = *st.MissedDeadline
+	}
+	for endpoint, cr := range st.Responses {
+		sg.cache.put(endpoint, cr)
+	}
+	for _, s := range st.History {
+		sg.velocity.record(s.Time, s.Count)
+	}
+}
+
+func copyStrings(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func sortedKeys(m map[int]bool) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,50 @@
+package stargazer
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TokenSource supplies a secret, like an API token, each time one is needed.
+// This allows the secret to be rotated while the program is running.
+type TokenSource func() (string, error)
+
+// StaticTokenSource returns a TokenSource that always supplies token.
+func StaticTokenSource(token string) TokenSource {
+	return func() (string, error) {
+		return token, nil
+	}
+}
+
+// FileTokenSource returns a TokenSource that reads the secret from the file at
+// path, such as a mounted Kubernetes secret or a systemd credential. The file
+// is read again whenever its modification time changes. Surrounding
+// whitespace is trimmed from the secret.
+func FileTokenSource(path string) TokenSource {
+	var (
+		mu      sync.Mutex
+		modTime time.Time
+		token   string
+	)
+	return func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", errors.Wrap(err, "error reading token file")
+		}
+		if info.ModTime().Equal(modTime) {
+			return token, nil
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", errors.Wrap(err, "error reading token file")
+		}
+		modTime, token = info.ModTime(), strings.TrimSpace(string(b))
+		return token, nil
+	}
+}
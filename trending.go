@@ -0,0 +1,311 @@
+package stargazer
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Periods of the GitHub Trending lists, which rank repositories by the stars
+// they gained over them.
+const (
+	TrendingDaily   = "daily"
+	TrendingWeekly  = "weekly"
+	TrendingMonthly = "monthly"
+)
+
+// Trending describes a repository entering or leaving a GitHub Trending
+// list.
+type Trending struct {
+	Repository string
+	Time       time.Time
+
+	// Since is the period the list covers, such as TrendingDaily, and
+	// Language the language it is for, or empty if it is for every
+	// language.
+	Since    string
+	Language string
+
+	// Entered is true if the repository entered the list and false if it
+	// left it. Rank is its place on the list, from 1, if it entered it.
+	Entered bool
+	Rank    int
+}
+
+// trendingList is a GitHub Trending list, for a period and a language.
+type trendingList struct {
+	since    string
+	language string
+}
+
+// trendingRepoPattern matches the link to each repository on a GitHub
+// Trending page, in the heading of the row listing it.
+var trendingRepoPattern = regexp.MustCompile(`(?s)<h2[^>]*>\s*<a[^>]*href="/([^"/?#]+/[^"/?#]+)"`)
+
+// TrendingWatcher polls GitHub Trending, running its hook when a repository
+// enters or leaves any of the lists it watches. GitHub has no API for them,
+// so their pages are read. The lists found by the first poll are taken as
+// already known, so the hook only runs for what happens after the watcher
+// starts.
+type TrendingWatcher struct {
+	// Repository is the repository looked for on the lists, in owner/repo
+	// format.
+	Repository string
+
+	// Interval is how often the lists are polled.
+	Interval time.Duration
+
+	// TrendingHook gets run when the repository enters or leaves a list.
+	TrendingHook func(Trending) error
+
+	periods   []string
+	languages []string
+	baseURL   string
+	client    *http.Client
+	editors   []RequestEditor
+	clock     Clock
+	log       Logger
+	stopCh    chan struct{}
+
+	// ranks are the places of the repository on the lists it was on at
+	// the last poll of each, by list, and polled the lists polled so far.
+	ranks  map[trendingList]int
+	polled map[trendingList]bool
+}
+
+// NewTrendingWatcher returns a watcher that polls the daily and weekly
+// GitHub Trending lists of every language every interval for repo. Lists
+// for particular languages can be watched too with WithTrendingLanguages.
+func NewTrendingWatcher(
+	repo string,
+	interval time.Duration,
+	options ...func(*TrendingWatcher)) (*TrendingWatcher, error) {
+
+	if _, err := ParseRepo(repo); err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, errors.New("trending interval must be positive")
+	}
+	tw := &TrendingWatcher{
+		Repository: repo,
+		Interval:   interval,
+		periods:    []string{TrendingDaily, TrendingWeekly},
+		baseURL:    "https://github.com",
+		client:     &http.Client{Timeout: 20 * time.Second},
+		clock:      realClock{},
+		log:        nopLogger{},
+		stopCh:     make(chan struct{}, 1),
+		ranks:      make(map[trendingList]int),
+		polled:     make(map[trendingList]bool),
+	}
+	for _, o := range options {
+		o(tw)
+	}
+	if len(tw.periods) == 0 {
+		return nil, errors.New("at least one trending period must be specified")
+	}
+	for _, since := range tw.periods {
+		if since != TrendingDaily && since != TrendingWeekly && since != TrendingMonthly {
+			return nil, errors.Errorf("invalid trending period %q: must be daily, weekly or monthly", since)
+		}
+	}
+	for _, lang := range tw.languages {
+		if lang == "" {
+			return nil, errors.New("trending languages must not be empty")
+		}
+	}
+	if _, err := url.Parse(tw.baseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid GitHub Trending base URL")
+	}
+	return tw, nil
+}
+
+// WithTrendingHook is an option that can be passed to NewTrendingWatcher to
+// set the hook run when the repository enters or leaves a list.
+func WithTrendingHook(hook func(Trending) error) func(*TrendingWatcher) {
+	return func(tw *TrendingWatcher) {
+		tw.TrendingHook = hook
+	}
+}
+
+// WithTrendingPeriods is an option that can be passed to NewTrendingWatcher
+// to watch the lists for periods, such as TrendingDaily, rather than the
+// daily and weekly ones.
+func WithTrendingPeriods(periods ...string) func(*TrendingWatcher) {
+	return func(tw *TrendingWatcher) {
+		tw.periods = periods
+	}
+}
+
+// WithTrendingLanguages is an option that can be passed to
+// NewTrendingWatcher to also watch the lists for languages, named as GitHub
+// names them in the address of their lists, such as "go" or "c++".
+func WithTrendingLanguages(languages ...string) func(*TrendingWatcher) {
+	return func(tw *TrendingWatcher) {
+		tw.languages = append(tw.languages, languages...)
+	}
+}
+
+// WithTrendingBaseURL is an option that can be passed to NewTrendingWatcher
+// to read the lists from another base URL than https://github.com.
+func WithTrendingBaseURL(baseURL string) func(*TrendingWatcher) {
+	return func(tw *TrendingWatcher) {
+		tw.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithTrendingHTTPClient is an option that can be passed to
+// NewTrendingWatcher to make its requests with client instead of a default
+// client with a 20 second timeout.
+func WithTrendingHTTPClient(client *http.Client) func(*TrendingWatcher) {
+	return func(tw *TrendingWatcher) {
+		if client != nil {
+			tw.client = client
+		}
+	}
+}
+
+// WithTrendingRequestEditor is an option that can be passed to
+// NewTrendingWatcher to have editors edit every request it makes, in order,
+// after its User-Agent is set.
+func WithTrendingRequestEditor(editors ...RequestEditor) func(*TrendingWatcher) {
+	return func(tw *TrendingWatcher) {
+		tw.editors = append(tw.editors, editors...)
+	}
+}
+
+// WithTrendingClock is an option that can be passed to NewTrendingWatcher to
+// have it tell the time with clock rather than the system clock.
+func WithTrendingClock(clock Clock) func(*TrendingWatcher) {
+	return func(tw *TrendingWatcher) {
+		tw.clock = clock
+	}
+}
+
+// WithTrendingLogger is an option that can be passed to NewTrendingWatcher to
+// set its logger.
+func WithTrendingLogger(logger Logger) func(*TrendingWatcher) {
+	return func(tw *TrendingWatcher) {
+		tw.log = logger
+	}
+}
+
+// Watch polls the lists until Stop is called.
+func (tw *TrendingWatcher) Watch() {
+	tw.log.Infow("watching GitHub Trending",
+		"repo", tw.Repository,
+		"poll_interval", tw.Interval)
+	tw.poll()
+	t := time.NewTicker(tw.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			tw.poll()
+		case <-tw.stopCh:
+			return
+		}
+	}
+}
+
+// Stop stops the watcher.
+func (tw *TrendingWatcher) Stop() {
+	select {
+	case tw.stopCh <- struct{}{}:
+	default:
+	}
+}
+
+// lists returns the lists watched: those of every language, and of each
+// language asked for, for each period.
+func (tw *TrendingWatcher) lists() []trendingList {
+	var lists []trendingList
+	for _, since := range tw.periods {
+		lists = append(lists, trendingList{since: since})
+		for _, lang := range tw.languages {
+			lists = append(lists, trendingList{since: since, language: lang})
+		}
+	}
+	return lists
+}
+
+// poll reads each list and runs the hook if the repository has entered or
+// left it since the last poll. A list that can't be read is skipped until
+// the next poll.
+func (tw *TrendingWatcher) poll() {
+	for _, list := range tw.lists() {
+		rank, err := tw.rank(list)
+		if err != nil {
+			tw.log.Infow("error reading GitHub Trending",
+				"repo", tw.Repository,
+				"since", list.since,
+				"language", list.language,
+				"err", err)
+			continue
+		}
+		previous, first := tw.ranks[list], !tw.polled[list]
+		tw.polled[list] = true
+		tw.ranks[list] = rank
+		if first || (rank > 0) == (previous > 0) {
+			continue
+		}
+		t := Trending{
+			Repository: tw.Repository,
+			Time:       tw.clock.Now(),
+			Since:      list.since,
+			Language:   list.language,
+			Entered:    rank > 0,
+			Rank:       rank,
+		}
+		if t.Entered {
+			tw.log.Infow("repository entered GitHub Trending",
+				"repo", tw.Repository,
+				"since", list.since,
+				"language", list.language,
+				"rank", rank)
+		} else {
+			tw.log.Infow("repository left GitHub Trending",
+				"repo", tw.Repository,
+				"since", list.since,
+				"language", list.language)
+		}
+		if tw.TrendingHook == nil {
+			continue
+		}
+		if err := tw.TrendingHook(t); err != nil {
+			tw.log.Infow("error calling trending hook function",
+				"repo", tw.Repository, "err", err)
+		}
+	}
+}
+
+// rank reads list and returns the place of the repository on it, from 1, or
+// 0 if it isn't on it.
+func (tw *TrendingWatcher) rank(list trendingList) (int, error) {
+	endpoint := tw.baseURL + "/trending"
+	if list.language != "" {
+		endpoint += "/" + url.PathEscape(strings.ToLower(list.language))
+	}
+	endpoint += "?since=" + list.since
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "text/html")
+	body, err := getBody(tw.client, tw.editors, req, "GitHub Trending")
+	if err != nil {
+		return 0, err
+	}
+	matches := trendingRepoPattern.FindAllSubmatch(body, -1)
+	for i, m := range matches {
+		if strings.EqualFold(string(m[1]), tw.Repository) {
+			return i + 1, nil
+		}
+	}
+	return 0, nil
+}
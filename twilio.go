@@ -10,7 +10,6 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
-	"go.uber.org/zap"
 )
 
 // TwilioSMSSender sends SMS messages.
@@ -25,9 +24,12 @@ type TwilioSMSSender struct {
 	// This must be a phone number set up in your Twilio account.
 	Sender string
 
-	apiBaseURL string
-	client     *http.Client
-	log        *zap.SugaredLogger
+	apiBaseURL      string
+	client          *http.Client
+	editors         []RequestEditor
+	log             Logger
+	metrics         Metrics
+	authTokenSource TokenSource
 }
 
 // NewTwilioSMSSender returns a new SMS sender with the
@@ -35,59 +37,125 @@ func NewTwilioSMSSender(
 	sid, authToken, sender string,
 	options ...func(*TwilioSMSSender)) (*TwilioSMSSender, error) {
 
-	if sid == "" {
-		return nil, errors.New("Twilio account SID must be specified")
-	}
-	if authToken == "" {
-		return nil, errors.New("Twilio auth token must be specified")
-	}
-	if sender == "" {
-		return nil, errors.New("sender phone number must be specified")
-	}
 	const twilioAPIBaseURL = "https://api.twilio.com/2010-04-01"
 	ts := &TwilioSMSSender{
 		AccountSID: sid,
 		AuthToken:  authToken,
 		Sender:     sender,
-		log:        zap.NewNop().Sugar(),
+		log:        nopLogger{},
+		metrics:    nopMetrics{},
 		client:     &http.Client{Timeout: 20 * time.Second},
 		apiBaseURL: twilioAPIBaseURL,
 	}
 	for _, o := range options {
 		o(ts)
 	}
+	if sid == "" {
+		return nil, errors.New("Twilio account SID must be specified")
+	}
+	if authToken == "" && ts.authTokenSource == nil {
+		return nil, errors.New("Twilio auth token must be specified")
+	}
+	if sender == "" {
+		return nil, errors.New("sender phone number must be specified")
+	}
+	if _, err := url.Parse(ts.apiBaseURL); err != nil {
+		return nil, errors.Wrap(err, "invalid Twilio API base URL")
+	}
 	return ts, nil
 }
 
 // WithTwilioLogger is an option that can be passed to NewTwilioSMSSender to
-// set the *zap.SugaredLogger that the TwilioSMSSender will use internally.  If
+// set the Logger that the TwilioSMSSender will use internally.  If
 // this option is not passed to NewTwilioSMSSender, a no-op log will be used
 // internally.
-func WithTwilioLogger(logger *zap.SugaredLogger) func(*TwilioSMSSender) {
+func WithTwilioLogger(logger Logger) func(*TwilioSMSSender) {
 	return func(sg *TwilioSMSSender) {
 		sg.log = logger
 	}
 }
 
+// WithTwilioAuthTokenSource is an option that can be passed to
+// NewTwilioSMSSender to have the Twilio auth token supplied by source each
+// time a message is sent, instead of using AuthToken.
+func WithTwilioAuthTokenSource(source TokenSource) func(*TwilioSMSSender) {
+	return func(ts *TwilioSMSSender) {
+		ts.authTokenSource = source
+	}
+}
+
+// WithTwilioBaseURL is an option that can be passed to NewTwilioSMSSender to
+// send messages through another implementation of the Twilio API, such as
+// twiliotest's, instead of api.twilio.com. baseURL is the root of the API,
+// without the version.
+func WithTwilioBaseURL(baseURL string) func(*TwilioSMSSender) {
+	return func(ts *TwilioSMSSender) {
+		ts.apiBaseURL = strings.TrimRight(baseURL, "/") + "/2010-04-01"
+	}
+}
+
+// WithTwilioHTTPClient is an option that can be passed to NewTwilioSMSSender
+// to make requests to the Twilio API with client, for example one that is
+// instrumented or goes through a proxy, instead of a default client with a
+// 20 second timeout.
+func WithTwilioHTTPClient(client *http.Client) func(*TwilioSMSSender) {
+	return func(ts *TwilioSMSSender) {
+		if client != nil {
+			ts.client = client
+		}
+	}
+}
+
+// WithTwilioRequestEditor is an option that can be passed to
+// NewTwilioSMSSender to have editors edit every request made to the Twilio
+// API, in order, after its User-Agent is set.
+func WithTwilioRequestEditor(editors ...RequestEditor) func(*TwilioSMSSender) {
+	return func(ts *TwilioSMSSender) {
+		ts.editors = append(ts.editors, editors...)
+	}
+}
+
 // Send sends message to phone number 'to' in an SMS.
 func (ts TwilioSMSSender) Send(to, message string) error {
-	req, err := ts.makeFormRequest(to, message)
+	return ts.SendMedia(to, message)
+}
+
+// SendMedia sends message to phone number 'to' with the images at mediaURLs
+// attached, in an MMS. Twilio fetches the images from the URLs, so they must
+// be publicly reachable. With no URLs, it sends an SMS as Send does.
+func (ts TwilioSMSSender) SendMedia(to, message string, mediaURLs ...string) error {
+	err := ts.send(to, message, mediaURLs)
+	result := "sent"
+	if err != nil {
+		result = "failed"
+	}
+	ts.metrics.Counter(MetricSMS, 1, "result", result)
+	return err
+}
+
+func (ts TwilioSMSSender) send(to, message string, mediaURLs []string) error {
+	req, err := ts.makeFormRequest(to, message, mediaURLs)
 	if err != nil {
 		return err
 	}
+	if err := editRequest(req, ts.editors); err != nil {
+		return err
+	}
+	start := time.Now()
 	resp, err := ts.client.Do(req)
+	observeRequest(ts.metrics, MetricTwilioRequests, MetricTwilioRequestDuration, time.Since(start), resp)
 	if err != nil {
-		return errors.Wrap(err, "error reaching Twilio API")
+		return fmt.Errorf("error reaching Twilio API: %w", err)
 	}
 
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return decodeTwilioAPIError(resp)
+	}
 	apiResponse, err := decodeTwilioAPIResponse(resp.Body)
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("Twilio error %d: %s", apiResponse.ErrCode, apiResponse.ErrMessage)
-	}
 	if isNotOKMessageStatus(apiResponse.MessageStatus) {
 		return fmt.Errorf("bad message status: %s", apiResponse.MessageStatus)
 	}
@@ -107,6 +175,28 @@ type twilioAPIResponse struct {
 	ErrMessage    string `json:"error_message"`
 }
 
+// twilioAPIError is the body of a response refusing a request.
+type twilioAPIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// decodeTwilioAPIError returns the *APIError for resp, with Twilio's error
+// code if the body has one.
+func decodeTwilioAPIError(resp *http.Response) error {
+	err := &APIError{
+		API:        "Twilio",
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		URL:        resp.Request.URL.String(),
+	}
+	var apiErr twilioAPIError
+	if json.NewDecoder(resp.Body).Decode(&apiErr) == nil {
+		err.Code, err.Message = apiErr.Code, apiErr.Message
+	}
+	return err
+}
+
 func decodeTwilioAPIResponse(r io.Reader) (*twilioAPIResponse, error) {
 	response := &twilioAPIResponse{}
 	d := json.NewDecoder(r)
@@ -116,18 +206,27 @@ func decodeTwilioAPIResponse(r io.Reader) (*twilioAPIResponse, error) {
 	return response, nil
 }
 
-func (ts TwilioSMSSender) makeFormRequest(to, message string) (*http.Request, error) {
+func (ts TwilioSMSSender) makeFormRequest(to, message string, mediaURLs []string) (*http.Request, error) {
 	values := url.Values{}
 	values.Set("From", ts.Sender)
 	values.Set("To", to)
 	values.Set("Body", message)
+	for _, mediaURL := range mediaURLs {
+		values.Add("MediaUrl", mediaURL)
+	}
 
 	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", ts.apiBaseURL, ts.AccountSID)
 	req, err := http.NewRequest("POST", endpoint, strings.NewReader(values.Encode()))
 	if err != nil {
 		return nil, err
 	}
-	req.SetBasicAuth(ts.AccountSID, ts.AuthToken)
+	authToken := ts.AuthToken
+	if ts.authTokenSource != nil {
+		if authToken, err = ts.authTokenSource(); err != nil {
+			return nil, errors.Wrap(err, "error getting Twilio auth token")
+		}
+	}
+	req.SetBasicAuth(ts.AccountSID, authToken)
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Add("Accept", "application/json")
 	return req, nil
@@ -0,0 +1,195 @@
+// Package twiliotest provides a fake Twilio Messages API for testing the
+// notification path without Twilio credentials. It records the messages sent
+// through it, and can refuse them with Twilio's error codes or accept them
+// with a status other than queued:
+//
+//	srv := twiliotest.NewServer()
+//	defer srv.Close()
+//	ts, err := stargazer.NewTwilioSMSSender("AC123", "token", "+15005550006",
+//		stargazer.WithTwilioBaseURL(srv.URL))
+//	...
+//	srv.FailNext(1, http.StatusBadRequest, 21211, "The 'To' number is not a valid phone number.")
+package twiliotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Message is a message sent through the fake API.
+type Message struct {
+	SID        string
+	AccountSID string
+	From       string
+	To         string
+	Body       string
+	Status     string
+	Time       time.Time
+
+	// MediaURLs are the URLs of the images attached to an MMS.
+	MediaURLs []string
+}
+
+// Server is a fake Twilio Messages API. Its URL can be given to
+// stargazer.WithTwilioBaseURL. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	messages []Message
+	sid      string
+	token    string
+	status   string
+	failures []failure
+}
+
+// failure is how a request that is to fail is refused.
+type failure struct {
+	status  int
+	code    int
+	message string
+}
+
+// NewServer starts a fake Twilio API that accepts every message with any
+// credentials, queueing it. It must be closed with Close.
+func NewServer() *Server {
+	s := &Server{status: "queued"}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// RequireAuth has the API refuse requests that aren't authenticated with
+// the account SID sid and auth token token, as Twilio does with error 20003.
+func (s *Server) RequireAuth(sid, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sid, s.token = sid, token
+}
+
+// FailNext has the API refuse the next n messages with the HTTP status and
+// Twilio error code and message given, such as 400 and 21211 for an invalid
+// To number, or 429 and 20429 for too many requests. Refused messages aren't
+// recorded.
+func (s *Server) FailNext(n, status, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, failure{status: status, code: code, message: message})
+	}
+}
+
+// SetStatus sets the status that accepted messages are given, such as
+// "failed" or "undelivered" to simulate a message that is accepted but not
+// OK. The default is "queued".
+func (s *Server) SetStatus(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// Messages returns the messages that have been accepted, oldest first.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Reset forgets the messages that have been accepted and any failures still
+// to come.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+	s.failures = nil
+}
+
+var messagesPath = regexp.MustCompile(`^/2010-04-01/Accounts/([^/]+)/Messages\.json$`)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := messagesPath.FindStringSubmatch(r.URL.Path)
+	if m == nil {
+		writeError(w, http.StatusNotFound, 20404, "The requested resource "+r.URL.Path+" was not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, 20004, "Method not allowed")
+		return
+	}
+	sid, token, ok := r.BasicAuth()
+	if !ok || sid != m[1] || (s.sid != "" && (sid != s.sid || token != s.token)) {
+		writeError(w, http.StatusUnauthorized, 20003, "Authenticate")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, 20001, "Invalid form")
+		return
+	}
+	to, from, body := r.PostForm.Get("To"), r.PostForm.Get("From"), r.PostForm.Get("Body")
+	media := r.PostForm["MediaUrl"]
+	switch {
+	case to == "":
+		writeError(w, http.StatusBadRequest, 21604, "A 'To' phone number is required.")
+		return
+	case from == "":
+		writeError(w, http.StatusBadRequest, 21603, "A 'From' phone number is required.")
+		return
+	case body == "" && len(media) == 0:
+		writeError(w, http.StatusBadRequest, 21602, "Message body is required.")
+		return
+	}
+	if len(s.failures) > 0 {
+		f := s.failures[0]
+		s.failures = s.failures[1:]
+		writeError(w, f.status, f.code, f.message)
+		return
+	}
+	msg := Message{
+		SID:        fmt.Sprintf("SM%032x", len(s.messages)+1),
+		AccountSID: sid,
+		From:       from,
+		To:         to,
+		Body:       body,
+		Status:     s.status,
+		Time:       time.Now(),
+		MediaURLs:  media,
+	}
+	s.messages = append(s.messages, msg)
+	resp := map[string]interface{}{
+		"sid":          msg.SID,
+		"account_sid":  msg.AccountSID,
+		"from":         msg.From,
+		"to":           msg.To,
+		"body":         msg.Body,
+		"status":       msg.Status,
+		"date_created": msg.Time.UTC().Format(time.RFC1123Z),
+		"num_media":    fmt.Sprint(len(msg.MediaURLs)),
+	}
+	if msg.Status == "failed" || msg.Status == "undelivered" {
+		resp["error_code"] = 30003
+		resp["error_message"] = "Unreachable destination handset"
+	}
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// writeError writes an error response as Twilio does.
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"code":      code,
+		"message":   message,
+		"more_info": fmt.Sprintf("https://www.twilio.com/docs/errors/%d", code),
+		"status":    status,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,69 @@
+package stargazer
+
+import (
+	"sync"
+	"time"
+)
+
+// Velocity is the rate at which a repository has been gaining stargazers over
+// the gazer's velocity window.
+type Velocity struct {
+	PerHour float64 `json:"stars_per_hour"`
+	PerDay  float64 `json:"stars_per_day"`
+}
+
+// Sample is a stargazers count observed at a point in time.
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+// velocityTracker keeps a rolling window of stargazer count samples from
+// which the star velocity can be computed. It is safe for concurrent use.
+type velocityTracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples []Sample
+}
+
+func newVelocityTracker(window time.Duration) *velocityTracker {
+	return &velocityTracker{window: window}
+}
+
+// record adds a sample to the window and drops any samples that have aged
+// out of it.
+func (vt *velocityTracker) record(at time.Time, count int) {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	vt.samples = append(vt.samples, Sample{Time: at, Count: count})
+	cutoff := at.Add(-vt.window)
+	i := 0
+	for i < len(vt.samples)-1 && vt.samples[i].Time.Before(cutoff) {
+		i++
+	}
+	vt.samples = vt.samples[i:]
+}
+
+// velocity computes the rate of change between the oldest and newest samples
+// in the window. Fewer than two samples yields a zero velocity.
+func (vt *velocityTracker) velocity() Velocity {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	if len(vt.samples) < 2 {
+		return Velocity{}
+	}
+	first, last := vt.samples[0], vt.samples[len(vt.samples)-1]
+	elapsed := last.Time.Sub(first.Time)
+	if elapsed <= 0 {
+		return Velocity{}
+	}
+	perHour := float64(last.Count-first.Count) / elapsed.Hours()
+	return Velocity{PerHour: perHour, PerDay: perHour * 24}
+}
+
+// history returns a copy of the samples in the window, oldest first.
+func (vt *velocityTracker) history() []Sample {
+	vt.mu.Lock()
+	defer vt.mu.Unlock()
+	return append([]Sample(nil), vt.samples...)
+}
@@ -0,0 +1,95 @@
+package stargazer
+
+import "time"
+
+// Stall describes a gazer that hasn't fetched the stargazers count
+// successfully for longer than its watchdog allows, such as because its token
+// has been revoked or its network is down.
+type Stall struct {
+	Repository string
+	Time       time.Time
+
+	// LastSuccess is when the count was last fetched, or zero if it hasn't
+	// been since the gazer started gazing, and For how long it has been
+	// since then, or since the gazer started or was resumed.
+	LastSuccess time.Time
+	For         time.Duration
+
+	// Err is why the last fetch failed, and ConsecutiveFailures how many
+	// have failed in a row. Err is nil if polls have been skipped rather
+	// than failing, as they are while the circuit breaker is open.
+	Err                 error
+	ConsecutiveFailures int
+}
+
+// WithWatchdog is an option that can be passed to NewGitHubStargazer to have
+// hook run when the stargazers count hasn't been fetched for after, so that
+// a silently broken token or network doesn't mean silently missed milestones.
+// The watchdog is checked at each poll while the gazer isn't paused, and
+// hook runs once for each stall, until a fetch succeeds again.
+func WithWatchdog(after time.Duration, hook func(Stall) error) func(*GitHubStargazer) {
+	return func(sg *GitHubStargazer) {
+		sg.watchdogAfter = after
+		sg.StallHook = hook
+	}
+}
+
+// resetWatchdog starts the time that the watchdog allows for a successful
+// fetch over from now, as it does when the gazer starts gazing or is
+// resumed.
+func (sg *GitHubStargazer) resetWatchdog() {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	sg.watchdogFrom = sg.clock.Now()
+}
+
+// checkWatchdog runs the stall hook if the count hasn't been fetched for
+// longer than the watchdog allows and it hasn't already run for this stall,
+// and rearms it once a fetch has succeeded.
+func (sg *GitHubStargazer) checkWatchdog() {
+	if sg.watchdogAfter <= 0 {
+		return
+	}
+	sg.mu.Lock()
+	now := sg.clock.Now()
+	from := sg.watchdogFrom
+	if sg.lastSuccess.After(from) {
+		from = sg.lastSuccess
+	}
+	if sg.stalled && sg.lastSuccess.After(sg.stalledAt) {
+		sg.stalled = false
+		sg.mu.Unlock()
+		sg.log.Infow("fetching stargazers count again after stall", "repo", sg.Repository)
+		return
+	}
+	if sg.stalled || now.Sub(from) < sg.watchdogAfter {
+		sg.mu.Unlock()
+		return
+	}
+	sg.stalled, sg.stalledAt = true, now
+	s := Stall{
+		Repository:          sg.Repository,
+		Time:                now,
+		LastSuccess:         sg.lastSuccess,
+		For:                 now.Sub(from),
+		Err:                 sg.lastErr,
+		ConsecutiveFailures: sg.failures,
+	}
+	sg.mu.Unlock()
+	sg.log.Warnw("stargazers count hasn't been fetched for too long",
+		"repo", sg.Repository,
+		"last_success", s.LastSuccess,
+		"for", s.For,
+		"consecutive_failures", s.ConsecutiveFailures)
+	sg.emit(Stalled{s})
+	if sg.StallHook == nil {
+		return
+	}
+	err := sg.StallHook(s)
+	if err != nil {
+		sg.log.Infow("error calling stall hook function",
+			"repo", sg.Repository,
+			"err", err)
+	}
+	sg.hookDone("stall", err)
+}